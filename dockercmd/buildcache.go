@@ -0,0 +1,78 @@
+package dockercmd
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// lists build cache records, only the build cache is queried since `DiskUsage` is expensive otherwise
+func (dc *DockerClient) ListBuildCache() ([]*types.BuildCache, error) {
+	usage, err := dc.cli.DiskUsage(context.Background(), types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.BuildCacheObject},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return usage.BuildCache, nil
+}
+
+type BuildCachePruneOpts struct {
+	// prunes all cache records instead of only dangling/unused ones
+	All bool
+	// amount of disk space (in bytes) to keep for cache
+	KeepStorage int64
+	// only prune cache records older than this duration (eg: 24h), empty means no age filter
+	Until string
+	// only prune records with these IDs
+	Ids []string
+}
+
+// choices of the prune dialog: storage to keep in bytes, and an age that disables the age filter
+var BuildCacheKeepStorage = map[string]int64{
+	"0":    0,
+	"1GB":  1e+9,
+	"5GB":  5e+9,
+	"10GB": 10e+9,
+	"20GB": 20e+9,
+}
+
+const BuildCacheAnyAge = "any age"
+
+// Prune options from the prune dialog's choices, keepStorage is a key of BuildCacheKeepStorage and until a duration
+// (eg: 24h) or BuildCacheAnyAge
+func MakeBuildCachePruneOpts(all bool, keepStorage string, until string) BuildCachePruneOpts {
+	opts := BuildCachePruneOpts{
+		All:         all,
+		KeepStorage: BuildCacheKeepStorage[keepStorage],
+	}
+	if until != BuildCacheAnyAge {
+		opts.Until = until
+	}
+	return opts
+}
+
+func (dc *DockerClient) PruneBuildCache(opts BuildCachePruneOpts) (*types.BuildCachePruneReport, error) {
+	return dc.cli.BuildCachePrune(context.Background(), buildCachePruneOptions(opts))
+}
+
+func buildCachePruneOptions(opts BuildCachePruneOpts) types.BuildCachePruneOptions {
+	pruneFilters := filters.NewArgs()
+
+	if opts.Until != "" {
+		pruneFilters.Add("until", opts.Until)
+	}
+
+	for _, id := range opts.Ids {
+		pruneFilters.Add("id", id)
+	}
+
+	return types.BuildCachePruneOptions{
+		All:         opts.All,
+		KeepStorage: opts.KeepStorage,
+		Filters:     pruneFilters,
+	}
+}
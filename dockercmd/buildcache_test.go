@@ -0,0 +1,62 @@
+package dockercmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBuildCachePruneOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      BuildCachePruneOpts
+		wantAll   bool
+		wantKeep  int64
+		wantUntil []string
+		wantIds   []string
+	}{
+		{
+			name: "unused records of any age",
+			opts: MakeBuildCachePruneOpts(false, "0", BuildCacheAnyAge),
+		},
+		{
+			name:      "all records older than a day",
+			opts:      MakeBuildCachePruneOpts(true, "0", "24h"),
+			wantAll:   true,
+			wantUntil: []string{"24h"},
+		},
+		{
+			name:      "keep storage",
+			opts:      MakeBuildCachePruneOpts(false, "5GB", "168h"),
+			wantKeep:  5e+9,
+			wantUntil: []string{"168h"},
+		},
+		{
+			name:     "keep storage of any age",
+			opts:     MakeBuildCachePruneOpts(true, "20GB", BuildCacheAnyAge),
+			wantAll:  true,
+			wantKeep: 20e+9,
+		},
+		{
+			name:    "single record",
+			opts:    BuildCachePruneOpts{All: true, Ids: []string{"abc123"}},
+			wantAll: true,
+			wantIds: []string{"abc123"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildCachePruneOptions(tt.opts)
+
+			if got.All != tt.wantAll || got.KeepStorage != tt.wantKeep {
+				t.Errorf("got all=%v keepStorage=%d, want all=%v keepStorage=%d", got.All, got.KeepStorage, tt.wantAll, tt.wantKeep)
+			}
+			if until := got.Filters.Get("until"); !slices.Equal(until, tt.wantUntil) {
+				t.Errorf("got until filter %v, want %v", until, tt.wantUntil)
+			}
+			if ids := got.Filters.Get("id"); !slices.Equal(ids, tt.wantIds) {
+				t.Errorf("got id filter %v, want %v", ids, tt.wantIds)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package dockercmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// labels set by docker compose on every container it creates
+const (
+	ComposeProjectLabel         = "com.docker.compose.project"
+	ComposeServiceLabel         = "com.docker.compose.service"
+	ComposeContainerNumberLabel = "com.docker.compose.container-number"
+	ComposeWorkingDirLabel      = "com.docker.compose.project.working_dir"
+	ComposeConfigFilesLabel     = "com.docker.compose.project.config_files"
+)
+
+// Lists all containers (running or not) belonging to the compose project
+func (dc *DockerClient) ComposeProjectContainers(project string) ([]types.Container, error) {
+	return dc.cli.ContainerList(context.Background(), container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", ComposeProjectLabel+"="+project)),
+	})
+}
+
+// Lists all containers (running or not) belonging to any compose project, grouped by project name
+func (dc *DockerClient) ComposeContainers() (map[string][]types.Container, error) {
+	list, err := dc.cli.ContainerList(context.Background(), container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", ComposeProjectLabel)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GroupByComposeProject(list), nil
+}
+
+// groups the containers by compose project name, containers that are not part of a project are left out
+func GroupByComposeProject(list []types.Container) map[string][]types.Container {
+	res := make(map[string][]types.Container)
+	for _, c := range list {
+		if project := c.Labels[ComposeProjectLabel]; project != "" {
+			res[project] = append(res[project], c)
+		}
+	}
+	return res
+}
+
+// Starts every container of the project that is not running
+func (dc *DockerClient) StartComposeProject(project string) error {
+	return dc.forEachComposeContainer(project, func(c types.Container) error {
+		if c.State == "running" {
+			return nil
+		}
+		return dc.cli.ContainerStart(context.Background(), c.ID, container.StartOptions{})
+	})
+}
+
+// Stops every running container of the project
+func (dc *DockerClient) StopComposeProject(project string) error {
+	return dc.forEachComposeContainer(project, func(c types.Container) error {
+		if c.State != "running" {
+			return nil
+		}
+		return dc.cli.ContainerStop(context.Background(), c.ID, container.StopOptions{})
+	})
+}
+
+func (dc *DockerClient) RestartComposeProject(project string) error {
+	return dc.forEachComposeContainer(project, func(c types.Container) error {
+		return dc.cli.ContainerRestart(context.Background(), c.ID, container.StopOptions{})
+	})
+}
+
+func (dc *DockerClient) RemoveComposeProject(project string, opts container.RemoveOptions) error {
+	return dc.forEachComposeContainer(project, func(c types.Container) error {
+		return dc.cli.ContainerRemove(context.Background(), c.ID, opts)
+	})
+}
+
+// runs fn for every container of the project, one failing container does not stop the others
+func (dc *DockerClient) forEachComposeContainer(project string, fn func(c types.Container) error) error {
+	containers, err := dc.ComposeProjectContainers(project)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, c := range containers {
+		if err := fn(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type ComposeAction string
+
+const (
+	ComposeUp      ComposeAction = "up"
+	ComposeDown    ComposeAction = "down"
+	ComposeRestart ComposeAction = "restart"
+	ComposePull    ComposeAction = "pull"
+)
+
+var ComposeActions = []ComposeAction{ComposeUp, ComposeDown, ComposeRestart, ComposePull}
+
+// Options for a `docker compose` invocation. There is no compose implementation in the engine, so we shell out to the
+// compose plugin, same as exec does with the docker cli.
+type ComposeOpts struct {
+	Action ComposeAction
+	// compose files, the project directory defaults to the directory of the first one
+	ConfigFiles []string
+	// optional, defaults to the project directory name
+	ProjectName string
+	// limit the action to these services, all services when empty
+	Services []string
+	// `up` only: replica count per service
+	Scale map[string]string
+	// `up` only: recreate containers even if their configuration did not change
+	ForceRecreate bool
+	// `down` only: remove named volumes declared in the file
+	RemoveVolumes bool
+	// `up` and `down`: remove containers of services that are no longer in the file
+	RemoveOrphans bool
+}
+
+// builds the arguments passed to `docker`
+func ComposeArgs(opts ComposeOpts) ([]string, error) {
+	if !slices.Contains(ComposeActions, opts.Action) {
+		return nil, fmt.Errorf("unknown compose action %q", opts.Action)
+	}
+
+	// plain output, we are not attached to a terminal
+	args := []string{"compose", "--ansi", "never"}
+
+	for _, file := range opts.ConfigFiles {
+		args = append(args, "--file", file)
+	}
+
+	if opts.ProjectName != "" {
+		args = append(args, "--project-name", opts.ProjectName)
+	}
+
+	args = append(args, string(opts.Action))
+
+	switch opts.Action {
+	case ComposeUp:
+		// compose only recreates services whose configuration changed, unless told otherwise
+		args = append(args, "--detach")
+		if opts.RemoveOrphans {
+			args = append(args, "--remove-orphans")
+		}
+		if opts.ForceRecreate {
+			args = append(args, "--force-recreate")
+		}
+
+		scaled := make([]string, 0, len(opts.Scale))
+		for service, replicas := range opts.Scale {
+			scaled = append(scaled, service+"="+replicas)
+		}
+		slices.Sort(scaled)
+		for _, s := range scaled {
+			args = append(args, "--scale", s)
+		}
+
+	case ComposeDown:
+		if len(opts.Services) > 0 {
+			return nil, errors.New("down always applies to the whole project, use stop/remove on single containers instead")
+		}
+		if opts.RemoveOrphans {
+			args = append(args, "--remove-orphans")
+		}
+		if opts.RemoveVolumes {
+			args = append(args, "--volumes")
+		}
+	}
+
+	return append(args, opts.Services...), nil
+}
+
+// Runs `docker compose`, combined stdout and stderr are written to output
+func (dc *DockerClient) RunCompose(opts ComposeOpts, output io.Writer) error {
+	args, err := ComposeArgs(opts)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker %s: %w", opts.Action, err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package dockercmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestComposeArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ComposeOpts
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "up with scale",
+			opts: ComposeOpts{
+				Action:      ComposeUp,
+				ConfigFiles: []string{"/srv/app/compose.yaml", "/srv/app/compose.override.yaml"},
+				ProjectName: "app",
+				Scale:       map[string]string{"worker": "3", "api": "2"},
+			},
+			want: []string{"compose", "--ansi", "never", "--file", "/srv/app/compose.yaml", "--file", "/srv/app/compose.override.yaml",
+				"--project-name", "app", "up", "--detach", "--scale", "api=2", "--scale", "worker=3"},
+		},
+		{
+			name: "force recreate single service",
+			opts: ComposeOpts{Action: ComposeUp, ConfigFiles: []string{"compose.yaml"}, Services: []string{"web"}, ForceRecreate: true, RemoveOrphans: true},
+			want: []string{"compose", "--ansi", "never", "--file", "compose.yaml", "up", "--detach", "--remove-orphans", "--force-recreate", "web"},
+		},
+		{
+			name: "down with volumes",
+			opts: ComposeOpts{Action: ComposeDown, ProjectName: "app", RemoveVolumes: true},
+			want: []string{"compose", "--ansi", "never", "--project-name", "app", "down", "--volumes"},
+		},
+		{
+			name: "down removing orphans",
+			opts: ComposeOpts{Action: ComposeDown, ProjectName: "app", RemoveOrphans: true},
+			want: []string{"compose", "--ansi", "never", "--project-name", "app", "down", "--remove-orphans"},
+		},
+		{
+			name:    "down with services",
+			opts:    ComposeOpts{Action: ComposeDown, Services: []string{"web"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown action",
+			opts:    ComposeOpts{Action: "rm"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ComposeArgs(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ComposeArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ComposeArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupByComposeProject(t *testing.T) {
+	list := []types.Container{
+		{ID: "web", Labels: map[string]string{ComposeProjectLabel: "shop"}},
+		{ID: "db", Labels: map[string]string{ComposeProjectLabel: "shop"}},
+		{ID: "grafana", Labels: map[string]string{ComposeProjectLabel: "monitoring"}},
+		{ID: "standalone"},
+	}
+
+	got := GroupByComposeProject(list)
+	if len(got) != 2 || len(got["shop"]) != 2 || len(got["monitoring"]) != 1 {
+		t.Errorf("got %v, want shop with 2 and monitoring with 1 container", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
@@ -39,6 +40,10 @@ func (dc *DockerClient) ToggleContainerListAll() {
 	dc.containerListArgs.All = !dc.containerListArgs.All
 }
 
+func (dc *DockerClient) IsListingAllContainers() bool {
+	return dc.containerListArgs.All
+}
+
 // Toggles running state of container
 func (dc *DockerClient) ToggleStartStopContainer(id string) error {
 	info, err := dc.cli.ContainerInspect(context.Background(), id)
@@ -106,3 +111,85 @@ func (dc *DockerClient) GetContainerLogs(id string) (io.ReadCloser, error) {
 
 	return rc, nil
 }
+
+// lists filesystem changes of the container relative to its image
+func (dc *DockerClient) ContainerDiff(id string) ([]LayerFile, error) {
+	changes, err := dc.cli.ContainerDiff(context.Background(), id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]LayerFile, len(changes))
+	for i, change := range changes {
+		res[i] = LayerFile{Path: strings.TrimPrefix(change.Path, "/")}
+
+		switch change.Kind {
+		case container.ChangeAdd:
+			res[i].Kind = FileAdded
+		case container.ChangeModify:
+			res[i].Kind = FileModified
+		case container.ChangeDelete:
+			res[i].Kind = FileRemoved
+		}
+	}
+
+	return res, nil
+}
+
+// Lists containers (running or not) created from the image
+func (dc *DockerClient) ContainersUsingImage(imageId string) ([]types.Container, error) {
+	containers, err := dc.cli.ContainerList(context.Background(), container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	// the `ancestor` filter also matches images built on top of this one, so compare ids ourselves
+	res := make([]types.Container, 0)
+	for _, c := range containers {
+		if c.ImageID == imageId {
+			res = append(res, c)
+		}
+	}
+
+	return res, nil
+}
+
+// Writes the container's filesystem to a tarball at path, gzipped if path ends with .gz or .tgz. Volumes are not part
+// of the export.
+func (dc *DockerClient) ExportContainer(id string, path string, progress ProgressFunc) error {
+	rc, err := dc.cli.ContainerExport(context.Background(), id)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return writeArchive(rc, path, progress)
+}
+
+type CommitOpts struct {
+	// repo:tag of the new image, untagged if empty
+	Ref     string
+	Author  string
+	Message string
+	// pause the container while committing, so the filesystem is consistent
+	Pause bool
+	// Dockerfile instructions applied to the image, eg: CMD ["/bin/sh"]
+	Changes []string
+}
+
+// Creates an image from the container's changes, returns the new image id
+func (dc *DockerClient) CommitContainer(id string, opts CommitOpts) (string, error) {
+	res, err := dc.cli.ContainerCommit(context.Background(), id, container.CommitOptions{
+		Reference: opts.Ref,
+		Author:    opts.Author,
+		Comment:   opts.Message,
+		Pause:     opts.Pause,
+		Changes:   opts.Changes,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return res.ID, nil
+}
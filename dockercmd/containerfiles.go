@@ -0,0 +1,559 @@
+package dockercmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+type ContainerFile struct {
+	Name       string
+	Path       string
+	Size       int64
+	Mode       fs.FileMode
+	ModTime    time.Time
+	LinkTarget string
+}
+
+func (f ContainerFile) IsDir() bool {
+	return f.Mode.IsDir()
+}
+
+func (dc *DockerClient) StatContainerPath(id string, path string) (types.ContainerPathStat, error) {
+	return dc.cli.ContainerStatPath(context.Background(), id, path)
+}
+
+// Lists direct children of dir. The engine has no listing endpoint, so the names are listed with `ls` inside the
+// container and each child is stat'ed, directory sizes are unknown (-1). Containers that are not running (or have no
+// ls) fall back to streaming the directory as a tarball and reading the headers, which transfers everything below
+// dir but gives directory sizes as the sum of their content.
+func (dc *DockerClient) ListContainerDir(id string, dir string) ([]ContainerFile, error) {
+	stat, err := dc.cli.ContainerStatPath(context.Background(), id, dir)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.Mode.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	names, err := dc.listDirNames(id, dir)
+	if err != nil {
+		return dc.listContainerDirTar(id, dir)
+	}
+
+	return statContainerFiles(dir, names, func(p string) (types.ContainerPathStat, error) {
+		return dc.cli.ContainerStatPath(context.Background(), id, p)
+	}), nil
+}
+
+// names of dir's children, read from `ls` run inside the container
+func (dc *DockerClient) listDirNames(id string, dir string) ([]string, error) {
+	ctx := context.Background()
+
+	exec, err := dc.cli.ContainerExecCreate(ctx, id, types.ExecConfig{
+		Cmd:          []string{"ls", "-A1", "--", dir},
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := dc.cli.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, resp.Reader); err != nil {
+		return nil, err
+	}
+
+	inspect, err := dc.cli.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return nil, err
+	}
+	if inspect.ExitCode != 0 {
+		return nil, fmt.Errorf("ls %s: %s", dir, strings.TrimSpace(stderr.String()))
+	}
+
+	var names []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if line != "" {
+			names = append(names, line)
+		}
+	}
+	return names, nil
+}
+
+// stats the children of dir a few at a time, children removed in the meantime are left out
+func statContainerFiles(dir string, names []string, stat func(path string) (types.ContainerPathStat, error)) []ContainerFile {
+	files := make([]*ContainerFile, len(names))
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, 8)
+
+	for i, name := range names {
+		wg.Add(1)
+		limit <- struct{}{}
+
+		go func() {
+			defer func() { <-limit; wg.Done() }()
+
+			p := path.Join(dir, name)
+			info, err := stat(p)
+			if err != nil {
+				return
+			}
+
+			file := &ContainerFile{Name: name, Path: p, Size: info.Size, Mode: info.Mode, ModTime: info.Mtime}
+			if file.IsDir() {
+				file.Size = -1
+			}
+			if info.Mode&fs.ModeSymlink != 0 {
+				file.LinkTarget = info.LinkTarget
+			}
+			files[i] = file
+		}()
+	}
+	wg.Wait()
+
+	var res []ContainerFile
+	for _, file := range files {
+		if file != nil {
+			res = append(res, *file)
+		}
+	}
+	sortContainerFiles(res)
+	return res
+}
+
+func (dc *DockerClient) listContainerDirTar(id string, dir string) ([]ContainerFile, error) {
+	rc, _, err := dc.cli.CopyFromContainer(context.Background(), id, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return listTarDir(rc, dir)
+}
+
+func listTarDir(r io.Reader, dir string) ([]ContainerFile, error) {
+	children := make(map[string]*ContainerFile)
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// entries are prefixed with the base name of the requested directory, except for the root
+		rel := strings.Trim(path.Clean(hdr.Name), "/")
+		if path.Clean(dir) != "/" {
+			var found bool
+			if _, rel, found = strings.Cut(rel, "/"); !found {
+				continue
+			}
+		}
+
+		if rel == "" || rel == "." {
+			continue
+		}
+
+		name, nested, isNested := strings.Cut(rel, "/")
+		child, ok := children[name]
+		if !ok {
+			child = &ContainerFile{Name: name, Path: path.Join(dir, name), Mode: fs.ModeDir}
+			children[name] = child
+		}
+
+		if isNested && nested != "" {
+			child.Size += hdr.Size
+			continue
+		}
+
+		child.Mode = hdr.FileInfo().Mode()
+		child.ModTime = hdr.ModTime
+		child.LinkTarget = hdr.Linkname
+		if !child.IsDir() {
+			child.Size = hdr.Size
+		}
+	}
+
+	res := make([]ContainerFile, 0, len(children))
+	for _, child := range children {
+		res = append(res, *child)
+	}
+
+	sortContainerFiles(res)
+	return res, nil
+}
+
+// directories first, then by name
+func sortContainerFiles(files []ContainerFile) {
+	slices.SortFunc(files, func(a, b ContainerFile) int {
+		if a.IsDir() != b.IsDir() {
+			if a.IsDir() {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// Reads a single file from the container, at most limit bytes are returned. truncated is set when the file is bigger.
+func (dc *DockerClient) ReadContainerFile(id string, path string, limit int64) (content []byte, truncated bool, err error) {
+	rc, stat, err := dc.cli.CopyFromContainer(context.Background(), id, path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rc.Close()
+
+	if stat.Mode.IsDir() {
+		return nil, false, fmt.Errorf("%s is a directory", path)
+	}
+
+	// the content is always wrapped in a tarball
+	tr := tar.NewReader(rc)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if hdr.Typeflag == tar.TypeSymlink {
+		return []byte("symlink to " + hdr.Linkname), false, nil
+	}
+
+	content, err = io.ReadAll(io.LimitReader(tr, limit))
+	if err != nil {
+		return nil, false, err
+	}
+
+	return content, hdr.Size > limit, nil
+}
+
+var ErrContainerFileChanged = errors.New("file was changed inside the container after it was checked out")
+
+// local copy of a container file, created by CheckoutContainerFile
+type CheckedOutFile struct {
+	ContainerPath string
+	LocalPath     string
+	// original header, used to restore ownership and mode on write back
+	header   *tar.Header
+	checksum [sha256.Size]byte
+}
+
+// Copies a single file out of the container into a temp file, so it can be edited locally
+func (dc *DockerClient) CheckoutContainerFile(id string, containerPath string) (*CheckedOutFile, error) {
+	hdr, content, err := dc.readContainerFileWithHeader(id, containerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "gomanagedocker-edit-")
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the base name, so editors can pick the right syntax highlighting
+	localPath := filepath.Join(dir, path.Base(containerPath))
+	if err := os.WriteFile(localPath, content, 0600); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	return &CheckedOutFile{
+		ContainerPath: containerPath,
+		LocalPath:     localPath,
+		header:        hdr,
+		checksum:      sha256.Sum256(content),
+	}, nil
+}
+
+// Writes the local copy back if it was modified, preserving the original ownership and mode. Unless force is set,
+// ErrContainerFileChanged is returned when the file was changed inside the container in the meantime.
+func (dc *DockerClient) WriteBackContainerFile(id string, file *CheckedOutFile, force bool) (written bool, err error) {
+	content, err := os.ReadFile(file.LocalPath)
+	if err != nil {
+		return false, err
+	}
+
+	if sha256.Sum256(content) == file.checksum {
+		return false, nil
+	}
+
+	if !force {
+		_, current, err := dc.readContainerFileWithHeader(id, file.ContainerPath)
+		if err != nil {
+			return false, err
+		}
+
+		if sha256.Sum256(current) != file.checksum {
+			return false, ErrContainerFileChanged
+		}
+	}
+
+	hdr := *file.header
+	hdr.Name = path.Base(file.ContainerPath)
+	hdr.Size = int64(len(content))
+	hdr.ModTime = time.Now()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&hdr); err != nil {
+		return false, err
+	}
+	if _, err := tw.Write(content); err != nil {
+		return false, err
+	}
+	if err := tw.Close(); err != nil {
+		return false, err
+	}
+
+	// without CopyUIDGID the daemon applies the uid, gid and mode of the header, with it everything would be owned by
+	// the container's user
+	err = dc.cli.CopyToContainer(context.Background(), id, path.Dir(file.ContainerPath), &buf, types.CopyToContainerOptions{})
+
+	return err == nil, err
+}
+
+// Removes the local copy
+func (file *CheckedOutFile) Discard() error {
+	return os.RemoveAll(filepath.Dir(file.LocalPath))
+}
+
+func (dc *DockerClient) readContainerFileWithHeader(id string, containerPath string) (*tar.Header, []byte, error) {
+	rc, stat, err := dc.cli.CopyFromContainer(context.Background(), id, containerPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rc.Close()
+
+	if !stat.Mode.IsRegular() {
+		return nil, nil, fmt.Errorf("%s is not a regular file", containerPath)
+	}
+
+	tr := tar.NewReader(rc)
+	hdr, err := tr.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return hdr, content, nil
+}
+
+// Copies srcPath (file or directory) out of the container into localDir
+func (dc *DockerClient) CopyFromContainer(id string, srcPath string, localDir string, progress ProgressFunc) error {
+	rc, _, err := dc.cli.CopyFromContainer(context.Background(), id, srcPath)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return ExtractTar(&progressReader{r: rc, progress: progress}, localDir)
+}
+
+// Copies localPath (file or directory) into dstDir inside the container
+func (dc *DockerClient) CopyToContainer(id string, localPath string, dstDir string, progress ProgressFunc) error {
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(WriteTar(pw, localPath))
+	}()
+
+	err := dc.cli.CopyToContainer(context.Background(), id, dstDir, &progressReader{r: pr, progress: progress}, types.CopyToContainerOptions{})
+	// unblocks the writer if the daemon bailed out early
+	pr.Close()
+	return err
+}
+
+// Extracts a tarball into dir, entry names are rooted at dir so `../` entries cannot escape it. Entries are never
+// written through a symlink (eg: `a -> /etc` followed by `a/passwd`), hardlink targets are rooted at dir as well.
+// Devices and fifos are skipped.
+func ExtractTar(r io.Reader, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	dir = filepath.Clean(dir)
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+hdr.Name)))
+		if target == dir {
+			continue
+		}
+
+		if err := checkNoSymlinkParents(dir, target); err != nil {
+			return err
+		}
+		// an existing symlink at target would be followed when writing to it
+		if info, err := os.Lstat(target); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			if err := os.Remove(target); err != nil {
+				return err
+			}
+		}
+
+		mode := hdr.FileInfo().Mode()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode.Perm()|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+			if err != nil {
+				return err
+			}
+
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			// link names are relative to the archive root, like entry names
+			source := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+hdr.Linkname)))
+			if err := checkNoSymlinkParents(dir, source); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Link(source, target); err != nil {
+				return fmt.Errorf("hardlink %s: %w", hdr.Name, err)
+			}
+		}
+	}
+}
+
+// fails if a directory between dir and target is a symlink, writing below it could end up outside of dir
+func checkNoSymlinkParents(dir string, target string) error {
+	rel, err := filepath.Rel(dir, filepath.Dir(target))
+	if err != nil || rel == "." {
+		return err
+	}
+
+	current := dir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			// created by MkdirAll, nothing below can be a symlink yet
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("refusing to extract %s: %s is a symlink", target, current)
+		}
+	}
+	return nil
+}
+
+// Writes localPath as a tarball, directories are added recursively with localPath's base name as root
+func WriteTar(w io.Writer, localPath string) error {
+	tw := tar.NewWriter(w)
+	base := filepath.Dir(filepath.Clean(localPath))
+
+	err := filepath.WalkDir(localPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
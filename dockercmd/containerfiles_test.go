@@ -0,0 +1,180 @@
+package dockercmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestListTarDir(t *testing.T) {
+	archive := makeTar(t, []tarEntry{
+		{name: "etc/", isDir: true},
+		{name: "etc/hosts", size: 10},
+		{name: "etc/ssl/", isDir: true},
+		{name: "etc/ssl/cert.pem", size: 300},
+		{name: "etc/ssl/key.pem", size: 200},
+	})
+
+	files, err := listTarDir(bytes.NewReader(archive), "/etc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 {
+		t.Fatalf("expected 2 entries, got %#v", files)
+	}
+
+	if files[0].Name != "ssl" || !files[0].IsDir() || files[0].Size != 500 || files[0].Path != "/etc/ssl" {
+		t.Errorf("unexpected directory entry: %#v", files[0])
+	}
+
+	if files[1].Name != "hosts" || files[1].IsDir() || files[1].Size != 10 {
+		t.Errorf("unexpected file entry: %#v", files[1])
+	}
+}
+
+func TestStatContainerFiles(t *testing.T) {
+	mtime := time.Unix(1700000000, 0)
+	stats := map[string]types.ContainerPathStat{
+		"/etc/hosts":     {Name: "hosts", Size: 10, Mode: 0644, Mtime: mtime},
+		"/etc/ssl":       {Name: "ssl", Size: 4096, Mode: fs.ModeDir | 0755, Mtime: mtime},
+		"/etc/localtime": {Name: "localtime", Size: 27, Mode: fs.ModeSymlink | 0777, LinkTarget: "/usr/share/zoneinfo/UTC"},
+	}
+
+	files := statContainerFiles("/etc", []string{"hosts", "localtime", "removed", "ssl"}, func(p string) (types.ContainerPathStat, error) {
+		stat, ok := stats[p]
+		if !ok {
+			return stat, errors.New("not found")
+		}
+		return stat, nil
+	})
+
+	if len(files) != 3 {
+		t.Fatalf("expected 3 entries, got %#v", files)
+	}
+	if files[0].Name != "ssl" || !files[0].IsDir() || files[0].Size != -1 || files[0].Path != "/etc/ssl" {
+		t.Errorf("unexpected directory entry: %#v", files[0])
+	}
+	if files[1].Name != "hosts" || files[1].Size != 10 || !files[1].ModTime.Equal(mtime) || files[1].LinkTarget != "" {
+		t.Errorf("unexpected file entry: %#v", files[1])
+	}
+	if files[2].Name != "localtime" || files[2].LinkTarget != "/usr/share/zoneinfo/UTC" {
+		t.Errorf("unexpected symlink entry: %#v", files[2])
+	}
+}
+
+func TestTarRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	os.MkdirAll(filepath.Join(src, "data", "nested"), 0755)
+	os.WriteFile(filepath.Join(src, "data", "nested", "file.txt"), []byte("hello"), 0640)
+
+	var buf bytes.Buffer
+	if err := WriteTar(&buf, filepath.Join(src, "data")); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := t.TempDir()
+	if err := ExtractTar(&buf, dst); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dst, "data", "nested", "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "hello" {
+		t.Errorf("expected hello, got %q", content)
+	}
+}
+
+func TestExtractTarStaysInsideDir(t *testing.T) {
+	archive := makeTar(t, []tarEntry{{name: "../../evil", size: 1}})
+
+	// entries are rooted at dir, so this is written inside it
+	dst := t.TempDir()
+	if err := ExtractTar(bytes.NewReader(archive), dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, "evil")); err != nil {
+		t.Errorf("expected entry to be extracted inside the destination: %s", err)
+	}
+}
+
+func writeHeaders(t *testing.T, headers []*tar.Header) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	for _, hdr := range headers {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(bytes.Repeat([]byte("a"), int(hdr.Size))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractTarDoesNotFollowSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	dst := t.TempDir()
+
+	archive := writeHeaders(t, []*tar.Header{
+		{Name: "a", Typeflag: tar.TypeSymlink, Linkname: outside},
+		{Name: "a/passwd", Typeflag: tar.TypeReg, Mode: 0644, Size: 3},
+	})
+
+	if err := ExtractTar(bytes.NewReader(archive), dst); err == nil {
+		t.Error("expected writing through a symlink to fail")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "passwd")); err == nil {
+		t.Error("file was written outside of the destination")
+	}
+
+	// a symlink already in the destination is replaced, not written through
+	os.WriteFile(filepath.Join(outside, "target"), []byte("keep"), 0644)
+	os.Symlink(filepath.Join(outside, "target"), filepath.Join(dst, "file"))
+
+	archive = writeHeaders(t, []*tar.Header{{Name: "file", Typeflag: tar.TypeReg, Mode: 0644, Size: 3}})
+	if err := ExtractTar(bytes.NewReader(archive), dst); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(outside, "target")); string(content) != "keep" {
+		t.Errorf("symlink target was overwritten: %q", content)
+	}
+}
+
+func TestExtractTarHardlinks(t *testing.T) {
+	dst := t.TempDir()
+
+	archive := writeHeaders(t, []*tar.Header{
+		{Name: "data/file", Typeflag: tar.TypeReg, Mode: 0644, Size: 4},
+		{Name: "data/link", Typeflag: tar.TypeLink, Linkname: "data/file"},
+		// rooted at the destination, so this links nothing outside of it
+		{Name: "data/escape", Typeflag: tar.TypeLink, Linkname: "../../data/file"},
+	})
+
+	if err := ExtractTar(bytes.NewReader(archive), dst); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"link", "escape"} {
+		content, err := os.ReadFile(filepath.Join(dst, "data", name))
+		if err != nil || string(content) != "aaaa" {
+			t.Errorf("%s: got %q, %v", name, content, err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package dockercmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// a daemon that can be connected to, either from a docker context or given by address
+type DockerHost struct {
+	// context name, empty for hosts given by address
+	Name string
+	// eg: unix:///var/run/docker.sock, tcp://10.0.0.2:2376, ssh://user@host
+	Host string
+	// directory with ca.pem, cert.pem and key.pem, empty without tls
+	TLSDir string
+}
+
+func (h DockerHost) String() string {
+	if h.Name == "" {
+		return h.Host
+	}
+	return h.Name + " (" + h.Host + ")"
+}
+
+// the parts of a context's meta.json we need
+type contextMeta struct {
+	Name      string
+	Endpoints map[string]struct {
+		Host string
+	}
+}
+
+// Hosts of the docker contexts (`docker context ls`), sorted by name. Contexts without a docker endpoint are skipped.
+func DockerContextHosts() ([]DockerHost, error) {
+	contextsDir := filepath.Join(filepath.Dir(DockerConfigPath()), "contexts")
+
+	entries, err := os.ReadDir(filepath.Join(contextsDir, "meta"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var hosts []DockerHost
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(contextsDir, "meta", entry.Name(), "meta.json"))
+		if err != nil {
+			continue
+		}
+
+		var meta contextMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			continue
+		}
+
+		endpoint, ok := meta.Endpoints["docker"]
+		if !ok || endpoint.Host == "" {
+			continue
+		}
+
+		host := DockerHost{Name: meta.Name, Host: endpoint.Host}
+
+		// the cli stores tls material under the same (hashed) directory name
+		tlsDir := filepath.Join(contextsDir, "tls", entry.Name(), "docker")
+		if _, err := os.Stat(filepath.Join(tlsDir, "ca.pem")); err == nil {
+			host.TLSDir = tlsDir
+		}
+
+		hosts = append(hosts, host)
+	}
+
+	slices.SortFunc(hosts, func(a DockerHost, b DockerHost) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return hosts, nil
+}
+
+// Connects to another daemon, ssh hosts are reached through `docker system dial-stdio` like the cli does
+func NewDockerClientForHost(host DockerHost) (DockerClient, error) {
+	opts := []client.Opt{client.WithAPIVersionNegotiation()}
+
+	u, err := url.Parse(host.Host)
+	if err != nil {
+		return DockerClient{}, fmt.Errorf("invalid docker host %s: %w", host.Host, err)
+	}
+
+	if u.Scheme == "ssh" {
+		opts = append(opts,
+			// the host is only used for the http requests, the connection comes from the dialer
+			client.WithHost("http://docker.example.com"),
+			client.WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialSSH(ctx, u)
+			}),
+		)
+	} else {
+		opts = append(opts, client.WithHost(host.Host))
+	}
+
+	if host.TLSDir != "" {
+		opts = append(opts, client.WithTLSClientConfig(
+			filepath.Join(host.TLSDir, "ca.pem"),
+			filepath.Join(host.TLSDir, "cert.pem"),
+			filepath.Join(host.TLSDir, "key.pem"),
+		))
+	}
+
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return DockerClient{}, err
+	}
+
+	return DockerClient{
+		cli: cli,
+		containerListArgs: container.ListOptions{
+			Size: true,
+		},
+	}, nil
+}
+
+func dialSSH(ctx context.Context, u *url.URL) (net.Conn, error) {
+	args := []string{}
+	if u.User != nil {
+		args = append(args, "-l", u.User.Username())
+	}
+	if u.Port() != "" {
+		args = append(args, "-p", u.Port())
+	}
+	args = append(args, "--", u.Hostname(), "docker", "system", "dial-stdio")
+
+	cmd := exec.CommandContext(ctx, "ssh", args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout, host: u.Host}, nil
+}
+
+// net.Conn over the stdin/stdout of a command
+type commandConn struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+	host   string
+}
+
+func (c *commandConn) Read(b []byte) (int, error)  { return c.stdout.Read(b) }
+func (c *commandConn) Write(b []byte) (int, error) { return c.stdin.Write(b) }
+
+func (c *commandConn) Close() error {
+	c.stdin.Close()
+	c.stdout.Close()
+	c.cmd.Process.Kill()
+	c.cmd.Wait()
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr                { return commandAddr("local") }
+func (c *commandConn) RemoteAddr() net.Addr               { return commandAddr(c.host) }
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+type commandAddr string
+
+func (a commandAddr) Network() string { return "ssh" }
+func (a commandAddr) String() string  { return string(a) }
+
+// key for state that belongs to a host, eg: migration progress
+func hostKey(host string) string {
+	sum := sha256.Sum256([]byte(host))
+	return hex.EncodeToString(sum[:])[:12]
+}
@@ -0,0 +1,178 @@
+package dockercmd
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+)
+
+// true for paths that should be gzipped, eg: images.tar.gz
+func IsGzipPath(path string) bool {
+	return strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".tgz")
+}
+
+// Sum of the image sizes, an estimate of how big a saved (uncompressed) archive of refs gets. Layers shared between
+// the images are counted more than once.
+func (dc *DockerClient) ImagesSize(refs []string) (int64, error) {
+	var total int64
+	for _, ref := range refs {
+		info, _, err := dc.cli.ImageInspectWithRaw(context.Background(), ref)
+		if err != nil {
+			return 0, err
+		}
+		total += info.Size
+	}
+	return total, nil
+}
+
+// Writes refs (names or ids) to a single archive at path, gzipped if path ends with .gz or .tgz. Progress reports the
+// uncompressed bytes received from the daemon.
+func (dc *DockerClient) SaveImages(refs []string, path string, progress ProgressFunc) error {
+	if len(refs) == 0 {
+		return errors.New("no images to save")
+	}
+
+	rc, err := dc.cli.ImageSave(context.Background(), refs)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return writeArchive(rc, path, progress)
+}
+
+// Writes r to a new file at path, gzipped if path ends with .gz or .tgz. Progress reports the bytes read from r.
+func writeArchive(r io.Reader, path string, progress ProgressFunc) (err error) {
+	// never overwrite an existing archive
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		// do not leave half written archives around
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if IsGzipPath(path) {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+
+	if _, err := io.Copy(w, &progressReader{r: r, progress: progress}); err != nil {
+		return err
+	}
+
+	if gz != nil {
+		return gz.Close()
+	}
+	return nil
+}
+
+// Loads the images of an archive created by `docker save` (or SaveImages), the daemon handles compressed archives
+// itself. Progress reports the bytes read from the file.
+func (dc *DockerClient) LoadImages(path string, progress ProgressFunc) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	res, err := dc.cli.ImageLoad(context.Background(), &progressReader{r: f, progress: progress}, true)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if !res.JSON {
+		_, err = io.Copy(io.Discard, res.Body)
+		return err
+	}
+	return writeJSONMessages(res.Body, io.Discard)
+}
+
+type ImageImportOpts struct {
+	// repo:tag of the new image, untagged if empty
+	Ref     string
+	Message string
+	// Dockerfile instructions applied to the image, eg: CMD ["/bin/sh"]
+	Changes []string
+}
+
+// Creates a single layer image from a filesystem tarball (eg: from `docker export`), compressed tarballs are handled by
+// the daemon. Progress reports the bytes read from the file.
+func (dc *DockerClient) ImportImage(path string, opts ImageImportOpts, progress ProgressFunc) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	source := types.ImageImportSource{Source: &progressReader{r: f, progress: progress}, SourceName: "-"}
+	rc, err := dc.cli.ImageImport(context.Background(), source, opts.Ref, image.ImportOptions{
+		Message: opts.Message,
+		Changes: opts.Changes,
+	})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return writeJSONMessages(rc, io.Discard)
+}
+
+// Splits `CMD ["a", "b"]; ENV A=1` into instructions. Semicolons inside quotes or brackets do not split.
+func ParseImageChanges(s string) []string {
+	var changes []string
+	var current strings.Builder
+	var quote rune
+	depth := 0
+
+	flush := func() {
+		if change := strings.TrimSpace(current.String()); change != "" {
+			changes = append(changes, change)
+		}
+		current.Reset()
+	}
+
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[':
+			depth++
+		case r == ']':
+			depth = max(depth-1, 0)
+		case r == ';' && depth == 0:
+			flush()
+			continue
+		}
+
+		current.WriteRune(r)
+	}
+
+	flush()
+	return changes
+}
@@ -0,0 +1,31 @@
+package dockercmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseImageChanges(t *testing.T) {
+	cases := map[string][]string{
+		"":                                       nil,
+		`CMD ["/bin/sh"]`:                        {`CMD ["/bin/sh"]`},
+		`CMD ["a", "b"]; ENV A=1;  ; `:           {`CMD ["a", "b"]`, "ENV A=1"},
+		`CMD ["sh", "-c", "a; b"]; WORKDIR /app`: {`CMD ["sh", "-c", "a; b"]`, "WORKDIR /app"},
+		`ENV MSG="a;b" B=1;EXPOSE 80`:            {`ENV MSG="a;b" B=1`, "EXPOSE 80"},
+		`ENV A=x\;y; USER app`:                   {`ENV A=x\;y`, "USER app"},
+	}
+
+	for s, want := range cases {
+		if got := ParseImageChanges(s); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestIsGzipPath(t *testing.T) {
+	for path, want := range map[string]bool{"a.tar": false, "a.tar.gz": true, "a.tgz": true, "gz": false} {
+		if got := IsGzipPath(path); got != want {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package dockercmd
 
 import (
 	"context"
+	"regexp"
+	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
@@ -27,3 +29,65 @@ func (dc *DockerClient) PruneImages() (types.ImagesPruneReport, error) {
 	report, err := dc.cli.ImagesPrune(context.Background(), filters.Args{})
 	return report, err
 }
+
+func (dc *DockerClient) InspectImage(id string) (*types.ImageInspect, error) {
+	res, _, err := dc.cli.ImageInspectWithRaw(context.Background(), id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+var (
+	// build args are prepended to RUN instructions, eg: `|2 FOO=bar BAZ=qux /bin/sh -c make` by the classic builder
+	// and `RUN |2 FOO=bar BAZ=qux /bin/sh -c make` by buildkit
+	buildArgsPrefix = regexp.MustCompile(`^(RUN )?\|\d+ (\S+=\S* )*`)
+	// classic builder records ADD/COPY sources as `file:<hash> in <dest>`
+	addCopyIn = regexp.MustCompile(`^((?:ADD|COPY) .*) in (\S+)\s*$`)
+)
+
+// Reconstructs a best effort Dockerfile from the image history (expected newest first, as returned by `ImageHistory`).
+// Base image instructions are included, since history does not tell where the base image ends.
+func ReconstructDockerfile(history []image.HistoryResponseItem) string {
+	var res strings.Builder
+	res.WriteString("FROM scratch\n")
+
+	for i := len(history) - 1; i >= 0; i-- {
+		instruction := historyToInstruction(history[i].CreatedBy)
+		if instruction == "" {
+			continue
+		}
+
+		res.WriteString(instruction)
+		res.WriteString("\n")
+	}
+
+	return res.String()
+}
+
+func historyToInstruction(createdBy string) string {
+	line := strings.TrimSpace(createdBy)
+	if line == "" {
+		return ""
+	}
+
+	// buildkit records instructions as is, with a trailing comment
+	line = strings.TrimSpace(strings.TrimSuffix(line, "# buildkit"))
+	line = buildArgsPrefix.ReplaceAllString(line, "$1")
+
+	if strings.HasPrefix(line, "RUN ") {
+		line = "RUN " + strings.TrimPrefix(strings.TrimPrefix(line, "RUN "), "/bin/sh -c ")
+	} else if after, ok := strings.CutPrefix(line, "/bin/sh -c #(nop) "); ok {
+		line = strings.TrimSpace(after)
+	} else if after, ok := strings.CutPrefix(line, "/bin/sh -c "); ok {
+		line = "RUN " + after
+	}
+
+	if match := addCopyIn.FindStringSubmatch(line); match != nil {
+		line = match[1] + " " + match[2]
+	}
+
+	return line
+}
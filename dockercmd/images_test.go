@@ -1,6 +1,10 @@
 package dockercmd
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/image"
+)
 
 func TestListImages(t *testing.T) {
 	cli := NewDockerClient()
@@ -12,3 +16,30 @@ func TestListImages(t *testing.T) {
 	}
 
 }
+
+func TestReconstructDockerfile(t *testing.T) {
+	history := []image.HistoryResponseItem{
+		{CreatedBy: "CMD [\"./server\"]"},
+		{CreatedBy: "RUN |2 GOOS=linux CGO_ENABLED=0 /bin/sh -c go test ./... # buildkit"},
+		{CreatedBy: "RUN /bin/sh -c go build -o server . # buildkit"},
+		{CreatedBy: "|1 VERSION=1.2 /bin/sh -c echo $VERSION > /version"},
+		{CreatedBy: "/bin/sh -c #(nop) COPY dir:abc123 in /app "},
+		{CreatedBy: "/bin/sh -c #(nop)  ENV PATH=/usr/local/bin"},
+		{CreatedBy: ""},
+		{CreatedBy: "/bin/sh -c #(nop) ADD file:deadbeef in / "},
+	}
+
+	want := `FROM scratch
+ADD file:deadbeef /
+ENV PATH=/usr/local/bin
+COPY dir:abc123 /app
+RUN echo $VERSION > /version
+RUN go build -o server .
+RUN go test ./...
+CMD ["./server"]
+`
+
+	if got := ReconstructDockerfile(history); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+package dockercmd
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+)
+
+// An image in the image tree, or a chain of layers several images build on without being an image itself (eg: the
+// base image was removed, or pulled images that share a base).
+type ImageTreeNode struct {
+	// nil for a shared layer chain
+	Image *image.Summary
+	// layers of the image (or chain)
+	Layers int
+	// layers shared with the parent node, 0 for roots
+	SharedLayers int
+	Children     []*ImageTreeNode
+}
+
+// Size of the layers no other image uses, ie: what removing the image frees. -1 when the daemon did not report
+// the shared size.
+func (n *ImageTreeNode) UniqueSize() int64 {
+	if n.Image == nil || n.Image.SharedSize < 0 {
+		return -1
+	}
+	return n.Image.Size - n.Image.SharedSize
+}
+
+// number of images in the subtree below the node
+func (n *ImageTreeNode) Descendants() int {
+	count := 0
+	for _, child := range n.Children {
+		if child.Image != nil {
+			count++
+		}
+		count += child.Descendants()
+	}
+	return count
+}
+
+// Like ListImages, but with SharedSize calculated, which makes the daemon walk every layer
+func (dc *DockerClient) ListImagesWithSharedSize() ([]image.Summary, error) {
+	return dc.cli.ImageList(context.Background(), image.ListOptions{ContainerCount: true, SharedSize: true})
+}
+
+// ids of the image's filesystem layers, base layer first
+func (dc *DockerClient) ImageLayerIDs(id string) ([]string, error) {
+	info, _, err := dc.cli.ImageInspectWithRaw(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	return info.RootFS.Layers, nil
+}
+
+// node of a trie over layer ids
+type layerTrie struct {
+	children map[string]*layerTrie
+	images   []image.Summary
+	depth    int
+}
+
+// Arranges images by the layers they build on: an image is the child of the image whose layers are the longest
+// prefix of its own. Chains of layers that several images share but that are not an image themselves become nodes
+// without an image. Images with identical layers (eg: only metadata differs) are nested below the oldest one, or
+// below their parent if ParentID says so. layers maps image ids to their layer ids, images missing from it are roots.
+func BuildImageTree(images []image.Summary, layers map[string][]string) []*ImageTreeNode {
+	root := &layerTrie{children: make(map[string]*layerTrie)}
+
+	for _, img := range images {
+		node := root
+		for _, layer := range layers[img.ID] {
+			child, ok := node.children[layer]
+			if !ok {
+				child = &layerTrie{children: make(map[string]*layerTrie), depth: node.depth + 1}
+				node.children[layer] = child
+			}
+			node = child
+		}
+		node.images = append(node.images, img)
+	}
+
+	// images without (known) layers share nothing, they must not hold the others
+	var nodes []*ImageTreeNode
+	for _, img := range root.images {
+		nodes = append(nodes, &ImageTreeNode{Image: &img})
+	}
+	root.images = nil
+
+	return sortImageTree(append(nodes, collectImageTree(root, 0)...))
+}
+
+// turns the trie into tree nodes, trie nodes that neither hold an image nor branch are skipped. parentDepth is the
+// number of layers of the closest node above that was kept.
+func collectImageTree(trie *layerTrie, parentDepth int) []*ImageTreeNode {
+	branches := len(trie.children) > 1 && trie.depth > 0
+	if len(trie.images) == 0 && !branches {
+		var res []*ImageTreeNode
+		for _, child := range trie.children {
+			res = append(res, collectImageTree(child, parentDepth)...)
+		}
+		return res
+	}
+
+	var children []*ImageTreeNode
+	for _, child := range trie.children {
+		children = append(children, collectImageTree(child, trie.depth)...)
+	}
+
+	if len(trie.images) == 0 {
+		return []*ImageTreeNode{{Layers: trie.depth, SharedLayers: parentDepth, Children: children}}
+	}
+
+	imgs := slices.Clone(trie.images)
+	slices.SortFunc(imgs, func(a image.Summary, b image.Summary) int {
+		if c := cmp.Compare(a.Created, b.Created); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	// images with the same layers, the oldest one holds the others (and everything built on top)
+	nodes := make(map[string]*ImageTreeNode, len(imgs))
+	for i := range imgs {
+		nodes[imgs[i].ID] = &ImageTreeNode{Image: &imgs[i], Layers: trie.depth, SharedLayers: trie.depth}
+	}
+
+	top := nodes[imgs[0].ID]
+	top.SharedLayers = parentDepth
+	top.Children = children
+
+	for _, img := range imgs[1:] {
+		parent := top
+		if p, ok := nodes[img.ParentID]; ok {
+			parent = p
+		}
+		parent.Children = append(parent.Children, nodes[img.ID])
+	}
+
+	return []*ImageTreeNode{top}
+}
+
+// orders nodes by name, shared chains by the name of their first child
+func sortImageTree(nodes []*ImageTreeNode) []*ImageTreeNode {
+	for _, node := range nodes {
+		node.Children = sortImageTree(node.Children)
+	}
+
+	slices.SortStableFunc(nodes, func(a *ImageTreeNode, b *ImageTreeNode) int {
+		return strings.Compare(imageTreeSortKey(a), imageTreeSortKey(b))
+	})
+	return nodes
+}
+
+func imageTreeSortKey(node *ImageTreeNode) string {
+	if node.Image == nil {
+		if len(node.Children) == 0 {
+			return ""
+		}
+		return imageTreeSortKey(node.Children[0])
+	}
+
+	// untagged images go last
+	if len(node.Image.RepoTags) == 0 || node.Image.RepoTags[0] == "<none>:<none>" {
+		return "~" + node.Image.ID
+	}
+	return node.Image.RepoTags[0]
+}
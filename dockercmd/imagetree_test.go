@@ -0,0 +1,80 @@
+package dockercmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/image"
+)
+
+// renders the tree as `name(layers/shared)` lines indented by depth, shared chains are named `chain`
+func formatImageTree(nodes []*ImageTreeNode, depth int) string {
+	var res strings.Builder
+	for _, node := range nodes {
+		name := "chain"
+		if node.Image != nil {
+			name = node.Image.RepoTags[0]
+		}
+		fmt.Fprintf(&res, "%s%s(%d/%d)\n", strings.Repeat("  ", depth), name, node.Layers, node.SharedLayers)
+		res.WriteString(formatImageTree(node.Children, depth+1))
+	}
+	return res.String()
+}
+
+func TestBuildImageTree(t *testing.T) {
+	images := []image.Summary{
+		{ID: "app2", RepoTags: []string{"app2:latest"}, Created: 3},
+		{ID: "retag", RepoTags: []string{"retag:latest"}, Created: 4},
+		{ID: "base", RepoTags: []string{"base:latest"}, Created: 1},
+		{ID: "app1", RepoTags: []string{"app1:latest"}, Created: 2},
+		{ID: "y", RepoTags: []string{"y:1"}},
+		{ID: "x", RepoTags: []string{"x:1"}},
+		{ID: "loaded", RepoTags: []string{"<none>:<none>"}},
+	}
+
+	layers := map[string][]string{
+		"base":  {"a"},
+		"app1":  {"a", "b"},
+		"app2":  {"a", "b", "c"},
+		"retag": {"a", "b"},
+		"x":     {"p", "q", "r"},
+		"y":     {"p", "q", "s"},
+	}
+
+	got := formatImageTree(BuildImageTree(images, layers), 0)
+	want := `base:latest(1/0)
+  app1:latest(2/1)
+    app2:latest(3/2)
+    retag:latest(2/2)
+chain(2/0)
+  x:1(3/2)
+  y:1(3/2)
+<none>:<none>(0/0)
+`
+	if got != want {
+		t.Errorf("got tree\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestImageTreeNodeSizes(t *testing.T) {
+	node := &ImageTreeNode{
+		Image: &image.Summary{Size: 100, SharedSize: 30},
+		Children: []*ImageTreeNode{
+			{Children: []*ImageTreeNode{{Image: &image.Summary{}}, {Image: &image.Summary{}}}},
+			{Image: &image.Summary{}},
+		},
+	}
+
+	if unique := node.UniqueSize(); unique != 70 {
+		t.Errorf("got unique size %d, want 70", unique)
+	}
+	if descendants := node.Descendants(); descendants != 3 {
+		t.Errorf("got %d descendants, want 3", descendants)
+	}
+
+	node.Image.SharedSize = -1
+	if unique := node.UniqueSize(); unique != -1 {
+		t.Errorf("unknown shared size should give -1, got %d", unique)
+	}
+}
@@ -0,0 +1,361 @@
+package dockercmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+	// blobs bigger than this are never configs or manifests, so we do not buffer them
+	maxMetadataBlobSize = 16 << 20
+)
+
+type FileChangeKind int
+
+const (
+	FileUnchanged FileChangeKind = iota
+	FileAdded
+	FileModified
+	FileRemoved
+)
+
+type LayerFile struct {
+	Path  string
+	Size  int64
+	IsDir bool
+	Kind  FileChangeKind
+}
+
+type ImageLayer struct {
+	ID        string
+	CreatedBy string
+	Created   int64
+	Size      int64
+	// true when the history entry did not produce a filesystem layer (eg: ENV, CMD)
+	Empty bool
+	Files []LayerFile
+}
+
+// file that is shipped in some layer but is not visible in the final image
+type WastedFile struct {
+	Path string
+	Size int64
+	// index of the layer that shipped the file
+	AddedIn int
+	// index of the layer that overwrote or removed the file
+	HiddenIn int
+}
+
+type ImageLayerAnalysis struct {
+	// layers in the order they were built, ie: base layer first
+	Layers      []ImageLayer
+	Wasted      []WastedFile
+	WastedBytes int64
+	TotalSize   int64
+}
+
+type imageManifest struct {
+	Config string
+	Layers []string
+}
+
+type imageConfig struct {
+	History []struct {
+		CreatedBy  string `json:"created_by"`
+		EmptyLayer bool   `json:"empty_layer"`
+	} `json:"history"`
+}
+
+func (dc *DockerClient) ImageHistory(id string) ([]image.HistoryResponseItem, error) {
+	return dc.cli.ImageHistory(context.Background(), id)
+}
+
+// Saves the image and computes per layer file changes along with space wasted by overwritten/removed files
+func (dc *DockerClient) AnalyzeImageLayers(id string) (*ImageLayerAnalysis, error) {
+	history, err := dc.ImageHistory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := dc.cli.ImageSave(context.Background(), []string{id})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return AnalyzeImageTar(rc, history)
+}
+
+// Parses a tarball produced by `ImageSave` (both legacy and OCI layouts). history is expected in the order
+// returned by `ImageHistory`, ie: newest first.
+func AnalyzeImageTar(r io.Reader, history []image.HistoryResponseItem) (*ImageLayerAnalysis, error) {
+	layerFiles := make(map[string][]LayerFile)
+	metadata := make(map[string][]byte)
+	// legacy archives deduplicate identical layers using symlinks
+	links := make(map[string]string)
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag == tar.TypeSymlink {
+			links[path.Clean(hdr.Name)] = path.Join(path.Dir(hdr.Name), hdr.Linkname)
+			continue
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		br := bufio.NewReader(tr)
+		peek, _ := br.Peek(512)
+
+		switch {
+		case isGzip(peek):
+			gz, err := gzip.NewReader(br)
+			if err != nil {
+				return nil, err
+			}
+			files, err := readLayerFiles(gz)
+			if err != nil {
+				return nil, err
+			}
+			layerFiles[name] = files
+		case isTar(peek):
+			files, err := readLayerFiles(br)
+			if err != nil {
+				return nil, err
+			}
+			layerFiles[name] = files
+		case hdr.Size <= maxMetadataBlobSize:
+			data, err := io.ReadAll(br)
+			if err != nil {
+				return nil, err
+			}
+			metadata[name] = data
+		}
+	}
+
+	var manifests []imageManifest
+	if err := json.Unmarshal(metadata["manifest.json"], &manifests); err != nil || len(manifests) == 0 {
+		return nil, errors.New("image archive does not contain a valid manifest.json")
+	}
+	manifest := manifests[0]
+
+	var config imageConfig
+	if data, ok := metadata[path.Clean(manifest.Config)]; ok {
+		// a broken config only costs us the layer alignment, so ignore the error
+		_ = json.Unmarshal(data, &config)
+	}
+
+	analysis := &ImageLayerAnalysis{
+		Layers: buildLayers(history, config),
+	}
+
+	nextLayer := 0
+	for i := range analysis.Layers {
+		layer := &analysis.Layers[i]
+		analysis.TotalSize += layer.Size
+
+		if layer.Empty || nextLayer >= len(manifest.Layers) {
+			continue
+		}
+
+		layerPath := path.Clean(manifest.Layers[nextLayer])
+		if target, ok := links[layerPath]; ok {
+			layerPath = target
+		}
+
+		// files are copied since the same blob can back multiple layers
+		layer.Files = slices.Clone(layerFiles[layerPath])
+		nextLayer += 1
+	}
+
+	analysis.computeChanges()
+
+	return analysis, nil
+}
+
+// aligns history entries with filesystem layers, uses the image config when it agrees with the daemon's history
+// and falls back to treating zero sized entries as empty layers
+func buildLayers(history []image.HistoryResponseItem, config imageConfig) []ImageLayer {
+	layers := make([]ImageLayer, len(history))
+	useConfig := len(config.History) == len(history)
+
+	for i := range history {
+		entry := history[len(history)-1-i]
+		layers[i] = ImageLayer{
+			ID:        entry.ID,
+			CreatedBy: entry.CreatedBy,
+			Created:   entry.Created,
+			Size:      entry.Size,
+		}
+
+		if useConfig {
+			layers[i].Empty = config.History[i].EmptyLayer
+		} else {
+			layers[i].Empty = entry.Size == 0
+		}
+	}
+
+	return layers
+}
+
+type trackedFile struct {
+	size  int64
+	isDir bool
+	layer int
+}
+
+// marks each file as added/modified/removed relative to the layers below it, and records what is wasted
+func (a *ImageLayerAnalysis) computeChanges() {
+	state := make(map[string]trackedFile)
+
+	// only files from lower layers can be hidden, the current layer may already have recreated some of them
+	hide := func(target string, layerIndex int) int64 {
+		var removed int64
+		for p, file := range state {
+			if file.layer == layerIndex || (p != target && !strings.HasPrefix(p, target+"/")) {
+				continue
+			}
+
+			if !file.isDir && file.size > 0 {
+				a.Wasted = append(a.Wasted, WastedFile{Path: p, Size: file.size, AddedIn: file.layer, HiddenIn: layerIndex})
+				removed += file.size
+			}
+			delete(state, p)
+		}
+		return removed
+	}
+
+	for layerIndex := range a.Layers {
+		files := a.Layers[layerIndex].Files
+		res := make([]LayerFile, 0, len(files))
+
+		for _, file := range files {
+			dir, base := path.Split(file.Path)
+			dir = strings.TrimSuffix(dir, "/")
+
+			switch {
+			case base == whiteoutOpaque:
+				// opaque directories hide everything below them from lower layers, but keep the dir itself
+				var children []string
+				for p, file := range state {
+					if file.layer != layerIndex && path.Dir(p) == dir {
+						children = append(children, p)
+					}
+				}
+
+				for _, child := range children {
+					isDir := state[child].isDir
+					removed := hide(child, layerIndex)
+					res = append(res, LayerFile{Path: child, Size: removed, IsDir: isDir, Kind: FileRemoved})
+				}
+
+			case strings.HasPrefix(base, whiteoutPrefix):
+				target := path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix))
+				isDir := state[target].isDir
+				removed := hide(target, layerIndex)
+				res = append(res, LayerFile{Path: target, Size: removed, IsDir: isDir, Kind: FileRemoved})
+
+			default:
+				prev, exists := state[file.Path]
+				switch {
+				case !exists:
+					file.Kind = FileAdded
+				case file.IsDir && prev.isDir:
+					file.Kind = FileUnchanged
+				default:
+					file.Kind = FileModified
+					if !prev.isDir && prev.size > 0 {
+						a.Wasted = append(a.Wasted, WastedFile{Path: file.Path, Size: prev.size, AddedIn: prev.layer, HiddenIn: layerIndex})
+					}
+				}
+
+				state[file.Path] = trackedFile{size: file.Size, isDir: file.IsDir, layer: layerIndex}
+				res = append(res, file)
+			}
+		}
+
+		slices.SortFunc(res, func(x, y LayerFile) int {
+			return strings.Compare(x.Path, y.Path)
+		})
+		a.Layers[layerIndex].Files = res
+	}
+
+	for _, wasted := range a.Wasted {
+		a.WastedBytes += wasted.Size
+	}
+
+	slices.SortStableFunc(a.Wasted, func(x, y WastedFile) int {
+		if c := cmp.Compare(y.Size, x.Size); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Path, y.Path)
+	})
+}
+
+// reads headers of a single layer tarball
+func readLayerFiles(r io.Reader) ([]LayerFile, error) {
+	var files []LayerFile
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		p := path.Clean("/" + hdr.Name)
+		if p == "/" {
+			continue
+		}
+
+		files = append(files, LayerFile{
+			Path:  strings.TrimPrefix(p, "/"),
+			Size:  hdr.Size,
+			IsDir: hdr.Typeflag == tar.TypeDir,
+		})
+	}
+}
+
+func isGzip(header []byte) bool {
+	return len(header) >= 2 && header[0] == 0x1f && header[1] == 0x8b
+}
+
+// checks for the `ustar` magic, an empty tarball is just zeroed blocks
+func isTar(header []byte) bool {
+	if len(header) < 512 {
+		return false
+	}
+
+	if bytes.Equal(header[257:262], []byte("ustar")) {
+		return true
+	}
+
+	return bytes.Count(header, []byte{0}) == len(header)
+}
@@ -0,0 +1,146 @@
+package dockercmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/docker/docker/api/types/image"
+)
+
+type tarEntry struct {
+	name  string
+	size  int64
+	isDir bool
+	// written instead of size bytes of filler when set
+	content string
+	// 0644 (0755 for directories) when zero
+	mode int64
+}
+
+func makeTar(t *testing.T, entries []tarEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	for _, entry := range entries {
+		data := bytes.Repeat([]byte("a"), int(entry.size))
+		if entry.content != "" {
+			data = []byte(entry.content)
+		}
+
+		hdr := &tar.Header{Name: entry.name, Size: int64(len(data)), Mode: 0644, Typeflag: tar.TypeReg}
+		if entry.isDir {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0755
+		}
+		if entry.mode != 0 {
+			hdr.Mode = entry.mode
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func makeImageArchive(t *testing.T, layers [][]byte, config []byte) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	add := func(name string, data []byte) {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(data)), Mode: 0644, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(data)
+	}
+
+	var layerPaths []string
+	for i, layer := range layers {
+		name := "blobs/sha256/layer" + string(rune('0'+i))
+		add(name, layer)
+		layerPaths = append(layerPaths, name)
+	}
+
+	add("blobs/sha256/config", config)
+
+	manifest, _ := json.Marshal([]imageManifest{{Config: "blobs/sha256/config", Layers: layerPaths}})
+	add("manifest.json", manifest)
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestAnalyzeImageTar(t *testing.T) {
+	layers := [][]byte{
+		makeTar(t, []tarEntry{
+			{name: "etc/", isDir: true},
+			{name: "etc/config", size: 100},
+			{name: "bin/", isDir: true},
+			{name: "bin/big", size: 1000},
+		}),
+		makeTar(t, []tarEntry{
+			{name: "etc/", isDir: true},
+			{name: "etc/config", size: 150},
+			{name: "bin/.wh.big"},
+			{name: "opt/", isDir: true},
+		}),
+	}
+
+	config := []byte(`{"history": [{"created_by": "ADD rootfs"}, {"created_by": "RUN tweak"}, {"created_by": "ENV A=b", "empty_layer": true}]}`)
+
+	// ImageHistory returns the newest entry first
+	history := []image.HistoryResponseItem{
+		{CreatedBy: "ENV A=b", Size: 0},
+		{CreatedBy: "RUN tweak", Size: 150},
+		{CreatedBy: "ADD rootfs", Size: 1100},
+	}
+
+	analysis, err := AnalyzeImageTar(bytes.NewReader(makeImageArchive(t, layers, config)), history)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(analysis.Layers) != 3 {
+		t.Fatalf("expected 3 layers, got %d", len(analysis.Layers))
+	}
+
+	if analysis.Layers[0].CreatedBy != "ADD rootfs" || !analysis.Layers[2].Empty {
+		t.Errorf("layers are not aligned with history: %#v", analysis.Layers)
+	}
+
+	if analysis.WastedBytes != 1100 {
+		t.Errorf("expected 1100 wasted bytes, got %d", analysis.WastedBytes)
+	}
+
+	kinds := make(map[string]FileChangeKind)
+	for _, file := range analysis.Layers[1].Files {
+		kinds[file.Path] = file.Kind
+	}
+
+	want := map[string]FileChangeKind{
+		"etc":        FileUnchanged,
+		"etc/config": FileModified,
+		"bin/big":    FileRemoved,
+		"opt":        FileAdded,
+	}
+
+	for p, kind := range want {
+		if kinds[p] != kind {
+			t.Errorf("%s: expected kind %d, got %d", p, kind, kinds[p])
+		}
+	}
+}
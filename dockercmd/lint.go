@@ -0,0 +1,244 @@
+package dockercmd
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+)
+
+type LintSeverity int
+
+const (
+	LintLow LintSeverity = iota
+	LintMedium
+	LintHigh
+)
+
+func (s LintSeverity) String() string {
+	switch s {
+	case LintHigh:
+		return "high"
+	case LintMedium:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// A risky setting found in the inspect data of a container or image
+type LintFinding struct {
+	// short id of the check, eg: privileged
+	Check    string
+	Severity LintSeverity
+	// what was found, eg: adds capabilities SYS_ADMIN
+	Summary string
+	// why it is risky and what to do about it
+	Explanation string
+}
+
+var lintExplanations = map[string]string{
+	"privileged":      "A privileged container gets every capability and access to all host devices, escaping it to the host is trivial. Add only the capabilities and devices it needs instead.",
+	"cap-add":         "Extra capabilities widen what a compromised process can do to the kernel and the host, SYS_ADMIN alone is close to privileged. Drop the ones the workload does not need.",
+	"host-network":    "The container shares the host's network stack: it can bind any host port and reach services that only listen on localhost. Publish the ports it needs instead.",
+	"host-pid":        "The container sees every host process and, as root, can signal or ptrace them. Only debugging tools should need this.",
+	"docker-socket":   "Access to the docker socket is root on the host, anyone in the container can start a privileged container. Use a socket proxy that only allows the calls needed.",
+	"root-user":       "Processes run as root (uid 0), a container breakout or a writable host mount gives root on the host unless user namespaces are enabled. Set USER in the Dockerfile or --user.",
+	"no-memory-limit": "Without a memory limit a leak or an attack can exhaust the host's memory and get other containers OOM killed. Set --memory.",
+	"no-cpu-limit":    "Without a cpu limit one container can starve the others. Set --cpus.",
+	"latest-tag":      "latest changes whenever a new version is pushed, what runs depends on when it was pulled and can not be reproduced. Pin a version tag or a digest.",
+	"no-healthcheck":  "Without a healthcheck docker only knows whether the process runs, not whether it works, so hung services are never restarted or reported.",
+	"writable-rootfs": "An attacker can modify binaries and configuration in the container's filesystem. Run with --read-only and mount volumes or tmpfs where writes are needed.",
+	"secret-env":      "Environment variables show up in docker inspect, in the image history and in logs of crashing processes. Pass secrets as files, eg: docker secrets or a read only mount.",
+}
+
+// capabilities that are (nearly) enough to escape to the host
+var dangerousCapabilities = []string{"ALL", "SYS_ADMIN", "SYS_MODULE", "SYS_PTRACE", "SYS_RAWIO", "DAC_READ_SEARCH", "NET_ADMIN", "BPF"}
+
+var dockerSockets = []string{"/var/run/docker.sock", "/run/docker.sock"}
+
+var secretEnvName = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|PRIVATE_?KEY|ACCESS_?KEY|CREDENTIAL)`)
+
+// Inspects the container and checks its configuration for risky settings, the most severe findings come first
+func (dc *DockerClient) LintContainer(id string) ([]LintFinding, error) {
+	info, err := dc.InspectContainer(id)
+	if err != nil {
+		return nil, err
+	}
+	return lintContainer(info), nil
+}
+
+// Inspects the image and checks its configuration for risky settings, the most severe findings come first
+func (dc *DockerClient) LintImage(id string) ([]LintFinding, error) {
+	info, err := dc.InspectImage(id)
+	if err != nil {
+		return nil, err
+	}
+	return lintImage(info), nil
+}
+
+func lintContainer(info *types.ContainerJSON) []LintFinding {
+	var res []LintFinding
+	add := func(check string, severity LintSeverity, summary string) {
+		res = append(res, LintFinding{Check: check, Severity: severity, Summary: summary, Explanation: lintExplanations[check]})
+	}
+
+	if hostConfig := info.HostConfig; hostConfig != nil {
+		if hostConfig.Privileged {
+			add("privileged", LintHigh, "runs privileged")
+		}
+
+		if len(hostConfig.CapAdd) > 0 {
+			severity := LintMedium
+			for _, capability := range hostConfig.CapAdd {
+				if slices.Contains(dangerousCapabilities, strings.TrimPrefix(strings.ToUpper(capability), "CAP_")) {
+					severity = LintHigh
+				}
+			}
+			add("cap-add", severity, "adds capabilities "+strings.Join(hostConfig.CapAdd, ", "))
+		}
+
+		if hostConfig.NetworkMode.IsHost() {
+			add("host-network", LintMedium, "uses the host network")
+		}
+		if hostConfig.PidMode.IsHost() {
+			add("host-pid", LintHigh, "uses the host pid namespace")
+		}
+
+		if hostConfig.Memory == 0 {
+			add("no-memory-limit", LintLow, "has no memory limit")
+		}
+		if hostConfig.NanoCPUs == 0 && hostConfig.CPUQuota == 0 {
+			add("no-cpu-limit", LintLow, "has no cpu limit")
+		}
+		if !hostConfig.ReadonlyRootfs {
+			add("writable-rootfs", LintLow, "root filesystem is writable")
+		}
+	}
+
+	for _, mp := range info.Mounts {
+		if mp.Type == mount.TypeBind && slices.Contains(dockerSockets, mp.Source) {
+			add("docker-socket", LintHigh, "mounts the docker socket at "+mp.Destination)
+		}
+	}
+
+	if config := info.Config; config != nil {
+		if isRootUser(config.User) {
+			add("root-user", LintMedium, "runs as root")
+		}
+		if isLatestRef(config.Image) {
+			add("latest-tag", LintLow, "uses "+config.Image)
+		}
+		if !hasHealthcheck(config) {
+			add("no-healthcheck", LintLow, "has no healthcheck")
+		}
+		if names := secretEnvNames(config.Env); len(names) > 0 {
+			add("secret-env", LintMedium, "passes secrets in "+strings.Join(names, ", "))
+		}
+	}
+
+	sortLintFindings(res)
+	return res
+}
+
+func lintImage(info *types.ImageInspect) []LintFinding {
+	var res []LintFinding
+	add := func(check string, severity LintSeverity, summary string) {
+		res = append(res, LintFinding{Check: check, Severity: severity, Summary: summary, Explanation: lintExplanations[check]})
+	}
+
+	for _, tag := range info.RepoTags {
+		if isLatestRef(tag) {
+			add("latest-tag", LintLow, "tagged "+tag)
+			break
+		}
+	}
+
+	if config := info.Config; config != nil {
+		if isRootUser(config.User) {
+			add("root-user", LintMedium, "runs as root by default")
+		}
+		if !hasHealthcheck(config) {
+			add("no-healthcheck", LintLow, "has no healthcheck")
+		}
+		if names := secretEnvNames(config.Env); len(names) > 0 {
+			add("secret-env", LintHigh, "bakes secrets into "+strings.Join(names, ", "))
+		}
+	}
+
+	sortLintFindings(res)
+	return res
+}
+
+// most severe first, checks keep their order otherwise
+func sortLintFindings(findings []LintFinding) {
+	slices.SortStableFunc(findings, func(a LintFinding, b LintFinding) int {
+		return cmp.Compare(b.Severity, a.Severity)
+	})
+}
+
+// highest severity of the findings, ok is false if there are none
+func MaxLintSeverity(findings []LintFinding) (LintSeverity, bool) {
+	if len(findings) == 0 {
+		return LintLow, false
+	}
+	return findings[0].Severity, true
+}
+
+// Counts findings per severity, eg: 2 high, 1 low
+func LintSummary(findings []LintFinding) string {
+	var parts []string
+	for severity := LintHigh; severity >= LintLow; severity-- {
+		count := 0
+		for _, finding := range findings {
+			if finding.Severity == severity {
+				count++
+			}
+		}
+		if count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count, severity))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// an empty user means root, so does uid 0 with any group
+func isRootUser(user string) bool {
+	name, _, _ := strings.Cut(user, ":")
+	return name == "" || name == "root" || name == "0"
+}
+
+// references without a tag or digest implicitly use latest
+func isLatestRef(ref string) bool {
+	if ref == "" || strings.HasPrefix(ref, "sha256:") || strings.Contains(ref, "@") {
+		return false
+	}
+
+	// the registry can have a port, the tag comes after the last path segment's colon
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	_, tag, ok := strings.Cut(name, ":")
+	return !ok || tag == "latest"
+}
+
+func hasHealthcheck(config *container.Config) bool {
+	return config.Healthcheck != nil && len(config.Healthcheck.Test) > 0 && config.Healthcheck.Test[0] != "NONE"
+}
+
+// names of environment variables that look like they hold a secret, values are never returned. Variables pointing to
+// a file (eg: POSTGRES_PASSWORD_FILE) are fine
+func secretEnvNames(env []string) []string {
+	var res []string
+	for _, entry := range env {
+		name, value, _ := strings.Cut(entry, "=")
+		if value == "" || strings.HasSuffix(strings.ToUpper(name), "_FILE") || !secretEnvName.MatchString(name) {
+			continue
+		}
+		res = append(res, name)
+	}
+	return res
+}
@@ -0,0 +1,117 @@
+package dockercmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+)
+
+func lintChecks(findings []LintFinding) []string {
+	var res []string
+	for _, finding := range findings {
+		res = append(res, finding.Check+":"+finding.Severity.String())
+	}
+	return res
+}
+
+func TestLintContainer(t *testing.T) {
+	risky := &types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			HostConfig: &container.HostConfig{
+				Privileged:  true,
+				CapAdd:      []string{"NET_RAW"},
+				NetworkMode: "host",
+				PidMode:     "host",
+			},
+		},
+		Mounts: []types.MountPoint{
+			{Type: mount.TypeBind, Source: "/var/run/docker.sock", Destination: "/var/run/docker.sock"},
+			{Type: mount.TypeVolume, Source: "/var/lib/docker/volumes/data/_data", Destination: "/data"},
+		},
+		Config: &container.Config{
+			Image: "localhost:5000/app",
+			Env:   []string{"PATH=/usr/bin", "DB_PASSWORD=hunter2", "POSTGRES_PASSWORD_FILE=/run/secrets/db", "API_TOKEN="},
+		},
+	}
+
+	got := lintChecks(lintContainer(risky))
+	want := []string{
+		"privileged:high", "host-pid:high", "docker-socket:high",
+		"cap-add:medium", "host-network:medium", "root-user:medium", "secret-env:medium",
+		"no-memory-limit:low", "no-cpu-limit:low", "writable-rootfs:low", "latest-tag:low", "no-healthcheck:low",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got findings %v, want %v", got, want)
+	}
+
+	for _, finding := range lintContainer(risky) {
+		if finding.Explanation == "" {
+			t.Errorf("%s has no explanation", finding.Check)
+		}
+		if finding.Check == "secret-env" && finding.Summary != "passes secrets in DB_PASSWORD" {
+			t.Errorf("unexpected summary %q", finding.Summary)
+		}
+	}
+
+	hardened := &types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			HostConfig: &container.HostConfig{
+				CapAdd:         []string{"CAP_SYS_ADMIN"},
+				ReadonlyRootfs: true,
+				Resources:      container.Resources{Memory: 512 << 20, NanoCPUs: 1e9},
+			},
+		},
+		Config: &container.Config{
+			Image:       "registry:5000/app:1.2@sha256:abc",
+			User:        "1000:1000",
+			Healthcheck: &container.HealthConfig{Test: []string{"CMD", "true"}},
+		},
+	}
+
+	if got := lintChecks(lintContainer(hardened)); !slices.Equal(got, []string{"cap-add:high"}) {
+		t.Errorf("got findings %v, want only the dangerous capability", got)
+	}
+}
+
+func TestLintImage(t *testing.T) {
+	info := &types.ImageInspect{
+		RepoTags: []string{"app:1.0", "app:latest"},
+		Config: &container.Config{
+			User:        "root",
+			Healthcheck: &container.HealthConfig{Test: []string{"NONE"}},
+			Env:         []string{"AWS_SECRET_ACCESS_KEY=abc"},
+		},
+	}
+
+	findings := lintImage(info)
+	if got, want := lintChecks(findings), []string{"secret-env:high", "root-user:medium", "latest-tag:low", "no-healthcheck:low"}; !slices.Equal(got, want) {
+		t.Errorf("got findings %v, want %v", got, want)
+	}
+	if summary := LintSummary(findings); summary != "1 high, 1 medium, 2 low" {
+		t.Errorf("unexpected summary %q", summary)
+	}
+	if severity, ok := MaxLintSeverity(findings); !ok || severity != LintHigh {
+		t.Errorf("got max severity %v", severity)
+	}
+}
+
+func TestIsLatestRef(t *testing.T) {
+	cases := map[string]bool{
+		"nginx":                  true,
+		"nginx:latest":           true,
+		"localhost:5000/app":     true,
+		"localhost:5000/app:1.0": false,
+		"nginx:1.25":             false,
+		"nginx@sha256:abc":       false,
+		"sha256:abc":             false,
+	}
+
+	for ref, want := range cases {
+		if got := isLatestRef(ref); got != want {
+			t.Errorf("isLatestRef(%q) = %v, want %v", ref, got, want)
+		}
+	}
+}
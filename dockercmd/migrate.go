@@ -0,0 +1,517 @@
+package dockercmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+)
+
+type MigrationStepState int
+
+const (
+	MigrationPending MigrationStepState = iota
+	MigrationRunning
+	MigrationDone
+	// nothing to do, eg: the target already has the image
+	MigrationSkipped
+	MigrationFailed
+)
+
+// one step of a migration, Transferred and Total are only set for steps that move data (Total is 0 when unknown)
+type MigrationStep struct {
+	Key   string
+	Name  string
+	State MigrationStepState
+	// done by an earlier, interrupted run
+	Resumed     bool
+	Transferred int64
+	Total       int64
+	// why a step was skipped or what was left out, eg: bind mounts
+	Note string
+	Err  error
+}
+
+// called with a copy of every step whenever one of them changes
+type MigrationProgressFunc func(steps []MigrationStep)
+
+type MigrateOpts struct {
+	// remove the container from the source once it runs on the target, its volumes are kept
+	RemoveSource bool
+}
+
+// how long a migrated container gets to become healthy on the target
+const migrationHealthTimeout = 60 * time.Second
+
+const (
+	migrateImageStep  = "image"
+	migrateStopStep   = "stop"
+	migrateVolumeStep = "volume:"
+	migrateCreateStep = "create"
+	migrateStartStep  = "start"
+	migrateRemoveStep = "remove"
+)
+
+// Progress of a migration, saved after every step so running the same migration again continues where it stopped
+type migrationState struct {
+	Container  string
+	Target     string
+	WasRunning bool
+	Done       []string
+	// volumes created on the target by this migration, they are emptied and filled again when a copy is retried
+	CreatedVolumes []string
+	// the container created on the target
+	TargetID string
+}
+
+// Moves a container to the daemon of target: its image is streamed over (save/load), its volumes are copied, and it
+// is created there with the same configuration. A running container is stopped during the copy and started on the
+// target. Bind mounted host paths are not copied.
+//
+// Finished steps are remembered, when a migration fails running it again skips them. If it fails before the container
+// runs on the target, the source container is started again and its volumes will be copied again on the next run.
+func (dc *DockerClient) MigrateContainer(id string, host DockerHost, opts MigrateOpts, progress MigrationProgressFunc) (err error) {
+	ctx := context.Background()
+
+	target, err := NewDockerClientForHost(host)
+	if err != nil {
+		return err
+	}
+	defer target.cli.Close()
+
+	sourceInfo, err := dc.cli.Info(ctx)
+	if err != nil {
+		return err
+	}
+	targetInfo, err := target.cli.Info(ctx)
+	if err != nil {
+		return fmt.Errorf("could not reach %s: %w", host, err)
+	}
+	if sourceInfo.ID == targetInfo.ID {
+		return fmt.Errorf("%s is the daemon the container already runs on", host)
+	}
+
+	info, err := dc.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return err
+	}
+	id = info.ID
+
+	img, _, err := dc.cli.ImageInspectWithRaw(ctx, info.Image)
+	if err != nil {
+		return err
+	}
+
+	statePath, err := migrationStatePath(id, host.Host)
+	if err != nil {
+		return err
+	}
+
+	state, err := loadMigrationState(statePath)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		state = &migrationState{Container: id, Target: host.Host, WasRunning: info.State.Running}
+	}
+
+	var volumes []string
+	for _, m := range info.Mounts {
+		if m.Type == mount.TypeVolume {
+			volumes = append(volumes, m.Name)
+		}
+	}
+
+	steps := planMigration(state, volumes, opts)
+	report := func() {
+		if progress != nil {
+			progress(slices.Clone(steps))
+		}
+	}
+	report()
+
+	m := migration{source: dc, target: target, info: info, image: img, state: state}
+
+	for i := range steps {
+		step := &steps[i]
+		if step.State != MigrationPending {
+			continue
+		}
+
+		step.State = MigrationRunning
+		report()
+
+		skipped, note, stepErr := m.run(step.Key, func(transferred int64, total int64) {
+			step.Transferred, step.Total = transferred, total
+			report()
+		})
+		step.Note = note
+
+		if stepErr != nil {
+			step.State = MigrationFailed
+			step.Err = stepErr
+			report()
+
+			err = fmt.Errorf("%s: %w", step.Name, stepErr)
+			err = errors.Join(err, m.restoreSource())
+			return errors.Join(err, state.save(statePath))
+		}
+
+		step.State = MigrationDone
+		if skipped {
+			step.State = MigrationSkipped
+		}
+		report()
+
+		state.Done = append(state.Done, step.Key)
+		if err := state.save(statePath); err != nil {
+			return err
+		}
+	}
+
+	// finished, the next migration of this container starts from scratch
+	return os.Remove(statePath)
+}
+
+// Steps left to do, the ones finished by an earlier run are marked done
+func planMigration(state *migrationState, volumes []string, opts MigrateOpts) []MigrationStep {
+	steps := []MigrationStep{{Key: migrateImageStep, Name: "transfer image"}}
+
+	if state.WasRunning {
+		steps = append(steps, MigrationStep{Key: migrateStopStep, Name: "stop container"})
+	}
+
+	for _, name := range volumes {
+		steps = append(steps, MigrationStep{Key: migrateVolumeStep + name, Name: "copy volume " + shortVolumeName(name)})
+	}
+
+	steps = append(steps, MigrationStep{Key: migrateCreateStep, Name: "create container on target"})
+
+	if state.WasRunning {
+		steps = append(steps, MigrationStep{Key: migrateStartStep, Name: "start container on target"})
+	}
+
+	if opts.RemoveSource {
+		steps = append(steps, MigrationStep{Key: migrateRemoveStep, Name: "remove source container"})
+	}
+
+	for i := range steps {
+		if slices.Contains(state.Done, steps[i].Key) {
+			steps[i].State = MigrationDone
+			steps[i].Resumed = true
+		}
+	}
+
+	return steps
+}
+
+// anonymous volumes are named by a 64 character hash
+func shortVolumeName(name string) string {
+	if isAnonymousVolume(name) {
+		return name[:12]
+	}
+	return name
+}
+
+type migration struct {
+	source *DockerClient
+	target DockerClient
+	info   types.ContainerJSON
+	image  types.ImageInspect
+	state  *migrationState
+}
+
+// runs a single step, skipped is set when there was nothing to do
+func (m *migration) run(key string, progress func(transferred int64, total int64)) (skipped bool, note string, err error) {
+	switch {
+	case key == migrateImageStep:
+		return m.transferImage(progress)
+
+	case key == migrateStopStep:
+		return false, "", m.source.cli.ContainerStop(context.Background(), m.info.ID, container.StopOptions{})
+
+	case strings.HasPrefix(key, migrateVolumeStep):
+		return m.copyVolume(strings.TrimPrefix(key, migrateVolumeStep), progress)
+
+	case key == migrateCreateStep:
+		return m.createContainer()
+
+	case key == migrateStartStep:
+		if err := m.target.cli.ContainerStart(context.Background(), m.state.TargetID, container.StartOptions{}); err != nil {
+			return false, "", err
+		}
+		if err := m.target.waitHealthy(m.state.TargetID, migrationHealthTimeout, func(string, ...any) {}); err != nil {
+			// the source container takes over again
+			m.target.cli.ContainerStop(context.Background(), m.state.TargetID, container.StopOptions{})
+			return false, "", err
+		}
+		return false, "", nil
+
+	case key == migrateRemoveStep:
+		return false, "volumes are kept", m.source.cli.ContainerRemove(context.Background(), m.info.ID, container.RemoveOptions{})
+	}
+
+	return false, "", fmt.Errorf("unknown migration step %s", key)
+}
+
+func (m *migration) transferImage(progress func(int64, int64)) (bool, string, error) {
+	ctx := context.Background()
+
+	if _, _, err := m.target.cli.ImageInspectWithRaw(ctx, m.image.ID); err == nil {
+		// the tags may still be missing, eg: when the image was loaded by id before
+		for _, tag := range m.image.RepoTags {
+			if err := m.target.cli.ImageTag(ctx, m.image.ID, tag); err != nil {
+				return false, "", err
+			}
+		}
+		return true, "target already has the image", nil
+	} else if !client.IsErrNotFound(err) {
+		return false, "", err
+	}
+
+	// saving the tags instead of the id keeps them on the target
+	refs := m.image.RepoTags
+	if len(refs) == 0 {
+		refs = []string{m.image.ID}
+	}
+
+	rc, err := m.source.cli.ImageSave(ctx, refs)
+	if err != nil {
+		return false, "", err
+	}
+	defer rc.Close()
+
+	res, err := m.target.cli.ImageLoad(ctx, &progressReader{r: rc, progress: func(n int64) {
+		progress(n, m.image.Size)
+	}}, true)
+	if err != nil {
+		return false, "", err
+	}
+	defer res.Body.Close()
+
+	if !res.JSON {
+		_, err = io.Copy(io.Discard, res.Body)
+		return false, "", err
+	}
+	return false, "", writeJSONMessages(res.Body, io.Discard)
+}
+
+func (m *migration) copyVolume(name string, progress func(int64, int64)) (bool, string, error) {
+	ctx := context.Background()
+
+	vol, err := m.source.InspectVolume(name)
+	if err != nil {
+		return false, "", err
+	}
+
+	exists, err := m.target.VolumeExists(name)
+	if err != nil {
+		return false, "", err
+	}
+
+	if exists && !slices.Contains(m.state.CreatedVolumes, name) {
+		return false, "", fmt.Errorf("volume %s already exists on the target", name)
+	}
+
+	// a retried copy would only add and overwrite files, files deleted on the source meanwhile would stay
+	if exists && !sharesStorage(vol) {
+		if err := m.target.EmptyVolume(name); err != nil {
+			return false, "", err
+		}
+	}
+
+	if !exists {
+		_, err := m.target.cli.VolumeCreate(ctx, volume.CreateOptions{
+			Name:       name,
+			Driver:     vol.Driver,
+			DriverOpts: vol.Options,
+			Labels:     vol.Labels,
+		})
+		if err != nil {
+			return false, "", err
+		}
+		m.state.CreatedVolumes = append(m.state.CreatedVolumes, name)
+	}
+
+	if sharesStorage(vol) {
+		return true, "data lives on " + vol.Options["device"] + ", not copied", nil
+	}
+
+	var total int64
+	if vol.UsageData != nil {
+		total = vol.UsageData.Size
+	}
+
+	return false, "", copyVolumeData(*m.source, name, m.target, name, func(n int64) {
+		progress(n, total)
+	})
+}
+
+func (m *migration) createContainer() (bool, string, error) {
+	ctx := context.Background()
+	name := strings.TrimPrefix(m.info.Name, "/")
+
+	var note string
+	if len(bindMounts(m.info)) > 0 {
+		note = "bind mounted paths have to exist on the target: " + strings.Join(bindMounts(m.info), ", ")
+	}
+
+	if m.state.TargetID != "" {
+		if _, err := m.target.cli.ContainerInspect(ctx, m.state.TargetID); err == nil {
+			return true, note, nil
+		}
+	}
+
+	config, hostConfig, networkingConfig, extraNetworks := recreateConfig(m.info, m.image)
+
+	// the image may only have been loaded by id
+	if _, _, err := m.target.cli.ImageInspectWithRaw(ctx, config.Image); err != nil {
+		config.Image = m.image.ID
+	}
+
+	networks := make([]string, 0, len(networkingConfig.EndpointsConfig)+len(extraNetworks))
+	for networkName := range networkingConfig.EndpointsConfig {
+		networks = append(networks, networkName)
+	}
+	for networkName := range extraNetworks {
+		networks = append(networks, networkName)
+	}
+	for _, networkName := range networks {
+		if err := m.ensureNetwork(networkName); err != nil {
+			return false, note, err
+		}
+	}
+
+	created, err := m.target.cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, name)
+	if err != nil {
+		return false, note, err
+	}
+
+	for networkName, endpoint := range extraNetworks {
+		if err := m.target.cli.NetworkConnect(ctx, networkName, created.ID, endpoint); err != nil {
+			// a retry creates it again
+			m.target.cli.ContainerRemove(ctx, created.ID, container.RemoveOptions{})
+			return false, note, err
+		}
+	}
+
+	m.state.TargetID = created.ID
+	return false, note, nil
+}
+
+// creates user defined networks missing on the target with the source's settings
+func (m *migration) ensureNetwork(name string) error {
+	ctx := context.Background()
+
+	if slices.Contains([]string{"bridge", "host", "none", "default"}, name) {
+		return nil
+	}
+
+	_, err := m.target.cli.NetworkInspect(ctx, name, types.NetworkInspectOptions{})
+	if err == nil || !client.IsErrNotFound(err) {
+		return err
+	}
+
+	source, err := m.source.cli.NetworkInspect(ctx, name, types.NetworkInspectOptions{})
+	if err != nil {
+		return err
+	}
+
+	ipam := source.IPAM
+	_, err = m.target.cli.NetworkCreate(ctx, name, types.NetworkCreate{
+		Driver:     source.Driver,
+		EnableIPv6: source.EnableIPv6,
+		IPAM:       &ipam,
+		Internal:   source.Internal,
+		Attachable: source.Attachable,
+		Options:    source.Options,
+		Labels:     source.Labels,
+	})
+	return err
+}
+
+// Starts the source container again after a failed migration, unless it already runs on the target. Its volumes may
+// change while it runs, so they have to be copied again.
+func (m *migration) restoreSource() error {
+	if !m.state.WasRunning || slices.Contains(m.state.Done, migrateStartStep) || !slices.Contains(m.state.Done, migrateStopStep) {
+		return nil
+	}
+
+	m.state.Done = slices.DeleteFunc(m.state.Done, func(key string) bool {
+		return key == migrateStopStep || strings.HasPrefix(key, migrateVolumeStep)
+	})
+
+	if err := m.source.cli.ContainerStart(context.Background(), m.info.ID, container.StartOptions{}); err != nil {
+		return fmt.Errorf("could not start the source container again: %w", err)
+	}
+	return nil
+}
+
+func bindMounts(info types.ContainerJSON) []string {
+	var res []string
+	for _, m := range info.Mounts {
+		if m.Type == mount.TypeBind {
+			res = append(res, m.Source)
+		}
+	}
+	return res
+}
+
+// Migrations are keyed by container and target, so migrating a container to two hosts does not mix up their progress
+func migrationStatePath(id string, target string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "gomanagedocker", "migrations", id[:min(12, len(id))]+"-"+hostKey(target)+".json"), nil
+}
+
+// returns nil (and no error) when there is no unfinished migration
+func loadMigrationState(path string) (*migrationState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var state migrationState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid migration state %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+func (s *migrationState) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// true when an earlier migration of the container to host did not finish
+func HasUnfinishedMigration(id string, host DockerHost) bool {
+	path, err := migrationStatePath(id, host.Host)
+	if err != nil {
+		return false
+	}
+	state, err := loadMigrationState(path)
+	return err == nil && state != nil
+}
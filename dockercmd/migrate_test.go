@@ -0,0 +1,115 @@
+package dockercmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanMigration(t *testing.T) {
+	anonymous := strings.Repeat("ab", 32)
+
+	state := &migrationState{WasRunning: true, Done: []string{migrateImageStep, migrateStopStep}}
+	steps := planMigration(state, []string{"data", anonymous}, MigrateOpts{RemoveSource: true})
+
+	var keys, names []string
+	for _, step := range steps {
+		keys = append(keys, step.Key)
+		names = append(names, step.Name)
+	}
+
+	wantKeys := []string{"image", "stop", "volume:data", "volume:" + anonymous, "create", "start", "remove"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("got steps %v, want %v", keys, wantKeys)
+	}
+	if names[3] != "copy volume "+anonymous[:12] {
+		t.Errorf("anonymous volume names should be shortened, got %q", names[3])
+	}
+
+	for i, step := range steps {
+		resumed := i < 2
+		if step.Resumed != resumed || (step.State == MigrationDone) != resumed {
+			t.Errorf("%s: got state %v (resumed %v)", step.Key, step.State, step.Resumed)
+		}
+	}
+
+	// stopped containers are neither stopped nor started
+	steps = planMigration(&migrationState{}, nil, MigrateOpts{})
+	keys = nil
+	for _, step := range steps {
+		keys = append(keys, step.Key)
+	}
+	if want := []string{"image", "create"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("got steps %v, want %v", keys, want)
+	}
+}
+
+func TestMigrationState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "migrations", "state.json")
+
+	state, err := loadMigrationState(path)
+	if err != nil || state != nil {
+		t.Fatalf("expected no state, got %v, %v", state, err)
+	}
+
+	want := &migrationState{Container: "abc", Target: "ssh://host", WasRunning: true, Done: []string{"image"}, TargetID: "def"}
+	if err := want.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = loadMigrationState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("got %+v, want %+v", state, want)
+	}
+
+	a, _ := migrationStatePath("abc", "ssh://a")
+	b, _ := migrationStatePath("abc", "ssh://b")
+	if a == b {
+		t.Error("migrations to different hosts should not share their state")
+	}
+}
+
+func TestDockerContextHosts(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+
+	writeContext := func(hash string, meta string) {
+		path := filepath.Join(dir, "contexts", "meta", hash)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "meta.json"), []byte(meta), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeContext("1", `{"Name": "remote", "Endpoints": {"docker": {"Host": "tcp://10.0.0.2:2376"}}}`)
+	writeContext("2", `{"Name": "builder", "Endpoints": {"docker": {"Host": "ssh://me@builder"}}}`)
+	writeContext("3", `{"Name": "k8s", "Endpoints": {"kubernetes": {}}}`)
+
+	tlsDir := filepath.Join(dir, "contexts", "tls", "1", "docker")
+	if err := os.MkdirAll(tlsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tlsDir, "ca.pem"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	hosts, err := DockerContextHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []DockerHost{
+		{Name: "builder", Host: "ssh://me@builder"},
+		{Name: "remote", Host: "tcp://10.0.0.2:2376", TLSDir: tlsDir},
+	}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("got %+v, want %+v", hosts, want)
+	}
+}
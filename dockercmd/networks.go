@@ -0,0 +1,21 @@
+package dockercmd
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+func (dc *DockerClient) ListNetworks() ([]types.NetworkResource, error) {
+	return dc.cli.NetworkList(context.Background(), types.NetworkListOptions{})
+}
+
+// containers (running or not) connected to the network
+func (dc *DockerClient) ContainersOnNetwork(networkId string) ([]types.Container, error) {
+	return dc.cli.ContainerList(context.Background(), container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("network", networkId)),
+	})
+}
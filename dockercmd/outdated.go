@@ -0,0 +1,179 @@
+package dockercmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// result of comparing a local image against the registry
+type ImageUpdateStatus struct {
+	// reference the containers were created with, eg: nginx:1.25
+	Ref          string
+	ImageID      string
+	RemoteDigest string
+	Outdated     bool
+	// set when the registry could not be asked, eg: locally built images or private registries without login
+	Err error
+}
+
+// max number of registries queried at once
+const updateCheckConcurrency = 4
+
+// Checks the images of running containers against their registry. The result is keyed by local image id.
+func (dc *DockerClient) CheckImageUpdates() (map[string]ImageUpdateStatus, error) {
+	containers, err := dc.cli.ContainerList(context.Background(), container.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("status", "running")),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// containers created from an image id (or a tag that was moved since) have nothing to compare against
+	refs := make(map[string]string)
+	for _, c := range containers {
+		if _, err := reference.ParseNormalizedNamed(c.Image); err == nil && !strings.HasPrefix(c.Image, "sha256:") {
+			refs[c.ImageID] = c.Image
+		}
+	}
+
+	res := make(map[string]ImageUpdateStatus, len(refs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, updateCheckConcurrency)
+
+	for imageId, ref := range refs {
+		wg.Add(1)
+		go func(imageId string, ref string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			status := dc.checkImageUpdate(imageId, ref)
+
+			mu.Lock()
+			res[imageId] = status
+			mu.Unlock()
+		}(imageId, ref)
+	}
+
+	wg.Wait()
+	return res, nil
+}
+
+func (dc *DockerClient) checkImageUpdate(imageId string, ref string) ImageUpdateStatus {
+	status := ImageUpdateStatus{Ref: ref, ImageID: imageId}
+
+	img, _, err := dc.cli.ImageInspectWithRaw(context.Background(), imageId)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+
+	if len(img.RepoDigests) == 0 {
+		status.Err = errors.New("image was not pulled from a registry")
+		return status
+	}
+
+	dist, err := dc.cli.DistributionInspect(context.Background(), ref, dc.registryAuth(ref))
+	if err != nil {
+		status.Err = err
+		return status
+	}
+
+	status.RemoteDigest = dist.Descriptor.Digest.String()
+	status.Outdated, status.Err = isImageOutdated(img.RepoDigests, ref, status.RemoteDigest)
+	return status
+}
+
+// Compares the registry digest of ref with the local repo digests (`name@sha256:...`) of the same repository
+func isImageOutdated(repoDigests []string, ref string, remoteDigest string) (bool, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	for _, repoDigest := range repoDigests {
+		local, err := reference.ParseNormalizedNamed(repoDigest)
+		if err != nil || local.Name() != named.Name() {
+			continue
+		}
+
+		found = true
+		if canonical, ok := local.(reference.Canonical); ok && canonical.Digest().String() == remoteDigest {
+			return false, nil
+		}
+	}
+
+	if !found {
+		return false, fmt.Errorf("no local digest for %s", reference.FamiliarName(named))
+	}
+
+	return true, nil
+}
+
+// Pulls every ref and recreates the running containers created from it that still use an older image
+func (dc *DockerClient) UpdateImages(refs []string, log io.Writer) error {
+	var errs []error
+
+	for _, ref := range refs {
+		// listed before pulling: once the tag moves, containers of the old image report its id instead of ref
+		running, err := dc.cli.ContainerList(context.Background(), container.ListOptions{
+			Filters: filters.NewArgs(filters.Arg("status", "running")),
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		containers := containersOfRef(running, ref)
+
+		fmt.Fprintf(log, "pulling %s\n", ref)
+		if err := dc.PullImage(ref, log); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ref, err))
+			continue
+		}
+
+		img, _, err := dc.cli.ImageInspectWithRaw(context.Background(), ref)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, c := range containers {
+			if c.ImageID == img.ID {
+				continue
+			}
+
+			fmt.Fprintf(log, "recreating %s\n", ContainerName(c))
+			if err := dc.RecreateContainer(c.ID, RecreateOpts{HealthTimeout: defaultUpdateHealthTimeout}, log); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", ContainerName(c), err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// containers created from ref, containers created from an image id are never updated
+func containersOfRef(list []types.Container, ref string) []types.Container {
+	var res []types.Container
+	for _, c := range list {
+		if c.Image == ref {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// health timeout used for containers recreated by UpdateImages
+const defaultUpdateHealthTimeout = 60 * time.Second
@@ -0,0 +1,55 @@
+package dockercmd
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestIsImageOutdated(t *testing.T) {
+	const current = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	const newer = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	cases := []struct {
+		name        string
+		repoDigests []string
+		ref         string
+		remote      string
+		outdated    bool
+		wantErr     bool
+	}{
+		{"up to date", []string{"nginx@" + current}, "nginx:latest", current, false, false},
+		{"outdated", []string{"nginx@" + current}, "nginx:1.25", newer, true, false},
+		{"normalized names", []string{"docker.io/library/nginx@" + current}, "nginx", current, false, false},
+		{"any matching digest", []string{"nginx@" + newer, "nginx@" + current}, "nginx", current, false, false},
+		{"other repository only", []string{"myregistry.local/nginx@" + current}, "nginx", current, false, true},
+	}
+
+	for _, c := range cases {
+		outdated, err := isImageOutdated(c.repoDigests, c.ref, c.remote)
+		if (err != nil) != c.wantErr {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if outdated != c.outdated {
+			t.Errorf("%s: got outdated=%v, want %v", c.name, outdated, c.outdated)
+		}
+	}
+}
+
+func TestContainersOfRef(t *testing.T) {
+	list := []types.Container{
+		{ID: "web", Image: "nginx:1.25", ImageID: "sha256:old"},
+		{ID: "proxy", Image: "nginx:1.25", ImageID: "sha256:new"},
+		{ID: "pinned", Image: "sha256:old", ImageID: "sha256:old"},
+		{ID: "db", Image: "postgres:16", ImageID: "sha256:pg"},
+	}
+
+	got := containersOfRef(list, "nginx:1.25")
+	if len(got) != 2 || got[0].ID != "web" || got[1].ID != "proxy" {
+		t.Errorf("got %v, want the web and proxy containers", got)
+	}
+
+	if got := containersOfRef(list, "redis"); len(got) != 0 {
+		t.Errorf("got %v, want no containers", got)
+	}
+}
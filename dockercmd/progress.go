@@ -0,0 +1,65 @@
+package dockercmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// called with the total number of bytes transferred so far
+type ProgressFunc func(transferred int64)
+
+type progressReader struct {
+	r           io.Reader
+	transferred int64
+	progress    ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.transferred += int64(n)
+
+	if p.progress != nil && n > 0 {
+		p.progress(p.transferred)
+	}
+
+	return n, err
+}
+
+// Writes the status lines of a json message stream (as returned by pull, push, load...) to w, progress bar updates
+// are skipped since w is line based. Returns the error reported in the stream, if any.
+func writeJSONMessages(r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	lastStatus := make(map[string]string)
+
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if msg.Error != nil {
+			return msg.Error
+		}
+
+		if msg.Stream != "" {
+			fmt.Fprint(w, msg.Stream)
+			continue
+		}
+
+		if msg.Status == "" || lastStatus[msg.ID] == msg.Status {
+			continue
+		}
+		lastStatus[msg.ID] = msg.Status
+
+		if msg.ID != "" {
+			fmt.Fprintf(w, "%s: %s\n", msg.ID, msg.Status)
+		} else {
+			fmt.Fprintln(w, msg.Status)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package dockercmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// state of a single layer while pushing (or pulling/loading), eg: Preparing, Pushing, Pushed, Layer already exists
+type LayerProgress struct {
+	ID      string
+	Status  string
+	Current int64
+	// 0 when not known
+	Total int64
+}
+
+// called for every layer update, and with an empty ID for general status lines (eg: the pushed digest)
+type LayerProgressFunc func(LayerProgress)
+
+// Returned when the registry rejected the credentials (or there were none)
+type RegistryAuthError struct {
+	Server string
+	Err    error
+}
+
+func (e *RegistryAuthError) Error() string {
+	if e.Server == DockerHubServer {
+		return fmt.Sprintf("access to docker hub denied, log in or check that the repository exists and you may push to it (%s)", e.Err)
+	}
+	return fmt.Sprintf("access to %s denied, log in or check that the repository exists and you may push to it (%s)", e.Server, e.Err)
+}
+
+func (e *RegistryAuthError) Unwrap() error {
+	return e.Err
+}
+
+// Tags source as ref (unless they are the same) and pushes ref with the stored credentials of its registry
+func (dc *DockerClient) PushImage(source string, ref string, progress LayerProgressFunc) error {
+	server, err := RegistryServerOf(ref)
+	if err != nil {
+		return err
+	}
+
+	if source != ref {
+		if err := dc.cli.ImageTag(context.Background(), source, ref); err != nil {
+			return err
+		}
+	}
+
+	rc, err := dc.cli.ImagePush(context.Background(), ref, image.PushOptions{RegistryAuth: dc.registryAuth(ref)})
+	if err != nil {
+		return asRegistryAuthError(server, err)
+	}
+	defer rc.Close()
+
+	return asRegistryAuthError(server, readLayerProgress(rc, progress))
+}
+
+// decodes a json message stream into per layer updates, returns the error reported in the stream
+func readLayerProgress(r io.Reader, progress LayerProgressFunc) error {
+	dec := json.NewDecoder(r)
+
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if msg.Error != nil {
+			return msg.Error
+		}
+
+		if msg.Status == "" {
+			continue
+		}
+
+		update := LayerProgress{ID: msg.ID, Status: msg.Status}
+		if msg.Progress != nil {
+			update.Current = msg.Progress.Current
+			update.Total = msg.Progress.Total
+		}
+		progress(update)
+	}
+}
+
+// registries word their auth errors differently, and the push stream reports them as plain messages
+func asRegistryAuthError(server string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err) {
+		return &RegistryAuthError{Server: server, Err: err}
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"unauthorized", "authentication required", "denied", "no basic auth credentials", "insufficient_scope"} {
+		if strings.Contains(msg, s) {
+			return &RegistryAuthError{Server: server, Err: err}
+		}
+	}
+
+	return err
+}
+
+// true if err was caused by missing or rejected credentials
+func IsRegistryAuthError(err error) bool {
+	var authErr *RegistryAuthError
+	return errors.As(err, &authErr)
+}
@@ -0,0 +1,307 @@
+package dockercmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+)
+
+type RecreateOpts struct {
+	// pull the container's image tag before recreating
+	Pull bool
+	// how long to wait for the new container to become healthy, containers without a healthcheck only have to
+	// keep running for StartGracePeriod
+	HealthTimeout time.Duration
+}
+
+// containers without a healthcheck count as started if they are still running after this long
+const StartGracePeriod = 3 * time.Second
+
+// Replaces the container with a new one created from the same configuration (and the current image of its tag).
+// The old container is stopped and renamed, if the new one does not start or becomes unhealthy it is removed and the
+// old one is brought back. Progress is written to log line by line.
+func (dc *DockerClient) RecreateContainer(id string, opts RecreateOpts, log io.Writer) error {
+	ctx := context.Background()
+	logf := func(format string, a ...any) {
+		fmt.Fprintf(log, format+"\n", a...)
+	}
+
+	info, err := dc.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimPrefix(info.Name, "/")
+
+	oldImage, _, err := dc.cli.ImageInspectWithRaw(ctx, info.Image)
+	if err != nil {
+		return err
+	}
+
+	if opts.Pull {
+		logf("pulling %s", info.Config.Image)
+		if err := dc.PullImage(info.Config.Image, log); err != nil {
+			return err
+		}
+	}
+
+	config, hostConfig, networkingConfig, extraNetworks := recreateConfig(info, oldImage)
+
+	wasRunning := info.State.Running
+	if wasRunning {
+		logf("stopping %s", name)
+		if err := dc.cli.ContainerStop(ctx, id, container.StopOptions{}); err != nil {
+			return err
+		}
+	}
+
+	backupName := fmt.Sprintf("%s-old-%d", name, time.Now().Unix())
+	logf("renaming %s to %s", name, backupName)
+	if err := dc.cli.ContainerRename(ctx, id, backupName); err != nil {
+		return errors.Join(err, dc.restoreOld(id, "", name, wasRunning, logf))
+	}
+
+	logf("creating new %s", name)
+	created, err := dc.cli.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, name)
+	if err != nil {
+		return errors.Join(err, dc.restoreOld(id, backupName, name, wasRunning, logf))
+	}
+
+	for networkName, endpoint := range extraNetworks {
+		logf("connecting to network %s", networkName)
+		if err := dc.cli.NetworkConnect(ctx, networkName, created.ID, endpoint); err != nil {
+			return errors.Join(err, dc.rollback(created.ID, id, backupName, name, wasRunning, logf))
+		}
+	}
+
+	// containers that were not running are only recreated, not started
+	if wasRunning {
+		logf("starting %s", name)
+		if err := dc.cli.ContainerStart(ctx, created.ID, container.StartOptions{}); err != nil {
+			return errors.Join(err, dc.rollback(created.ID, id, backupName, name, wasRunning, logf))
+		}
+
+		if err := dc.waitHealthy(created.ID, opts.HealthTimeout, logf); err != nil {
+			return errors.Join(err, dc.rollback(created.ID, id, backupName, name, wasRunning, logf))
+		}
+	}
+
+	logf("removing old container %s", backupName)
+	if err := dc.cli.ContainerRemove(ctx, id, container.RemoveOptions{}); err != nil {
+		return fmt.Errorf("%s was recreated, but the old container could not be removed: %w", name, err)
+	}
+
+	logf("%s recreated", name)
+	return nil
+}
+
+// Builds the create options for a copy of the container. Values that came from the old image are dropped, so the
+// (possibly updated) image of the tag provides them again. Runtime data (generated hostname, addresses) is dropped
+// as well. Only one network endpoint can be set on create, the others are returned in extraNetworks.
+func recreateConfig(info types.ContainerJSON, oldImage types.ImageInspect) (
+	config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig,
+	extraNetworks map[string]*network.EndpointSettings,
+) {
+	c := *info.Config
+	config = &c
+
+	imgConfig := oldImage.Config
+	if imgConfig == nil {
+		imgConfig = &container.Config{}
+	}
+
+	config.Env = slices.DeleteFunc(slices.Clone(config.Env), func(env string) bool {
+		return slices.Contains(imgConfig.Env, env)
+	})
+	if slices.Equal(config.Cmd, imgConfig.Cmd) && slices.Equal(config.Entrypoint, imgConfig.Entrypoint) {
+		config.Cmd = nil
+		config.Entrypoint = nil
+	}
+	if config.WorkingDir == imgConfig.WorkingDir {
+		config.WorkingDir = ""
+	}
+	if config.User == imgConfig.User {
+		config.User = ""
+	}
+	if config.Healthcheck != nil && imgConfig.Healthcheck != nil && healthcheckEqual(config.Healthcheck, imgConfig.Healthcheck) {
+		config.Healthcheck = nil
+	}
+
+	config.Labels = make(map[string]string, len(info.Config.Labels))
+	for k, v := range info.Config.Labels {
+		if imgValue, ok := imgConfig.Labels[k]; !ok || imgValue != v {
+			config.Labels[k] = v
+		}
+	}
+
+	config.Volumes = nil
+	config.ExposedPorts = nil
+	for port := range info.Config.ExposedPorts {
+		if _, fromImage := imgConfig.ExposedPorts[port]; !fromImage {
+			if config.ExposedPorts == nil {
+				config.ExposedPorts = make(map[nat.Port]struct{})
+			}
+			config.ExposedPorts[port] = struct{}{}
+		}
+	}
+
+	// the engine defaults the hostname to the short container id
+	if strings.HasPrefix(info.ID, config.Hostname) {
+		config.Hostname = ""
+	}
+
+	hc := *info.HostConfig
+	hostConfig = &hc
+	hostConfig.Mounts = slices.Clone(hostConfig.Mounts)
+
+	// anonymous volumes are not part of the host config, without this the new container would get empty ones
+	for _, m := range info.Mounts {
+		if m.Type != mount.TypeVolume || !isAnonymousVolume(m.Name) || isMountSpecified(hostConfig, m.Destination) {
+			continue
+		}
+
+		hostConfig.Mounts = append(hostConfig.Mounts, mount.Mount{
+			Type:     mount.TypeVolume,
+			Source:   m.Name,
+			Target:   m.Destination,
+			ReadOnly: !m.RW,
+		})
+	}
+
+	networkingConfig = &network.NetworkingConfig{EndpointsConfig: make(map[string]*network.EndpointSettings)}
+	extraNetworks = make(map[string]*network.EndpointSettings)
+
+	if info.NetworkSettings != nil {
+		primary := string(hostConfig.NetworkMode)
+
+		for name, endpoint := range info.NetworkSettings.Networks {
+			settings := &network.EndpointSettings{
+				IPAMConfig: endpoint.IPAMConfig,
+				Links:      endpoint.Links,
+				DriverOpts: endpoint.DriverOpts,
+				// the engine adds the short container id as an alias on user defined networks
+				Aliases: slices.DeleteFunc(slices.Clone(endpoint.Aliases), func(alias string) bool {
+					return alias == info.ID[:min(12, len(info.ID))]
+				}),
+			}
+
+			if name == primary || (primary == "default" && name == "bridge") {
+				networkingConfig.EndpointsConfig[name] = settings
+			} else {
+				extraNetworks[name] = settings
+			}
+		}
+	}
+
+	return config, hostConfig, networkingConfig, extraNetworks
+}
+
+func isMountSpecified(hostConfig *container.HostConfig, target string) bool {
+	for _, m := range hostConfig.Mounts {
+		if m.Target == target {
+			return true
+		}
+	}
+
+	for _, bind := range hostConfig.Binds {
+		// `source:target[:opts]` or just `target` for anonymous volumes
+		parts := strings.Split(bind, ":")
+		if parts[0] == target || (len(parts) > 1 && parts[1] == target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func healthcheckEqual(a, b *container.HealthConfig) bool {
+	return slices.Equal(a.Test, b.Test) && a.Interval == b.Interval && a.Timeout == b.Timeout &&
+		a.StartPeriod == b.StartPeriod && a.StartInterval == b.StartInterval && a.Retries == b.Retries
+}
+
+// waits until the container is healthy, or for containers without a healthcheck, still running after a grace period
+func (dc *DockerClient) waitHealthy(id string, timeout time.Duration, logf func(string, ...any)) error {
+	deadline := time.Now().Add(timeout)
+	graceEnd := time.Now().Add(StartGracePeriod)
+
+	for {
+		info, err := dc.cli.ContainerInspect(context.Background(), id)
+		if err != nil {
+			return err
+		}
+
+		if !info.State.Running {
+			return fmt.Errorf("new container exited with code %d", info.State.ExitCode)
+		}
+
+		if info.State.Health == nil {
+			if time.Now().After(graceEnd) {
+				return nil
+			}
+		} else {
+			switch info.State.Health.Status {
+			case types.Healthy:
+				logf("new container is healthy")
+				return nil
+			case types.Unhealthy:
+				return errors.New("new container is unhealthy")
+			}
+
+			if time.Now().After(deadline) {
+				return fmt.Errorf("new container did not become healthy within %s", timeout)
+			}
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// removes the new container and brings the old one back
+func (dc *DockerClient) rollback(newId string, oldId string, backupName string, name string, wasRunning bool, logf func(string, ...any)) error {
+	logf("rolling back, removing new container")
+	if err := dc.cli.ContainerRemove(context.Background(), newId, container.RemoveOptions{Force: true}); err != nil {
+		return fmt.Errorf("rollback failed, could not remove the new container: %w", err)
+	}
+
+	return dc.restoreOld(oldId, backupName, name, wasRunning, logf)
+}
+
+func (dc *DockerClient) restoreOld(oldId string, backupName string, name string, wasRunning bool, logf func(string, ...any)) error {
+	if backupName != "" {
+		logf("renaming %s back to %s", backupName, name)
+		if err := dc.cli.ContainerRename(context.Background(), oldId, name); err != nil {
+			return fmt.Errorf("rollback failed, old container is still named %s: %w", backupName, err)
+		}
+	}
+
+	if wasRunning {
+		logf("starting old container")
+		if err := dc.cli.ContainerStart(context.Background(), oldId, container.StartOptions{}); err != nil {
+			return fmt.Errorf("rollback failed, could not start the old container: %w", err)
+		}
+	}
+
+	logf("rolled back to the old container")
+	return nil
+}
+
+// Pulls ref with the stored credentials of its registry, layer progress is written to log
+func (dc *DockerClient) PullImage(ref string, log io.Writer) error {
+	rc, err := dc.cli.ImagePull(context.Background(), ref, image.PullOptions{RegistryAuth: dc.registryAuth(ref)})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return writeJSONMessages(rc, log)
+}
@@ -0,0 +1,92 @@
+package dockercmd
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+)
+
+func TestRecreateConfig(t *testing.T) {
+	info, img := makeTestContainer()
+	info.ID = "0123456789abcdef0123456789abcdef"
+	info.HostConfig.Binds = []string{"web-data:/var/www", "/etc/web.conf:/etc/web.conf:ro"}
+	info.NetworkSettings.Networks = map[string]*network.EndpointSettings{
+		"backend": {
+			Aliases:    []string{"web", "0123456789ab"},
+			IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: "172.20.0.10"},
+			IPAddress:  "172.20.0.10",
+			EndpointID: "runtime-data",
+		},
+		"frontend": {Aliases: []string{"0123456789ab"}},
+	}
+
+	config, hostConfig, networkingConfig, extraNetworks := recreateConfig(info, img)
+
+	if config.Hostname != "" {
+		t.Errorf("generated hostname should be dropped, got %q", config.Hostname)
+	}
+
+	if config.Cmd != nil {
+		t.Errorf("image default command should be dropped, got %v", config.Cmd)
+	}
+
+	if want := []string{"MODE=production", "GREETING=hello world"}; !slices.Equal(config.Env, want) {
+		t.Errorf("env: got %v, want %v", config.Env, want)
+	}
+
+	if want := map[string]string{"team": "web", "com.docker.compose.project": "web"}; !reflect.DeepEqual(config.Labels, want) {
+		t.Errorf("labels: got %v, want %v", config.Labels, want)
+	}
+
+	// the original config must not be modified
+	if len(info.Config.Env) != 4 {
+		t.Errorf("original env was modified: %v", info.Config.Env)
+	}
+
+	wantMounts := []mount.Mount{{
+		Type:   mount.TypeVolume,
+		Source: "4f1c5b0e8a7d2c9b6e3f0a1d4c7b8e5f2a9d6c3b0e7f4a1d8c5b2e9f6a3d0c7b",
+		Target: "/cache",
+	}}
+	if !reflect.DeepEqual(hostConfig.Mounts, wantMounts) {
+		t.Errorf("anonymous volumes should be kept: got %+v", hostConfig.Mounts)
+	}
+
+	wantPrimary := map[string]*network.EndpointSettings{
+		"backend": {
+			Aliases:    []string{"web"},
+			IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: "172.20.0.10"},
+		},
+	}
+	if !reflect.DeepEqual(networkingConfig.EndpointsConfig, wantPrimary) {
+		t.Errorf("primary network: got %+v", networkingConfig.EndpointsConfig["backend"])
+	}
+
+	wantExtra := map[string]*network.EndpointSettings{"frontend": {Aliases: []string{}}}
+	if !reflect.DeepEqual(extraNetworks, wantExtra) {
+		t.Errorf("extra networks: got %+v", extraNetworks)
+	}
+}
+
+func TestRecreateConfigKeepsOverriddenEntrypoint(t *testing.T) {
+	info := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{ID: "abc", HostConfig: &container.HostConfig{}},
+		Config: &container.Config{
+			Image:      "alpine",
+			Entrypoint: []string{"/bin/sh", "-c"},
+			Cmd:        []string{"sleep infinity"},
+		},
+	}
+	img := types.ImageInspect{Config: &container.Config{Cmd: []string{"/bin/sh"}}}
+
+	config, _, _, _ := recreateConfig(info, img)
+
+	if !slices.Equal(config.Entrypoint, info.Config.Entrypoint) || !slices.Equal(config.Cmd, info.Config.Cmd) {
+		t.Errorf("got entrypoint %v cmd %v", config.Entrypoint, config.Cmd)
+	}
+}
@@ -0,0 +1,417 @@
+package dockercmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/registry"
+)
+
+// key docker uses for docker hub credentials in config.json
+const DockerHubServer = "https://index.docker.io/v1/"
+
+// returned by credential helpers for unknown servers
+const credentialsNotFound = "credentials not found in native keychain"
+
+// The parts of ~/.docker/config.json needed for registry credentials. Everything else in the file is kept as is when
+// it is written back.
+type dockerConfig struct {
+	raw         map[string]json.RawMessage
+	auths       map[string]json.RawMessage
+	credsStore  string
+	credHelpers map[string]string
+}
+
+// an entry of `auths`, `auth` is base64 of `username:password`
+type configAuth struct {
+	Auth          string `json:"auth,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+}
+
+// what credential helpers read and write, see github.com/docker/docker-credential-helpers
+type helperCredentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// Location of the docker cli config, respects $DOCKER_CONFIG like the cli does
+func DockerConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// a missing config file is the same as an empty one
+func loadDockerConfig(path string) (*dockerConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		data = []byte("{}")
+	} else if err != nil {
+		return nil, err
+	}
+
+	return parseDockerConfig(data)
+}
+
+func parseDockerConfig(data []byte) (*dockerConfig, error) {
+	config := &dockerConfig{
+		raw:         make(map[string]json.RawMessage),
+		auths:       make(map[string]json.RawMessage),
+		credHelpers: make(map[string]string),
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return config, nil
+	}
+
+	if err := json.Unmarshal(data, &config.raw); err != nil {
+		return nil, fmt.Errorf("invalid docker config: %w", err)
+	}
+
+	fields := []struct {
+		name string
+		dst  any
+	}{
+		{"auths", &config.auths},
+		{"credsStore", &config.credsStore},
+		{"credHelpers", &config.credHelpers},
+	}
+
+	for _, field := range fields {
+		if value, ok := config.raw[field.name]; ok {
+			if err := json.Unmarshal(value, field.dst); err != nil {
+				return nil, fmt.Errorf("invalid %s in docker config: %w", field.name, err)
+			}
+		}
+	}
+
+	// `null` in the file
+	if config.auths == nil {
+		config.auths = make(map[string]json.RawMessage)
+	}
+	if config.credHelpers == nil {
+		config.credHelpers = make(map[string]string)
+	}
+
+	return config, nil
+}
+
+func (c *dockerConfig) marshal() ([]byte, error) {
+	auths, err := json.Marshal(c.auths)
+	if err != nil {
+		return nil, err
+	}
+	c.raw["auths"] = auths
+
+	return json.MarshalIndent(c.raw, "", "\t")
+}
+
+// the config holds credentials, it is replaced atomically and only readable by the user
+func (c *dockerConfig) save(path string) error {
+	data, err := c.marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "config.json.tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// key of server in `auths`, existing entries are matched by hostname (eg: `https://ghcr.io` and `ghcr.io`)
+func (c *dockerConfig) authKey(server string) string {
+	if _, ok := c.auths[server]; ok {
+		return server
+	}
+
+	hostname := registryHostname(server)
+	for key := range c.auths {
+		if registryHostname(key) == hostname {
+			return key
+		}
+	}
+
+	return server
+}
+
+// credential helper responsible for server, empty if credentials are stored in the config file
+func (c *dockerConfig) helperFor(server string) string {
+	if helper, ok := c.credHelpers[registryHostname(server)]; ok {
+		return helper
+	}
+	return c.credsStore
+}
+
+func (c *dockerConfig) credentials(server string) (registry.AuthConfig, error) {
+	authConfig := registry.AuthConfig{ServerAddress: server}
+
+	if helper := c.helperFor(server); helper != "" {
+		var creds helperCredentials
+		if err := runCredentialHelper(helper, "get", server, &creds); err != nil {
+			return authConfig, err
+		}
+
+		// helpers store identity tokens with this username
+		if creds.Username == "<token>" {
+			authConfig.IdentityToken = creds.Secret
+		} else {
+			authConfig.Username = creds.Username
+			authConfig.Password = creds.Secret
+		}
+		return authConfig, nil
+	}
+
+	raw, ok := c.auths[c.authKey(server)]
+	if !ok {
+		return authConfig, fmt.Errorf("not logged in to %s", server)
+	}
+
+	var entry configAuth
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return authConfig, err
+	}
+
+	authConfig.IdentityToken = entry.IdentityToken
+	if entry.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return authConfig, fmt.Errorf("invalid credentials for %s in docker config: %w", server, err)
+		}
+
+		user, password, found := strings.Cut(string(decoded), ":")
+		if !found {
+			return authConfig, fmt.Errorf("invalid credentials for %s in docker config", server)
+		}
+		authConfig.Username = user
+		authConfig.Password = password
+	}
+
+	return authConfig, nil
+}
+
+func (c *dockerConfig) storeCredentials(authConfig registry.AuthConfig) error {
+	server := authConfig.ServerAddress
+	key := c.authKey(server)
+
+	if helper := c.helperFor(server); helper != "" {
+		creds := helperCredentials{ServerURL: server, Username: authConfig.Username, Secret: authConfig.Password}
+		if authConfig.IdentityToken != "" {
+			creds.Username = "<token>"
+			creds.Secret = authConfig.IdentityToken
+		}
+
+		if err := runCredentialHelper(helper, "store", creds, nil); err != nil {
+			return err
+		}
+
+		// the cli keeps an empty entry so the server shows up as logged in
+		c.auths[key] = json.RawMessage("{}")
+		return nil
+	}
+
+	entry := configAuth{IdentityToken: authConfig.IdentityToken}
+	if entry.IdentityToken == "" {
+		entry.Auth = base64.StdEncoding.EncodeToString([]byte(authConfig.Username + ":" + authConfig.Password))
+	}
+
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	c.auths[key] = raw
+	return nil
+}
+
+func (c *dockerConfig) eraseCredentials(server string) error {
+	key := c.authKey(server)
+
+	if helper := c.helperFor(server); helper != "" {
+		err := runCredentialHelper(helper, "erase", server, nil)
+		if err != nil && !strings.Contains(err.Error(), credentialsNotFound) {
+			return err
+		}
+	} else if _, ok := c.auths[key]; !ok {
+		return fmt.Errorf("not logged in to %s", server)
+	}
+
+	delete(c.auths, key)
+	return nil
+}
+
+// Runs `docker-credential-<helper> <action>`. Strings are passed as is on stdin, anything else as json, the json
+// output is decoded into out if it is not nil
+func runCredentialHelper(helper string, action string, in any, out any) error {
+	var stdin []byte
+	if s, ok := in.(string); ok {
+		stdin = []byte(s)
+	} else {
+		var err error
+		if stdin, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	cmd := exec.Command("docker-credential-"+helper, action)
+	cmd.Stdin = bytes.NewReader(stdin)
+	output, err := cmd.Output()
+	if err != nil {
+		// helpers report errors on stdout
+		msg := strings.TrimSpace(string(output))
+		if exitErr, ok := err.(*exec.ExitError); ok && msg == "" {
+			msg = strings.TrimSpace(string(exitErr.Stderr))
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("credential helper %s: %s", helper, msg)
+	}
+
+	if out != nil {
+		return json.Unmarshal(output, out)
+	}
+	return nil
+}
+
+// strips the scheme and path, eg: https://index.docker.io/v1/ -> index.docker.io
+func registryHostname(server string) string {
+	hostname := server
+	if _, rest, found := strings.Cut(hostname, "://"); found {
+		hostname = rest
+	}
+	hostname, _, _ = strings.Cut(hostname, "/")
+	return hostname
+}
+
+// config key of the registry ref is pushed to / pulled from
+func RegistryServerOf(ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", err
+	}
+
+	if domain := reference.Domain(named); domain != "docker.io" {
+		return domain, nil
+	}
+	return DockerHubServer, nil
+}
+
+func normalizeServer(server string) string {
+	server = strings.TrimSpace(server)
+	switch server {
+	case "", "docker.io", "index.docker.io", "registry-1.docker.io":
+		return DockerHubServer
+	}
+	return server
+}
+
+// Checks the credentials with the registry and stores them in the docker config (or its credential helper).
+// Leaving server empty logs in to docker hub.
+func (dc *DockerClient) RegistryLogin(server string, username string, password string) error {
+	server = normalizeServer(server)
+	path := DockerConfigPath()
+
+	config, err := loadDockerConfig(path)
+	if err != nil {
+		return err
+	}
+
+	authConfig := registry.AuthConfig{ServerAddress: server, Username: username, Password: password}
+	res, err := dc.cli.RegistryLogin(context.Background(), authConfig)
+	if err != nil {
+		return fmt.Errorf("login to %s failed: %w", server, err)
+	}
+
+	// registries that hand out a token do not need the password to be stored
+	if res.IdentityToken != "" {
+		authConfig.Password = ""
+		authConfig.IdentityToken = res.IdentityToken
+	}
+
+	if err := config.storeCredentials(authConfig); err != nil {
+		return err
+	}
+	return config.save(path)
+}
+
+// removes the stored credentials of server, docker hub if empty
+func (dc *DockerClient) RegistryLogout(server string) error {
+	server = normalizeServer(server)
+	path := DockerConfigPath()
+
+	config, err := loadDockerConfig(path)
+	if err != nil {
+		return err
+	}
+
+	if err := config.eraseCredentials(server); err != nil {
+		return err
+	}
+	return config.save(path)
+}
+
+// servers with credentials in the docker config, sorted
+func (dc *DockerClient) LoggedInRegistries() ([]string, error) {
+	config, err := loadDockerConfig(DockerConfigPath())
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make([]string, 0, len(config.auths))
+	for server := range config.auths {
+		servers = append(servers, server)
+	}
+	slices.Sort(servers)
+	return servers, nil
+}
+
+// Encoded credentials for the registry of ref, as expected by pull, push and distribution inspect. Empty when there are
+// none, the registry is then accessed anonymously.
+func (dc *DockerClient) registryAuth(ref string) string {
+	server, err := RegistryServerOf(ref)
+	if err != nil {
+		return ""
+	}
+
+	config, err := loadDockerConfig(DockerConfigPath())
+	if err != nil {
+		return ""
+	}
+
+	authConfig, err := config.credentials(server)
+	if err != nil {
+		return ""
+	}
+
+	encoded, _ := registry.EncodeAuthConfig(authConfig)
+	return encoded
+}
@@ -0,0 +1,156 @@
+package dockercmd
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/registry"
+)
+
+func TestDockerConfigCredentials(t *testing.T) {
+	config, err := parseDockerConfig([]byte(`{
+		"auths": {
+			"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNzOndvcmQ="},
+			"ghcr.io": {"identitytoken": "token"}
+		},
+		"credHelpers": {"gcr.io": "gcloud"},
+		"detachKeys": "ctrl-x"
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := config.credentials(DockerHubServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the first colon separates the password
+	want := registry.AuthConfig{ServerAddress: DockerHubServer, Username: "user", Password: "pass:word"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got, err = config.credentials("https://ghcr.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.IdentityToken != "token" {
+		t.Errorf("identity token not found by hostname, got %+v", got)
+	}
+
+	if _, err := config.credentials("quay.io"); err == nil {
+		t.Errorf("expected an error for a registry without credentials")
+	}
+
+	if helper := config.helperFor("gcr.io"); helper != "gcloud" {
+		t.Errorf("got helper %q, want gcloud", helper)
+	}
+	if helper := config.helperFor("quay.io"); helper != "" {
+		t.Errorf("got helper %q for a registry without one", helper)
+	}
+}
+
+func TestDockerConfigStoreKeepsOtherSettings(t *testing.T) {
+	config, err := parseDockerConfig([]byte(`{"auths": {"ghcr.io": {"auth": "b2xkOm9sZA=="}}, "detachKeys": "ctrl-x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = config.storeCredentials(registry.AuthConfig{ServerAddress: "https://ghcr.io", Username: "new", Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.storeCredentials(registry.AuthConfig{ServerAddress: "quay.io", Username: "a", Password: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.eraseCredentials("quay.io"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := config.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var written map[string]any
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		// the existing entry is updated instead of adding `https://ghcr.io`
+		"auths":      map[string]any{"ghcr.io": map[string]any{"auth": "bmV3OnNlY3JldA=="}},
+		"detachKeys": "ctrl-x",
+	}
+	if !reflect.DeepEqual(written, want) {
+		t.Errorf("got %v, want %v", written, want)
+	}
+
+	if err := config.eraseCredentials("quay.io"); err == nil {
+		t.Errorf("expected an error when logging out of a registry twice")
+	}
+}
+
+func TestRegistryServerOf(t *testing.T) {
+	cases := map[string]string{
+		"nginx":                    DockerHubServer,
+		"user/app:1.0":             DockerHubServer,
+		"ghcr.io/user/app:latest":  "ghcr.io",
+		"localhost:5000/app":       "localhost:5000",
+		"registry.local/team/app:": "",
+	}
+
+	for ref, want := range cases {
+		got, err := RegistryServerOf(ref)
+		if want == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error", ref)
+			}
+			continue
+		}
+
+		if err != nil || got != want {
+			t.Errorf("%s: got %q (%v), want %q", ref, got, err, want)
+		}
+	}
+}
+
+func TestReadLayerProgress(t *testing.T) {
+	stream := `{"status":"The push refers to repository [ghcr.io/user/app]"}
+{"status":"Preparing","progressDetail":{},"id":"a1"}
+{"status":"Pushing","progressDetail":{"current":512,"total":1024},"id":"a1"}
+{"status":"Layer already exists","progressDetail":{},"id":"b2"}
+{"status":"latest: digest: sha256:abc size: 1234"}
+`
+
+	var got []LayerProgress
+	err := readLayerProgress(strings.NewReader(stream), func(update LayerProgress) {
+		got = append(got, update)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []LayerProgress{
+		{Status: "The push refers to repository [ghcr.io/user/app]"},
+		{ID: "a1", Status: "Preparing"},
+		{ID: "a1", Status: "Pushing", Current: 512, Total: 1024},
+		{ID: "b2", Status: "Layer already exists"},
+		{Status: "latest: digest: sha256:abc size: 1234"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	err = readLayerProgress(strings.NewReader(`{"errorDetail":{"message":"denied: requested access to the resource is denied"},"error":"denied: requested access to the resource is denied"}`), func(LayerProgress) {})
+	if err = asRegistryAuthError("ghcr.io", err); !IsRegistryAuthError(err) {
+		t.Errorf("expected an auth error, got %v", err)
+	}
+
+	if err := asRegistryAuthError("ghcr.io", errors.New("connection refused")); IsRegistryAuthError(err) {
+		t.Errorf("unrelated error reported as auth error: %v", err)
+	}
+}
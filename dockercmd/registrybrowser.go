@@ -0,0 +1,603 @@
+package dockercmd
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/registry"
+)
+
+const (
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// page size requested from the catalog and tag list endpoints
+const registryPageSize = 100
+
+// Returned when the registry refuses to delete, registry:2 only allows it with REGISTRY_STORAGE_DELETE_ENABLED=true
+var ErrRegistryDeleteDisabled = errors.New("the registry does not allow deleting, registry:2 needs REGISTRY_STORAGE_DELETE_ENABLED=true")
+
+// Returned when deleting a single tag is not supported, only the manifest (with every tag pointing to it) can be deleted
+var ErrRegistryTagDeleteUnsupported = errors.New("the registry can not delete single tags (or deleting is disabled), delete the manifest instead, this removes every tag pointing to it")
+
+// Talks to the HTTP API of a v2 compatible registry (https://distribution.github.io/distribution/spec/api/), using
+// the credentials stored in the docker config
+type RegistryClient struct {
+	// host[:port], as used in image references
+	Server  string
+	baseURL string
+	http    *http.Client
+	auth    registry.AuthConfig
+	// the registry asked for basic auth instead of tokens
+	basicAuth bool
+	// bearer tokens by scope
+	tokens map[string]string
+	mu     sync.Mutex
+}
+
+type ManifestDetails struct {
+	Repository string
+	Reference  string
+	Digest     string
+	MediaType  string
+	// config and layers, for indexes the sum of every platform
+	Size int64
+	// one entry for single platform images as well
+	Platforms []PlatformManifest
+}
+
+type PlatformManifest struct {
+	// os/arch[/variant]
+	Platform string
+	Digest   string
+	Size     int64
+	Layers   int
+}
+
+type registryDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+	Platform  *struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant"`
+	} `json:"platform,omitempty"`
+}
+
+// fields of both manifests and indexes (lists)
+type registryManifest struct {
+	MediaType string               `json:"mediaType"`
+	Config    registryDescriptor   `json:"config"`
+	Layers    []registryDescriptor `json:"layers"`
+	Manifests []registryDescriptor `json:"manifests"`
+}
+
+type registryErrors struct {
+	Errors []struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// Connects to server (host[:port], optionally with a scheme). Without a scheme https is tried first, registries on
+// this machine that only speak plain http (eg: a local registry:2 container) are used over http. Credentials would be
+// sent in clear text, so remote registries are only used over http if the server is given as http://...
+func NewRegistryClient(server string) (*RegistryClient, error) {
+	server = strings.TrimSuffix(strings.TrimSpace(server), "/")
+	if server == "" {
+		return nil, errors.New("no registry given")
+	}
+
+	c := &RegistryClient{
+		Server: registryHostname(server),
+		http:   &http.Client{Timeout: 30 * time.Second},
+		tokens: make(map[string]string),
+	}
+
+	if config, err := loadDockerConfig(DockerConfigPath()); err == nil {
+		// not being logged in is fine, the registry might allow anonymous access
+		c.auth, _ = config.credentials(c.Server)
+	}
+
+	schemes := []string{"https"}
+	if isLoopbackRegistry(c.Server) {
+		schemes = append(schemes, "http")
+	}
+	if scheme, _, found := strings.Cut(server, "://"); found {
+		schemes = []string{scheme}
+	}
+
+	var err error
+	for _, scheme := range schemes {
+		c.baseURL = scheme + "://" + c.Server
+		if err = c.ping(); err == nil {
+			return c, nil
+		}
+	}
+
+	return nil, err
+}
+
+// host[:port] is localhost or a loopback address
+func isLoopbackRegistry(server string) bool {
+	host := server
+	if h, _, err := net.SplitHostPort(server); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// checks that the registry speaks the v2 api, auth is handled by the first real request
+func (c *RegistryClient) ping() error {
+	res, err := c.http.Get(c.baseURL + "/v2/")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("%s is not a v2 registry (%s)", c.Server, res.Status)
+	}
+	return nil
+}
+
+// Lists every repository, registries without the catalog endpoint (eg: docker hub) return an error
+func (c *RegistryClient) Catalog() ([]string, error) {
+	var repos []string
+	path := fmt.Sprintf("/v2/_catalog?n=%d", registryPageSize)
+
+	for path != "" {
+		var page struct {
+			Repositories []string `json:"repositories"`
+		}
+
+		next, err := c.getJSON(path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		repos = append(repos, page.Repositories...)
+		path = next
+	}
+
+	return repos, nil
+}
+
+func (c *RegistryClient) Tags(repo string) ([]string, error) {
+	var tags []string
+	path := fmt.Sprintf("/v2/%s/tags/list?n=%d", repo, registryPageSize)
+
+	for path != "" {
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+
+		next, err := c.getJSON(path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, page.Tags...)
+		path = next
+	}
+
+	return tags, nil
+}
+
+// Fetches the manifest of a tag or digest. For indexes the manifest of every platform is fetched as well.
+func (c *RegistryClient) Manifest(repo string, reference string) (*ManifestDetails, error) {
+	manifest, digest, err := c.manifest(repo, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &ManifestDetails{Repository: repo, Reference: reference, Digest: digest, MediaType: manifest.MediaType}
+
+	if len(manifest.Manifests) == 0 {
+		platform := PlatformManifest{Digest: digest, Size: manifestSize(manifest), Layers: len(manifest.Layers)}
+		platform.Platform, err = c.configPlatform(repo, manifest.Config.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		details.Size = platform.Size
+		details.Platforms = []PlatformManifest{platform}
+		return details, nil
+	}
+
+	for _, entry := range manifest.Manifests {
+		platform := PlatformManifest{Digest: entry.Digest, Platform: "unknown"}
+		if entry.Platform != nil {
+			platform.Platform = joinPlatform(entry.Platform.OS, entry.Platform.Architecture, entry.Platform.Variant)
+		}
+
+		// buildkit attaches attestations (sboms, provenance) as unknown/unknown entries
+		if platform.Platform == "unknown/unknown" {
+			continue
+		}
+
+		child, _, err := c.manifest(repo, entry.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		platform.Size = manifestSize(child)
+		platform.Layers = len(child.Layers)
+		details.Size += platform.Size
+		details.Platforms = append(details.Platforms, platform)
+	}
+
+	return details, nil
+}
+
+// Deletes a tag without touching the manifest it points to, not every registry supports this
+func (c *RegistryClient) DeleteTag(repo string, tag string) error {
+	err := c.delete(fmt.Sprintf("/v2/%s/manifests/%s", repo, tag))
+	if errors.Is(err, ErrRegistryDeleteDisabled) || isUnsupported(err) {
+		// registry:2 answers tag deletes with UNSUPPORTED whether deleting is enabled or not
+		return ErrRegistryTagDeleteUnsupported
+	}
+	return err
+}
+
+// Deletes a manifest by digest, every tag pointing to it is gone as well
+func (c *RegistryClient) DeleteManifest(repo string, digest string) error {
+	return c.delete(fmt.Sprintf("/v2/%s/manifests/%s", repo, digest))
+}
+
+// reference to pull repo:tag from this registry
+func (c *RegistryClient) ImageRef(repo string, tag string) string {
+	return c.Server + "/" + repo + ":" + tag
+}
+
+// helpers
+
+type registryUnsupportedError struct {
+	err error
+}
+
+func (e registryUnsupportedError) Error() string { return e.err.Error() }
+
+func isUnsupported(err error) bool {
+	var unsupported registryUnsupportedError
+	return errors.As(err, &unsupported)
+}
+
+func (c *RegistryClient) manifest(repo string, reference string) (*registryManifest, string, error) {
+	accept := strings.Join([]string{mediaTypeOCIIndex, mediaTypeDockerManifestList, mediaTypeOCIManifest, mediaTypeDockerManifest}, ", ")
+
+	res, err := c.do(http.MethodGet, fmt.Sprintf("/v2/%s/manifests/%s", repo, reference), accept)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var manifest registryManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, "", fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType = res.Header.Get("Content-Type")
+	}
+
+	digest := res.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	}
+
+	return &manifest, digest, nil
+}
+
+func (c *RegistryClient) configPlatform(repo string, digest string) (string, error) {
+	var config struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant"`
+	}
+
+	if _, err := c.getJSON(fmt.Sprintf("/v2/%s/blobs/%s", repo, digest), &config); err != nil {
+		return "", err
+	}
+
+	return joinPlatform(config.OS, config.Architecture, config.Variant), nil
+}
+
+func manifestSize(manifest *registryManifest) int64 {
+	size := manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		size += layer.Size
+	}
+	return size
+}
+
+func joinPlatform(os string, arch string, variant string) string {
+	if os == "" && arch == "" {
+		return "unknown"
+	}
+
+	platform := os + "/" + arch
+	if variant != "" {
+		platform += "/" + variant
+	}
+	return platform
+}
+
+func (c *RegistryClient) delete(path string) error {
+	res, err := c.do(http.MethodDelete, path, "")
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// decodes the response into v, returns the path of the next page if the registry paginates
+func (c *RegistryClient) getJSON(path string, v any) (string, error) {
+	res, err := c.do(http.MethodGet, path, "")
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return "", err
+	}
+
+	return nextPage(res.Header.Get("Link")), nil
+}
+
+// `</v2/_catalog?last=b&n=100>; rel="next"` -> /v2/_catalog?last=b&n=100
+func nextPage(link string) string {
+	target, params, found := strings.Cut(link, ";")
+	if !found || !strings.Contains(params, `rel="next"`) {
+		return ""
+	}
+
+	target = strings.Trim(strings.TrimSpace(target), "<>")
+	if u, err := url.Parse(target); err == nil && u.IsAbs() {
+		return u.RequestURI()
+	}
+	return target
+}
+
+// sends the request, answering an auth challenge once. Responses other than 2xx are returned as errors.
+func (c *RegistryClient) do(method string, path string, accept string) (*http.Response, error) {
+	scope := registryScope(method, path)
+
+	res, err := c.send(method, path, accept, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == http.StatusUnauthorized {
+		challenge := res.Header.Get("WWW-Authenticate")
+		res.Body.Close()
+
+		if err := c.authorize(challenge, scope); err != nil {
+			return nil, err
+		}
+
+		if res, err = c.send(method, path, accept, scope); err != nil {
+			return nil, err
+		}
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+
+	defer res.Body.Close()
+	return nil, c.responseError(res)
+}
+
+func (c *RegistryClient) send(method string, path string, accept string, scope string) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	c.mu.Lock()
+	token := c.tokens[scope]
+	basicAuth := c.basicAuth
+	c.mu.Unlock()
+
+	switch {
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case basicAuth && c.auth.Username != "":
+		req.SetBasicAuth(c.auth.Username, c.auth.Password)
+	}
+
+	return c.http.Do(req)
+}
+
+// answers a `WWW-Authenticate` challenge, either with basic auth or by fetching a token for scope
+func (c *RegistryClient) authorize(challenge string, scope string) error {
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.auth.Username == "" {
+			return &RegistryAuthError{Server: c.Server, Err: errors.New("authentication required")}
+		}
+		c.mu.Lock()
+		c.basicAuth = true
+		c.mu.Unlock()
+		return nil
+
+	case "bearer":
+		// the token is kept under the scope of the request, even if the registry asked for a different one
+		tokenScope := scope
+		if params["scope"] != "" {
+			tokenScope = params["scope"]
+		}
+
+		token, err := c.fetchToken(params["realm"], params["service"], tokenScope)
+		if err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		c.tokens[scope] = token
+		c.mu.Unlock()
+		return nil
+	}
+
+	return &RegistryAuthError{Server: c.Server, Err: fmt.Errorf("unsupported auth challenge %q", challenge)}
+}
+
+// token endpoints of the docker token spec, identity tokens are exchanged with the oauth2 endpoint
+func (c *RegistryClient) fetchToken(realm string, service string, scope string) (string, error) {
+	if realm == "" {
+		return "", &RegistryAuthError{Server: c.Server, Err: errors.New("auth challenge without realm")}
+	}
+
+	var req *http.Request
+	var err error
+
+	if c.auth.IdentityToken != "" {
+		form := url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {c.auth.IdentityToken},
+			"service":       {service},
+			"scope":         {scope},
+			"client_id":     {"gomanagedocker"},
+		}
+		req, err = http.NewRequest(http.MethodPost, realm, strings.NewReader(form.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		query := url.Values{"service": {service}, "scope": {scope}}
+		req, err = http.NewRequest(http.MethodGet, realm+"?"+query.Encode(), nil)
+		if err == nil && c.auth.Username != "" {
+			req.SetBasicAuth(c.auth.Username, c.auth.Password)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", &RegistryAuthError{Server: c.Server, Err: fmt.Errorf("token request failed: %s", res.Status)}
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+func (c *RegistryClient) responseError(res *http.Response) error {
+	var body registryErrors
+	json.NewDecoder(res.Body).Decode(&body)
+
+	msg := res.Status
+	if len(body.Errors) > 0 {
+		msg = body.Errors[0].Code + ": " + body.Errors[0].Message
+	}
+	err := errors.New(msg)
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return &RegistryAuthError{Server: c.Server, Err: err}
+	case res.Request.Method == http.MethodDelete && res.StatusCode == http.StatusMethodNotAllowed:
+		return ErrRegistryDeleteDisabled
+	case len(body.Errors) > 0 && body.Errors[0].Code == "UNSUPPORTED":
+		return registryUnsupportedError{err: err}
+	}
+
+	return err
+}
+
+// `repository:<name>:pull` for repository endpoints, `registry:catalog:*` for the catalog
+func registryScope(method string, path string) string {
+	path, _, _ = strings.Cut(strings.TrimPrefix(path, "/v2/"), "?")
+	if path == "_catalog" {
+		return "registry:catalog:*"
+	}
+
+	for _, endpoint := range []string{"/manifests/", "/blobs/", "/tags/"} {
+		if repo, _, found := strings.Cut(path, endpoint); found {
+			actions := "pull"
+			if method == http.MethodDelete {
+				actions = "delete"
+			}
+			return "repository:" + repo + ":" + actions
+		}
+	}
+
+	return ""
+}
+
+// `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"` -> Bearer, {realm: ..., service: ...}
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := make(map[string]string)
+
+	for rest != "" {
+		var name, value string
+		name, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if name == "" {
+			break
+		}
+
+		if strings.HasPrefix(rest, `"`) {
+			// quoted values may contain commas, eg: scope="repository:a:pull,push"
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+
+		params[strings.ToLower(strings.TrimSpace(name))] = value
+	}
+
+	return scheme, params
+}
@@ -0,0 +1,243 @@
+package dockercmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// a registry:2 lookalike with token auth, deleting manifests is enabled but tags can not be deleted
+func newTestRegistry(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	var server *httptest.Server
+
+	authorized := func(w http.ResponseWriter, r *http.Request, scope string) bool {
+		if r.Header.Get("Authorization") == "Bearer token-"+scope {
+			return true
+		}
+
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="%s"`, server.URL, scope))
+		w.WriteHeader(http.StatusUnauthorized)
+		return false
+	}
+
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v2/")
+
+		switch {
+		case path == "":
+			w.WriteHeader(http.StatusUnauthorized)
+
+		case path == "_catalog":
+			if !authorized(w, r, "registry:catalog:*") {
+				return
+			}
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/_catalog?last=app&n=100>; rel="next"`)
+				json.NewEncoder(w).Encode(map[string]any{"repositories": []string{"app"}})
+			} else {
+				json.NewEncoder(w).Encode(map[string]any{"repositories": []string{"team/web"}})
+			}
+
+		case path == "app/tags/list":
+			if authorized(w, r, "repository:app:pull") {
+				json.NewEncoder(w).Encode(map[string]any{"name": "app", "tags": []string{"1.0", "latest"}})
+			}
+
+		case path == "app/manifests/latest":
+			if !authorized(w, r, "repository:app:pull") {
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", "sha256:index")
+			w.Write([]byte(`{"mediaType": "application/vnd.oci.image.index.v1+json", "manifests": [
+				{"digest": "sha256:amd64", "platform": {"os": "linux", "architecture": "amd64"}},
+				{"digest": "sha256:arm64", "platform": {"os": "linux", "architecture": "arm64", "variant": "v8"}},
+				{"digest": "sha256:attestation", "platform": {"os": "unknown", "architecture": "unknown"}}
+			]}`))
+
+		case path == "app/manifests/sha256:amd64" || path == "app/manifests/sha256:arm64" || path == "app/manifests/1.0":
+			if !authorized(w, r, "repository:app:"+map[bool]string{true: "delete", false: "pull"}[r.Method == http.MethodDelete]) {
+				return
+			}
+
+			if r.Method == http.MethodDelete {
+				if strings.HasSuffix(path, "1.0") {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					w.Write([]byte(`{"errors": [{"code": "UNSUPPORTED", "message": "The operation is unsupported."}]}`))
+					return
+				}
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+
+			w.Write([]byte(`{"mediaType": "application/vnd.oci.image.manifest.v1+json",
+				"config": {"digest": "sha256:config", "size": 100},
+				"layers": [{"size": 1000}, {"size": 500}]}`))
+
+		case path == "app/blobs/sha256:config":
+			if authorized(w, r, "repository:app:pull") {
+				w.Write([]byte(`{"os": "linux", "architecture": "amd64"}`))
+			}
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": [{"code": "MANIFEST_UNKNOWN", "message": "manifest unknown"}]}`))
+		}
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": "token-" + r.URL.Query().Get("scope")})
+	})
+
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestRegistryClient(t *testing.T, server *httptest.Server, password string) *RegistryClient {
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	dir := t.TempDir()
+	t.Setenv("DOCKER_CONFIG", dir)
+	config := fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, host, base64.StdEncoding.EncodeToString([]byte("user:"+password)))
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// no scheme, https has to fall back to http
+	client, err := NewRegistryClient(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestRegistryClient(t *testing.T) {
+	server := newTestRegistry(t)
+	client := newTestRegistryClient(t, server, "secret")
+
+	repos, err := client.Catalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"app", "team/web"}; !reflect.DeepEqual(repos, want) {
+		t.Errorf("got repositories %v, want %v", repos, want)
+	}
+
+	tags, err := client.Tags("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1.0", "latest"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("got tags %v, want %v", tags, want)
+	}
+
+	details, err := client.Manifest("app", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ManifestDetails{
+		Repository: "app",
+		Reference:  "latest",
+		Digest:     "sha256:index",
+		MediaType:  "application/vnd.oci.image.index.v1+json",
+		Size:       3200,
+		Platforms: []PlatformManifest{
+			{Platform: "linux/amd64", Digest: "sha256:amd64", Size: 1600, Layers: 2},
+			{Platform: "linux/arm64/v8", Digest: "sha256:arm64", Size: 1600, Layers: 2},
+		},
+	}
+	if !reflect.DeepEqual(details, want) {
+		t.Errorf("got %+v, want %+v", details, want)
+	}
+
+	details, err = client.Manifest("app", "1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(details.Platforms) != 1 || details.Platforms[0].Platform != "linux/amd64" || details.Size != 1600 {
+		t.Errorf("unexpected single platform details %+v", details)
+	}
+
+	if _, err := client.Manifest("app", "missing"); err == nil || !strings.Contains(err.Error(), "MANIFEST_UNKNOWN") {
+		t.Errorf("expected the registry error, got %v", err)
+	}
+
+	if err := client.DeleteTag("app", "1.0"); !errors.Is(err, ErrRegistryTagDeleteUnsupported) {
+		t.Errorf("expected tag deletes to be unsupported, got %v", err)
+	}
+	if err := client.DeleteManifest("app", "sha256:amd64"); err != nil {
+		t.Errorf("could not delete manifest: %v", err)
+	}
+
+	if ref := client.ImageRef("app", "1.0"); ref != client.Server+"/app:1.0" {
+		t.Errorf("unexpected image ref %s", ref)
+	}
+}
+
+func TestRegistryClientWrongPassword(t *testing.T) {
+	server := newTestRegistry(t)
+	client := newTestRegistryClient(t, server, "wrong")
+
+	if _, err := client.Catalog(); !IsRegistryAuthError(err) {
+		t.Errorf("expected an auth error, got %v", err)
+	}
+}
+
+func TestIsLoopbackRegistry(t *testing.T) {
+	cases := map[string]bool{
+		"localhost":          true,
+		"localhost:5000":     true,
+		"127.0.0.1:5000":     true,
+		"[::1]:5000":         true,
+		"::1":                true,
+		"registry.local":     false,
+		"192.168.1.10:5000":  false,
+		"localhost.evil.com": false,
+	}
+
+	for server, want := range cases {
+		if got := isLoopbackRegistry(server); got != want {
+			t.Errorf("isLoopbackRegistry(%q) = %v, want %v", server, got, want)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry",scope="repository:a/b:pull,push"`)
+
+	if scheme != "Bearer" {
+		t.Errorf("got scheme %q", scheme)
+	}
+
+	want := map[string]string{"realm": "https://auth.example.com/token", "service": "registry", "scope": "repository:a/b:pull,push"}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("got %v, want %v", params, want)
+	}
+}
+
+func TestNextPage(t *testing.T) {
+	cases := map[string]string{
+		`</v2/_catalog?last=b&n=100>; rel="next"`:                   "/v2/_catalog?last=b&n=100",
+		`<https://r.example.com/v2/a/tags/list?last=1>; rel="next"`: "/v2/a/tags/list?last=1",
+		"": "",
+	}
+
+	for link, want := range cases {
+		if got := nextPage(link); got != want {
+			t.Errorf("%q: got %q, want %q", link, got, want)
+		}
+	}
+}
@@ -0,0 +1,511 @@
+package dockercmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+)
+
+// The settings of a container that differ from its image's defaults, enough to recreate it by hand.
+// Built from ContainerInspect by ContainerRunSpec and rendered with RunCommand or ComposeService.
+type RunSpec struct {
+	Name  string
+	Image string
+	// empty but not nil when the image's entrypoint was cleared with `--entrypoint ""`
+	Entrypoint []string
+	Cmd        []string
+	User       string
+	WorkingDir string
+	Hostname   string
+	Env        []string
+	Labels     map[string]string
+	// `[hostIP:]hostPort:containerPort/proto`
+	Ports []string
+	// `source:target[:ro]`, source is the volume name for named volumes
+	Volumes []string
+	Tmpfs   []string
+	// the first network is the one the container is created with, the rest are connected afterwards
+	Networks      []string
+	NetworkMode   string
+	RestartPolicy string
+	Privileged    bool
+	ReadOnly      bool
+	Init          bool
+	CapAdd        []string
+	CapDrop       []string
+	// resource limits, zero means unlimited
+	Memory            int64
+	MemoryReservation int64
+	NanoCPUs          int64
+	CPUShares         int64
+	PidsLimit         int64
+}
+
+func (dc *DockerClient) ContainerRunSpec(id string) (*RunSpec, error) {
+	info, err := dc.cli.ContainerInspect(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := dc.cli.ImageInspectWithRaw(context.Background(), info.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := MakeRunSpec(info, img)
+	return &spec, nil
+}
+
+// networks every container is attached to unless told otherwise, they never need to be spelled out
+var defaultNetworks = []string{"default", "bridge"}
+
+func MakeRunSpec(info types.ContainerJSON, img types.ImageInspect) RunSpec {
+	spec := RunSpec{
+		Name:   strings.TrimPrefix(info.Name, "/"),
+		Image:  info.Config.Image,
+		Labels: make(map[string]string),
+	}
+
+	imgConfig := img.Config
+	// images imported from a tarball have no config at all
+	if imgConfig == nil {
+		imgConfig = &container.Config{}
+	}
+
+	if !slices.Equal(info.Config.Entrypoint, imgConfig.Entrypoint) {
+		spec.Entrypoint = info.Config.Entrypoint
+		if spec.Entrypoint == nil {
+			spec.Entrypoint = []string{}
+		}
+	}
+	// overriding the entrypoint drops the image's CMD, so the command has to be repeated then
+	if spec.Entrypoint != nil || !slices.Equal(info.Config.Cmd, imgConfig.Cmd) {
+		spec.Cmd = info.Config.Cmd
+	}
+	if info.Config.User != imgConfig.User {
+		spec.User = info.Config.User
+	}
+	if info.Config.WorkingDir != imgConfig.WorkingDir {
+		spec.WorkingDir = info.Config.WorkingDir
+	}
+	// the engine defaults the hostname to the short container id
+	if info.Config.Hostname != "" && !strings.HasPrefix(info.ID, info.Config.Hostname) {
+		spec.Hostname = info.Config.Hostname
+	}
+
+	for _, env := range info.Config.Env {
+		if !slices.Contains(imgConfig.Env, env) {
+			spec.Env = append(spec.Env, env)
+		}
+	}
+
+	for k, v := range info.Config.Labels {
+		// compose labels are bookkeeping of compose itself
+		if strings.HasPrefix(k, "com.docker.compose.") {
+			continue
+		}
+		if imgValue, ok := imgConfig.Labels[k]; ok && imgValue == v {
+			continue
+		}
+		spec.Labels[k] = v
+	}
+
+	spec.Ports = formatPortBindings(info.HostConfig.PortBindings)
+
+	for _, m := range info.Mounts {
+		var source string
+		switch m.Type {
+		case mount.TypeVolume:
+			// anonymous volumes come from VOLUME instructions in the image and are recreated automatically
+			if _, fromImage := imgConfig.Volumes[m.Destination]; fromImage && isAnonymousVolume(m.Name) {
+				continue
+			}
+			source = m.Name
+			if isAnonymousVolume(m.Name) {
+				source = ""
+			}
+		case mount.TypeBind:
+			source = m.Source
+		default:
+			continue
+		}
+
+		volume := m.Destination
+		if source != "" {
+			volume = source + ":" + m.Destination
+		}
+		if !m.RW {
+			volume += ":ro"
+		}
+		spec.Volumes = append(spec.Volumes, volume)
+	}
+	slices.Sort(spec.Volumes)
+
+	for target, opts := range info.HostConfig.Tmpfs {
+		if opts != "" {
+			target += ":" + opts
+		}
+		spec.Tmpfs = append(spec.Tmpfs, target)
+	}
+	slices.Sort(spec.Tmpfs)
+
+	networkMode := string(info.HostConfig.NetworkMode)
+	if strings.HasPrefix(networkMode, "container:") || networkMode == "host" || networkMode == "none" {
+		spec.NetworkMode = networkMode
+	} else if info.NetworkSettings != nil {
+		for name := range info.NetworkSettings.Networks {
+			if !slices.Contains(defaultNetworks, name) {
+				spec.Networks = append(spec.Networks, name)
+			}
+		}
+		slices.Sort(spec.Networks)
+
+		// the network the container was created with goes first
+		if i := slices.Index(spec.Networks, networkMode); i > 0 {
+			spec.Networks = append([]string{networkMode}, slices.Delete(spec.Networks, i, i+1)...)
+		}
+	}
+
+	if policy := info.HostConfig.RestartPolicy; policy.Name != "" && policy.Name != "no" {
+		spec.RestartPolicy = string(policy.Name)
+		if policy.MaximumRetryCount > 0 {
+			spec.RestartPolicy += ":" + strconv.Itoa(policy.MaximumRetryCount)
+		}
+	}
+
+	spec.Privileged = info.HostConfig.Privileged
+	spec.ReadOnly = info.HostConfig.ReadonlyRootfs
+	spec.Init = info.HostConfig.Init != nil && *info.HostConfig.Init
+	spec.CapAdd = info.HostConfig.CapAdd
+	spec.CapDrop = info.HostConfig.CapDrop
+
+	resources := info.HostConfig.Resources
+	spec.Memory = resources.Memory
+	spec.MemoryReservation = resources.MemoryReservation
+	spec.NanoCPUs = resources.NanoCPUs
+	spec.CPUShares = resources.CPUShares
+	if resources.PidsLimit != nil && *resources.PidsLimit > 0 {
+		spec.PidsLimit = *resources.PidsLimit
+	}
+
+	return spec
+}
+
+// Renders the spec as a `docker run` invocation, extra networks are connected with `docker network connect`
+func (spec RunSpec) RunCommand() string {
+	args := [][]string{{"docker", "run", "--detach"}}
+	add := func(flag string, values ...string) {
+		for _, v := range values {
+			args = append(args, []string{flag, shellQuote(v)})
+		}
+	}
+
+	if spec.Name != "" {
+		add("--name", spec.Name)
+	}
+	if spec.Hostname != "" {
+		add("--hostname", spec.Hostname)
+	}
+	if spec.User != "" {
+		add("--user", spec.User)
+	}
+	if spec.WorkingDir != "" {
+		add("--workdir", spec.WorkingDir)
+	}
+	add("--env", spec.Env...)
+	for _, k := range sortedKeys(spec.Labels) {
+		add("--label", k+"="+spec.Labels[k])
+	}
+	add("--publish", spec.Ports...)
+	add("--volume", spec.Volumes...)
+	add("--tmpfs", spec.Tmpfs...)
+
+	if spec.NetworkMode != "" {
+		add("--network", spec.NetworkMode)
+	} else if len(spec.Networks) > 0 {
+		add("--network", spec.Networks[0])
+	}
+
+	if spec.RestartPolicy != "" {
+		add("--restart", spec.RestartPolicy)
+	}
+	if spec.Privileged {
+		args = append(args, []string{"--privileged"})
+	}
+	if spec.ReadOnly {
+		args = append(args, []string{"--read-only"})
+	}
+	if spec.Init {
+		args = append(args, []string{"--init"})
+	}
+	add("--cap-add", spec.CapAdd...)
+	add("--cap-drop", spec.CapDrop...)
+
+	if spec.Memory > 0 {
+		add("--memory", strconv.FormatInt(spec.Memory, 10))
+	}
+	if spec.MemoryReservation > 0 {
+		add("--memory-reservation", strconv.FormatInt(spec.MemoryReservation, 10))
+	}
+	if spec.NanoCPUs > 0 {
+		add("--cpus", formatCPUs(spec.NanoCPUs))
+	}
+	if spec.CPUShares > 0 {
+		add("--cpu-shares", strconv.FormatInt(spec.CPUShares, 10))
+	}
+	if spec.PidsLimit > 0 {
+		add("--pids-limit", strconv.FormatInt(spec.PidsLimit, 10))
+	}
+
+	// only the first element of the entrypoint fits in the flag, the rest is passed before the command
+	var trailing []string
+	if len(spec.Entrypoint) > 0 {
+		add("--entrypoint", spec.Entrypoint[0])
+		trailing = append(trailing, spec.Entrypoint[1:]...)
+	} else if spec.Entrypoint != nil {
+		add("--entrypoint", "")
+	}
+
+	last := []string{shellQuote(spec.Image)}
+	for _, arg := range append(trailing, spec.Cmd...) {
+		last = append(last, shellQuote(arg))
+	}
+	args = append(args, last)
+
+	lines := make([]string, len(args))
+	for i, arg := range args {
+		lines[i] = strings.Join(arg, " ")
+	}
+
+	var res strings.Builder
+	res.WriteString(strings.Join(lines, " \\\n  "))
+	res.WriteString("\n")
+
+	if spec.NetworkMode == "" && len(spec.Networks) > 1 {
+		for _, network := range spec.Networks[1:] {
+			fmt.Fprintf(&res, "docker network connect %s %s\n", shellQuote(network), shellQuote(spec.Name))
+		}
+	}
+
+	return res.String()
+}
+
+// Renders the spec as a compose file with a single service. Named volumes and networks are declared as external,
+// since they already exist.
+func (spec RunSpec) ComposeService() string {
+	var res strings.Builder
+	w := func(indent int, format string, a ...any) {
+		res.WriteString(strings.Repeat("  ", indent))
+		fmt.Fprintf(&res, format, a...)
+		res.WriteString("\n")
+	}
+	list := func(indent int, key string, values []string, quote func(string) string) {
+		if len(values) == 0 {
+			return
+		}
+		w(indent, "%s:", key)
+		for _, v := range values {
+			w(indent+1, "- %s", quote(v))
+		}
+	}
+
+	service := spec.Name
+	if service == "" {
+		service = "app"
+	}
+
+	w(0, "services:")
+	w(1, "%s:", yamlKey(service))
+	w(2, "image: %s", yamlQuote(spec.Image))
+	if spec.Name != "" {
+		w(2, "container_name: %s", yamlQuote(spec.Name))
+	}
+	if spec.Hostname != "" {
+		w(2, "hostname: %s", yamlQuote(spec.Hostname))
+	}
+	if spec.Entrypoint != nil && len(spec.Entrypoint) == 0 {
+		w(2, "entrypoint: []")
+	}
+	list(2, "entrypoint", spec.Entrypoint, yamlQuote)
+	list(2, "command", spec.Cmd, yamlQuote)
+	if spec.User != "" {
+		w(2, "user: %s", yamlQuote(spec.User))
+	}
+	if spec.WorkingDir != "" {
+		w(2, "working_dir: %s", yamlQuote(spec.WorkingDir))
+	}
+	list(2, "environment", spec.Env, yamlQuote)
+
+	if len(spec.Labels) > 0 {
+		w(2, "labels:")
+		for _, k := range sortedKeys(spec.Labels) {
+			w(3, "%s: %s", yamlKey(k), yamlQuote(spec.Labels[k]))
+		}
+	}
+
+	// yaml 1.1 reads `xx:yy` as a base 60 number
+	list(2, "ports", spec.Ports, strconv.Quote)
+	list(2, "volumes", spec.Volumes, yamlQuote)
+	list(2, "tmpfs", spec.Tmpfs, yamlQuote)
+
+	if spec.NetworkMode != "" {
+		w(2, "network_mode: %s", yamlQuote(spec.NetworkMode))
+	} else {
+		list(2, "networks", spec.Networks, yamlQuote)
+	}
+
+	if spec.RestartPolicy != "" {
+		w(2, "restart: %s", yamlQuote(spec.RestartPolicy))
+	}
+	if spec.Privileged {
+		w(2, "privileged: true")
+	}
+	if spec.ReadOnly {
+		w(2, "read_only: true")
+	}
+	if spec.Init {
+		w(2, "init: true")
+	}
+	list(2, "cap_add", spec.CapAdd, yamlQuote)
+	list(2, "cap_drop", spec.CapDrop, yamlQuote)
+
+	if spec.Memory > 0 {
+		w(2, "mem_limit: %d", spec.Memory)
+	}
+	if spec.MemoryReservation > 0 {
+		w(2, "mem_reservation: %d", spec.MemoryReservation)
+	}
+	if spec.NanoCPUs > 0 {
+		w(2, "cpus: %s", formatCPUs(spec.NanoCPUs))
+	}
+	if spec.CPUShares > 0 {
+		w(2, "cpu_shares: %d", spec.CPUShares)
+	}
+	if spec.PidsLimit > 0 {
+		w(2, "pids_limit: %d", spec.PidsLimit)
+	}
+
+	var namedVolumes []string
+	for _, v := range spec.Volumes {
+		source, _, found := strings.Cut(v, ":")
+		// bind mounts start with a path
+		if found && !strings.HasPrefix(source, "/") && !strings.HasPrefix(source, ".") && !slices.Contains(namedVolumes, source) {
+			namedVolumes = append(namedVolumes, source)
+		}
+	}
+
+	if len(namedVolumes) > 0 {
+		w(0, "volumes:")
+		for _, v := range namedVolumes {
+			w(1, "%s:", yamlKey(v))
+			w(2, "external: true")
+		}
+	}
+
+	if spec.NetworkMode == "" && len(spec.Networks) > 0 {
+		w(0, "networks:")
+		for _, n := range spec.Networks {
+			w(1, "%s:", yamlKey(n))
+			w(2, "external: true")
+		}
+	}
+
+	return res.String()
+}
+
+// helpers
+
+func formatPortBindings(bindings nat.PortMap) []string {
+	var res []string
+
+	for port, hostBindings := range bindings {
+		for _, b := range hostBindings {
+			binding := string(port)
+			if b.HostPort != "" {
+				binding = b.HostPort + ":" + binding
+			}
+			if b.HostIP != "" && b.HostIP != "0.0.0.0" && b.HostIP != "::" {
+				if strings.Contains(b.HostIP, ":") {
+					binding = "[" + b.HostIP + "]:" + binding
+				} else {
+					binding = b.HostIP + ":" + binding
+				}
+			}
+			// tcp is the default
+			res = append(res, strings.TrimSuffix(binding, "/tcp"))
+		}
+	}
+
+	slices.Sort(res)
+	return res
+}
+
+// anonymous volumes get a random 64 character hex name
+var anonymousVolumeName = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+func isAnonymousVolume(name string) bool {
+	return anonymousVolumeName.MatchString(name)
+}
+
+func formatCPUs(nanoCPUs int64) string {
+	return strconv.FormatFloat(float64(nanoCPUs)/1e9, 'f', -1, 64)
+}
+
+var shellSafe = regexp.MustCompile(`^[a-zA-Z0-9@%+=:,./_-]+$`)
+
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+var yamlPlain = regexp.MustCompile(`^[a-zA-Z0-9/._-][a-zA-Z0-9@%+=:,./_ -]*$`)
+
+// quotes values that yaml would otherwise misinterpret, eg: numbers, booleans or `key: value` like strings. Compose
+// interpolates variables in every value, so $ is escaped as $$ to keep eg: passwords as they are
+func yamlQuote(s string) string {
+	s = strings.ReplaceAll(s, "$", "$$")
+	if yamlPlain.MatchString(s) && !strings.Contains(s, ": ") && !strings.HasSuffix(s, ":") &&
+		!strings.HasSuffix(s, " ") && !isYamlScalar(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+var yamlPlainKey = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+func yamlKey(s string) string {
+	if yamlPlainKey.MatchString(s) && !isYamlScalar(s) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+func isYamlScalar(s string) bool {
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~", "y", "n":
+		return true
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,210 @@
+package dockercmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+)
+
+func makeTestContainer() (types.ContainerJSON, types.ImageInspect) {
+	pids := int64(100)
+	anonymous := "4f1c5b0e8a7d2c9b6e3f0a1d4c7b8e5f2a9d6c3b0e7f4a1d8c5b2e9f6a3d0c7b"
+
+	info := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:   "0123456789abcdef",
+			Name: "/web",
+			HostConfig: &container.HostConfig{
+				NetworkMode: "backend",
+				PortBindings: nat.PortMap{
+					"80/tcp":  {{HostIP: "0.0.0.0", HostPort: "8080"}},
+					"53/udp":  {{HostIP: "127.0.0.1", HostPort: "5353"}},
+					"443/tcp": {{HostIP: "", HostPort: ""}},
+				},
+				RestartPolicy: container.RestartPolicy{Name: container.RestartPolicyOnFailure, MaximumRetryCount: 3},
+				Resources: container.Resources{
+					Memory:    512 * 1024 * 1024,
+					NanoCPUs:  1_500_000_000,
+					PidsLimit: &pids,
+				},
+				CapAdd: []string{"NET_ADMIN"},
+			},
+		},
+		Mounts: []types.MountPoint{
+			{Type: mount.TypeVolume, Name: "web-data", Destination: "/var/www", RW: true},
+			{Type: mount.TypeBind, Source: "/etc/web.conf", Destination: "/etc/web.conf", RW: false},
+			{Type: mount.TypeVolume, Name: anonymous, Destination: "/cache", RW: true},
+		},
+		Config: &container.Config{
+			Hostname: "0123456789ab",
+			Image:    "nginx:1.25",
+			Env:      []string{"PATH=/usr/local/sbin:/usr/local/bin", "NGINX_VERSION=1.25", "MODE=production", "GREETING=hello world"},
+			Cmd:      []string{"nginx", "-g", "daemon off;"},
+			Labels:   map[string]string{"maintainer": "NGINX", "team": "web", "com.docker.compose.project": "web"},
+		},
+		NetworkSettings: &types.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"backend":  {},
+				"frontend": {},
+				"bridge":   {},
+			},
+		},
+	}
+
+	img := types.ImageInspect{
+		Config: &container.Config{
+			Env:     []string{"PATH=/usr/local/sbin:/usr/local/bin", "NGINX_VERSION=1.25"},
+			Cmd:     []string{"nginx", "-g", "daemon off;"},
+			Labels:  map[string]string{"maintainer": "NGINX"},
+			Volumes: map[string]struct{}{"/cache": {}},
+		},
+	}
+
+	return info, img
+}
+
+func TestMakeRunSpec(t *testing.T) {
+	info, img := makeTestContainer()
+	spec := MakeRunSpec(info, img)
+
+	want := RunSpec{
+		Name:          "web",
+		Image:         "nginx:1.25",
+		Env:           []string{"MODE=production", "GREETING=hello world"},
+		Labels:        map[string]string{"team": "web"},
+		Ports:         []string{"127.0.0.1:5353:53/udp", "443", "8080:80"},
+		Volumes:       []string{"/etc/web.conf:/etc/web.conf:ro", "web-data:/var/www"},
+		Networks:      []string{"backend", "frontend"},
+		RestartPolicy: "on-failure:3",
+		CapAdd:        []string{"NET_ADMIN"},
+		Memory:        512 * 1024 * 1024,
+		NanoCPUs:      1_500_000_000,
+		PidsLimit:     100,
+	}
+
+	if !reflect.DeepEqual(spec, want) {
+		t.Errorf("got %+v\nwant %+v", spec, want)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	info, img := makeTestContainer()
+	got := MakeRunSpec(info, img).RunCommand()
+
+	want := `docker run --detach \
+  --name web \
+  --env MODE=production \
+  --env 'GREETING=hello world' \
+  --label team=web \
+  --publish 127.0.0.1:5353:53/udp \
+  --publish 443 \
+  --publish 8080:80 \
+  --volume /etc/web.conf:/etc/web.conf:ro \
+  --volume web-data:/var/www \
+  --network backend \
+  --restart on-failure:3 \
+  --cap-add NET_ADMIN \
+  --memory 536870912 \
+  --cpus 1.5 \
+  --pids-limit 100 \
+  nginx:1.25
+docker network connect frontend web
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestComposeService(t *testing.T) {
+	info, img := makeTestContainer()
+	got := MakeRunSpec(info, img).ComposeService()
+
+	want := `services:
+  web:
+    image: nginx:1.25
+    container_name: web
+    environment:
+      - MODE=production
+      - GREETING=hello world
+    labels:
+      team: web
+    ports:
+      - "127.0.0.1:5353:53/udp"
+      - "443"
+      - "8080:80"
+    volumes:
+      - /etc/web.conf:/etc/web.conf:ro
+      - web-data:/var/www
+    networks:
+      - backend
+      - frontend
+    restart: on-failure:3
+    cap_add:
+      - NET_ADMIN
+    mem_limit: 536870912
+    cpus: 1.5
+    pids_limit: 100
+volumes:
+  web-data:
+    external: true
+networks:
+  backend:
+    external: true
+  frontend:
+    external: true
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestComposeServiceEscapesVariables(t *testing.T) {
+	spec := RunSpec{
+		Name:   "app",
+		Image:  "app:1.0",
+		Cmd:    []string{"sh", "-c", "echo $HOME"},
+		Env:    []string{"DB_PASSWORD=pa$word"},
+		Labels: map[string]string{"price": "$5"},
+	}
+
+	want := `services:
+  app:
+    image: app:1.0
+    container_name: app
+    command:
+      - sh
+      - -c
+      - "echo $$HOME"
+    environment:
+      - "DB_PASSWORD=pa$$word"
+    labels:
+      price: "$$5"
+`
+	if got := spec.ComposeService(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestClearedEntrypoint(t *testing.T) {
+	info, img := makeTestContainer()
+	img.Config.Entrypoint = []string{"/docker-entrypoint.sh"}
+
+	spec := MakeRunSpec(info, img)
+	if spec.Entrypoint == nil || len(spec.Entrypoint) != 0 {
+		t.Fatalf("got entrypoint %#v, want an empty override", spec.Entrypoint)
+	}
+
+	// without the image's entrypoint the command has to be given again
+	if run := spec.RunCommand(); !strings.Contains(run, "--entrypoint '' \\\n  nginx:1.25 nginx -g 'daemon off;'") {
+		t.Errorf("run command does not clear the entrypoint:\n%s", run)
+	}
+	if compose := spec.ComposeService(); !strings.Contains(compose, "    entrypoint: []\n    command:\n") {
+		t.Errorf("compose service does not clear the entrypoint:\n%s", compose)
+	}
+}
@@ -0,0 +1,644 @@
+package dockercmd
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"debug/buildinfo"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+type PackageType string
+
+const (
+	PackageDeb    PackageType = "deb"
+	PackageApk    PackageType = "apk"
+	PackageGo     PackageType = "golang"
+	PackageNpm    PackageType = "npm"
+	PackagePython PackageType = "pypi"
+)
+
+type Package struct {
+	Name    string
+	Version string
+	Type    PackageType
+	// os packages only
+	Arch string
+	// as declared by the package, not necessarily a valid SPDX expression. Empty when unknown
+	License string
+	// file the package was found in
+	Source string
+}
+
+type OSRelease struct {
+	ID         string
+	VersionID  string
+	PrettyName string
+}
+
+// software bill of materials of an image, built from the package databases in its filesystem
+type SBOM struct {
+	Image   string
+	ImageID string
+	OS      OSRelease
+	// sorted by type, name and version
+	Packages []Package
+	// package databases that were found but could not be read, eg: rpm
+	Warnings []string
+}
+
+const (
+	// package databases and lockfiles bigger than this are skipped
+	maxSBOMFileSize = 64 << 20
+	// executables bigger than this are not checked for go build info, they are copied to a temporary file to read it
+	maxGoBinarySize = 512 << 20
+)
+
+// rpm keeps its database in BerkeleyDB or sqlite, neither can be read without extra dependencies
+var rpmDatabases = []string{"var/lib/rpm/Packages", "var/lib/rpm/rpmdb.sqlite", "usr/lib/sysimage/rpm/rpmdb.sqlite"}
+
+// Saves the image and lists the os and language packages found in its filesystem. Nothing is downloaded, so it works
+// offline. Progress reports the bytes received from the daemon.
+func (dc *DockerClient) GenerateSBOM(id string, progress ProgressFunc) (*SBOM, error) {
+	info, _, err := dc.cli.ImageInspectWithRaw(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := dc.cli.ImageSave(context.Background(), []string{info.ID})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	sbom, err := ScanImageTar(&progressReader{r: rc, progress: progress})
+	if err != nil {
+		return nil, err
+	}
+
+	sbom.ImageID = info.ID
+	sbom.Image = info.ID
+	if len(info.RepoTags) > 0 {
+		sbom.Image = info.RepoTags[0]
+	}
+	return sbom, nil
+}
+
+// files of interest found in a single layer
+type sbomLayer struct {
+	// package databases, lockfiles and metadata. Present with nil content for rpm databases
+	files map[string][]byte
+	// packages from the build info of go binaries, executables without build info map to nil
+	goPackages map[string][]Package
+	// every regular file in the layer, they replace what lower layers had at the same path (eg: a go binary
+	// overwritten by a shell script)
+	regularFiles []string
+	whiteouts    []string
+	opaqueDirs   []string
+}
+
+// what is left of a file after all layers were applied
+type sbomFile struct {
+	data       []byte
+	goPackages []Package
+	isBinary   bool
+}
+
+// Builds an SBOM from a tarball produced by `ImageSave` (both legacy and OCI layouts). Layers are applied in order, so
+// packages removed by later layers are not listed.
+func ScanImageTar(r io.Reader) (*SBOM, error) {
+	// go binaries are copied here one at a time, reading their build info needs random access
+	scratch, err := os.CreateTemp("", "gomanagedocker-sbom-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(scratch.Name())
+	defer scratch.Close()
+
+	layers := make(map[string]*sbomLayer)
+	metadata := make(map[string][]byte)
+	// legacy archives deduplicate identical layers using symlinks
+	links := make(map[string]string)
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if hdr.Typeflag == tar.TypeSymlink {
+			links[path.Clean(hdr.Name)] = path.Join(path.Dir(hdr.Name), hdr.Linkname)
+			continue
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		br := bufio.NewReader(tr)
+		peek, _ := br.Peek(512)
+
+		switch {
+		case isGzip(peek):
+			gz, err := gzip.NewReader(br)
+			if err != nil {
+				return nil, err
+			}
+			if layers[name], err = scanSBOMLayer(gz, scratch); err != nil {
+				return nil, err
+			}
+		case isTar(peek):
+			if layers[name], err = scanSBOMLayer(br, scratch); err != nil {
+				return nil, err
+			}
+		case hdr.Size <= maxMetadataBlobSize:
+			data, err := io.ReadAll(br)
+			if err != nil {
+				return nil, err
+			}
+			metadata[name] = data
+		}
+	}
+
+	var manifests []imageManifest
+	if err := json.Unmarshal(metadata["manifest.json"], &manifests); err != nil || len(manifests) == 0 {
+		return nil, errors.New("image archive does not contain a valid manifest.json")
+	}
+
+	files := make(map[string]sbomFile)
+	for _, layerPath := range manifests[0].Layers {
+		layerPath = path.Clean(layerPath)
+		if target, ok := links[layerPath]; ok {
+			layerPath = target
+		}
+
+		layer, ok := layers[layerPath]
+		if !ok {
+			continue
+		}
+
+		// whiteouts only hide files of lower layers
+		for _, dir := range layer.opaqueDirs {
+			removeSBOMFiles(files, dir, true)
+		}
+		for _, target := range layer.whiteouts {
+			removeSBOMFiles(files, target, false)
+		}
+		for _, p := range layer.regularFiles {
+			delete(files, p)
+		}
+
+		for p, data := range layer.files {
+			files[p] = sbomFile{data: data}
+		}
+		for p, pkgs := range layer.goPackages {
+			files[p] = sbomFile{goPackages: pkgs, isBinary: true}
+		}
+	}
+
+	return buildSBOM(files), nil
+}
+
+// removes target and everything below it, or only what is below it for opaque directories
+func removeSBOMFiles(files map[string]sbomFile, target string, onlyChildren bool) {
+	for p := range files {
+		if (!onlyChildren && p == target) || strings.HasPrefix(p, target+"/") {
+			delete(files, p)
+		}
+	}
+}
+
+func scanSBOMLayer(r io.Reader, scratch *os.File) (*sbomLayer, error) {
+	layer := &sbomLayer{
+		files:      make(map[string][]byte),
+		goPackages: make(map[string][]Package),
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return layer, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		p := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		dir, base := path.Split(p)
+		dir = strings.TrimSuffix(dir, "/")
+
+		switch {
+		case base == whiteoutOpaque:
+			layer.opaqueDirs = append(layer.opaqueDirs, dir)
+			continue
+		case strings.HasPrefix(base, whiteoutPrefix):
+			layer.whiteouts = append(layer.whiteouts, path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+			continue
+		case hdr.Typeflag != tar.TypeReg:
+			continue
+		}
+
+		layer.regularFiles = append(layer.regularFiles, p)
+
+		switch {
+		case slices.Contains(rpmDatabases, p):
+			layer.files[p] = nil
+
+		case isSBOMMetadataFile(p):
+			if hdr.Size > maxSBOMFileSize {
+				continue
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			layer.files[p] = data
+
+		case hdr.Mode&0111 != 0 && hdr.Size <= maxGoBinarySize:
+			br := bufio.NewReader(tr)
+			if magic, _ := br.Peek(4); !bytes.Equal(magic, []byte("\x7fELF")) {
+				continue
+			}
+
+			if err := scratch.Truncate(0); err != nil {
+				return nil, err
+			}
+			n, err := io.Copy(io.NewOffsetWriter(scratch, 0), br)
+			if err != nil {
+				return nil, err
+			}
+			layer.goPackages[p] = goBinaryPackages(io.NewSectionReader(scratch, 0, n), p)
+		}
+	}
+}
+
+// package databases, lockfiles and the metadata files that describe packages
+func isSBOMMetadataFile(p string) bool {
+	dir, base := path.Split(p)
+	dir = strings.TrimSuffix(dir, "/")
+
+	switch {
+	case p == "var/lib/dpkg/status" || p == "lib/apk/db/installed":
+		return true
+	case p == "etc/os-release" || p == "usr/lib/os-release":
+		return true
+	// distroless images have a status file per package
+	case dir == "var/lib/dpkg/status.d" && !strings.HasSuffix(base, ".md5sums"):
+		return true
+	case base == "copyright" && path.Dir(dir) == "usr/share/doc":
+		return true
+	// npm v7+ also keeps a hidden lockfile in node_modules/.package-lock.json
+	case strings.HasSuffix(base, "package-lock.json"):
+		return true
+	case strings.Contains(p, "-packages/"):
+		// site-packages or dist-packages
+		return (base == "METADATA" && strings.HasSuffix(dir, ".dist-info")) ||
+			(base == "PKG-INFO" && strings.HasSuffix(dir, ".egg-info")) ||
+			strings.HasSuffix(base, ".egg-info")
+	}
+
+	return false
+}
+
+func buildSBOM(files map[string]sbomFile) *SBOM {
+	sbom := &SBOM{}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+
+	// dpkg keeps licenses in machine readable copyright files, keyed by package name
+	debLicenses := make(map[string]string)
+	var pkgs []Package
+
+	for _, p := range paths {
+		file := files[p]
+		dir, base := path.Split(p)
+		dir = strings.TrimSuffix(dir, "/")
+
+		switch {
+		case file.isBinary:
+			pkgs = append(pkgs, file.goPackages...)
+		case slices.Contains(rpmDatabases, p):
+			sbom.Warnings = append(sbom.Warnings, "rpm database /"+p+" can not be read, rpm packages are not listed")
+		case p == "etc/os-release" || p == "usr/lib/os-release":
+			// etc/os-release takes precedence, it sorts first
+			if sbom.OS.ID == "" {
+				sbom.OS = parseOSRelease(file.data)
+			}
+		case p == "var/lib/dpkg/status" || dir == "var/lib/dpkg/status.d":
+			pkgs = append(pkgs, parseDpkgStatus(file.data, p)...)
+		case base == "copyright":
+			if licenses := parseDebianCopyright(file.data); licenses != "" {
+				debLicenses[path.Base(dir)] = licenses
+			}
+		case p == "lib/apk/db/installed":
+			pkgs = append(pkgs, parseApkInstalled(file.data, p)...)
+		case strings.HasSuffix(base, "package-lock.json"):
+			pkgs = append(pkgs, parsePackageLock(file.data, p)...)
+		default:
+			if pkg, ok := parsePythonMetadata(file.data, p); ok {
+				pkgs = append(pkgs, pkg)
+			}
+		}
+	}
+
+	for i := range pkgs {
+		if pkgs[i].Type == PackageDeb && pkgs[i].License == "" {
+			pkgs[i].License = debLicenses[pkgs[i].Name]
+		}
+	}
+
+	slices.SortStableFunc(pkgs, func(a Package, b Package) int {
+		return cmp.Or(cmp.Compare(a.Type, b.Type), cmp.Compare(a.Name, b.Name), cmp.Compare(a.Version, b.Version))
+	})
+
+	// the same package can be listed more than once, eg: by a lockfile and npm's hidden lockfile
+	sbom.Packages = slices.CompactFunc(pkgs, func(a Package, b Package) bool {
+		return a.Type == b.Type && a.Name == b.Name && a.Version == b.Version
+	})
+
+	return sbom
+}
+
+// Packages a go binary was built from: the standard library, the main module and its dependencies. Binaries without
+// build info (ie: not built by go) have none.
+func goBinaryPackages(r io.ReaderAt, source string) []Package {
+	info, err := buildinfo.Read(r)
+	if err != nil {
+		return nil
+	}
+
+	pkgs := []Package{{Name: "stdlib", Version: info.GoVersion, Type: PackageGo, Source: source}}
+
+	if info.Main.Path != "" {
+		pkgs = append(pkgs, Package{Name: info.Main.Path, Version: info.Main.Version, Type: PackageGo, Source: source})
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		pkgs = append(pkgs, Package{Name: dep.Path, Version: dep.Version, Type: PackageGo, Source: source})
+	}
+
+	return pkgs
+}
+
+// Splits deb822 style paragraphs (dpkg status, apk uses a similar format) into fields. Continuation lines (starting
+// with a space) are appended to the previous field.
+func parseParagraphs(data []byte, separator string) []map[string]string {
+	var res []map[string]string
+	current := make(map[string]string)
+	lastKey := ""
+
+	flush := func() {
+		if len(current) > 0 {
+			res = append(res, current)
+		}
+		current = make(map[string]string)
+		lastKey = ""
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+		case (line[0] == ' ' || line[0] == '\t') && lastKey != "":
+			current[lastKey] += "\n" + strings.TrimSpace(line)
+		default:
+			key, value, ok := strings.Cut(line, separator)
+			if !ok {
+				continue
+			}
+			lastKey = strings.TrimSpace(key)
+			// repeated fields (eg: Classifier in python metadata) are kept on seperate lines
+			if prev, exists := current[lastKey]; exists {
+				current[lastKey] = prev + "\n" + strings.TrimSpace(value)
+			} else {
+				current[lastKey] = strings.TrimSpace(value)
+			}
+		}
+	}
+
+	flush()
+	return res
+}
+
+func parseDpkgStatus(data []byte, source string) []Package {
+	var pkgs []Package
+
+	for _, fields := range parseParagraphs(data, ":") {
+		// distroless status.d files have no status, everything in there is installed
+		if status, ok := fields["Status"]; ok && !strings.HasSuffix(status, " installed") {
+			continue
+		}
+		if fields["Package"] == "" {
+			continue
+		}
+
+		pkgs = append(pkgs, Package{
+			Name:    fields["Package"],
+			Version: fields["Version"],
+			Type:    PackageDeb,
+			Arch:    fields["Architecture"],
+			Source:  source,
+		})
+	}
+
+	return pkgs
+}
+
+// licenses named in a machine readable debian copyright file, joined with AND
+func parseDebianCopyright(data []byte) string {
+	var licenses []string
+
+	for _, line := range strings.Split(string(data), "\n") {
+		// only the first line names the license, continuation lines hold its text
+		license, ok := strings.CutPrefix(line, "License:")
+		license = strings.TrimSpace(license)
+		if ok && license != "" && !slices.Contains(licenses, license) {
+			licenses = append(licenses, license)
+		}
+	}
+
+	return strings.Join(licenses, " AND ")
+}
+
+func parseApkInstalled(data []byte, source string) []Package {
+	var pkgs []Package
+
+	for _, fields := range parseParagraphs(data, ":") {
+		if fields["P"] == "" {
+			continue
+		}
+
+		pkgs = append(pkgs, Package{
+			Name:    fields["P"],
+			Version: fields["V"],
+			Type:    PackageApk,
+			Arch:    fields["A"],
+			License: fields["L"],
+			Source:  source,
+		})
+	}
+
+	return pkgs
+}
+
+type npmLockDependency struct {
+	Version      string
+	Dependencies map[string]npmLockDependency
+}
+
+type npmLockfile struct {
+	Name     string
+	Version  string
+	Packages map[string]struct {
+		Name    string
+		Version string
+		License json.RawMessage
+		Link    bool
+	}
+	// lockfile v1
+	Dependencies map[string]npmLockDependency
+}
+
+// Packages of a package-lock.json, v2/v3 lockfiles list every package under "packages", v1 lockfiles only have the
+// nested "dependencies" (without licenses)
+func parsePackageLock(data []byte, source string) []Package {
+	var lock npmLockfile
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil
+	}
+
+	var pkgs []Package
+
+	if len(lock.Packages) > 0 {
+		for key, entry := range lock.Packages {
+			if entry.Link || entry.Version == "" {
+				continue
+			}
+
+			// keys are install paths, eg: node_modules/a/node_modules/@scope/b, the root project has an empty key
+			name := entry.Name
+			if i := strings.LastIndex(key, "node_modules/"); i >= 0 {
+				name = key[i+len("node_modules/"):]
+			}
+			if name == "" {
+				continue
+			}
+
+			pkgs = append(pkgs, Package{Name: name, Version: entry.Version, Type: PackageNpm, License: npmLicense(entry.License), Source: source})
+		}
+
+		return pkgs
+	}
+
+	var walk func(deps map[string]npmLockDependency)
+	walk = func(deps map[string]npmLockDependency) {
+		for name, dep := range deps {
+			pkgs = append(pkgs, Package{Name: name, Version: dep.Version, Type: PackageNpm, Source: source})
+			walk(dep.Dependencies)
+		}
+	}
+	walk(lock.Dependencies)
+
+	return pkgs
+}
+
+// licenses are usually an SPDX expression, old packages use {"type": "MIT"}
+func npmLicense(raw json.RawMessage) string {
+	var license string
+	if err := json.Unmarshal(raw, &license); err == nil {
+		return license
+	}
+
+	var legacy struct{ Type string }
+	if err := json.Unmarshal(raw, &legacy); err == nil {
+		return legacy.Type
+	}
+	return ""
+}
+
+// Reads the METADATA (or PKG-INFO) headers of an installed python distribution
+func parsePythonMetadata(data []byte, source string) (Package, bool) {
+	// the headers end at the first empty line, the description follows
+	header, _, _ := strings.Cut(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n\n")
+
+	paragraphs := parseParagraphs([]byte(header), ":")
+	if len(paragraphs) == 0 || paragraphs[0]["Name"] == "" {
+		return Package{}, false
+	}
+	fields := paragraphs[0]
+
+	pkg := Package{Name: fields["Name"], Version: fields["Version"], Type: PackagePython, Source: source}
+
+	license := strings.TrimSpace(fields["License"])
+	switch {
+	case fields["License-Expression"] != "":
+		pkg.License = fields["License-Expression"]
+	// the License field sometimes holds the whole license text
+	case license != "" && license != "UNKNOWN" && !strings.Contains(license, "\n") && len(license) <= 80:
+		pkg.License = license
+	default:
+		var classifiers []string
+		for _, classifier := range strings.Split(fields["Classifier"], "\n") {
+			if strings.HasPrefix(classifier, "License ::") {
+				parts := strings.Split(classifier, "::")
+				classifiers = append(classifiers, strings.TrimSpace(parts[len(parts)-1]))
+			}
+		}
+		pkg.License = strings.Join(classifiers, " AND ")
+	}
+
+	return pkg, true
+}
+
+func parseOSRelease(data []byte) OSRelease {
+	values := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok {
+			values[key] = strings.Trim(value, `"'`)
+		}
+	}
+
+	return OSRelease{ID: values["ID"], VersionID: values["VERSION_ID"], PrettyName: values["PRETTY_NAME"]}
+}
+
+// Packages whose name, version, license or type contains query (case insensitive)
+func FilterPackages(pkgs []Package, query string) []Package {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return pkgs
+	}
+
+	var res []Package
+	for _, pkg := range pkgs {
+		haystack := strings.ToLower(strings.Join([]string{pkg.Name, pkg.Version, pkg.License, string(pkg.Type)}, " "))
+		if strings.Contains(haystack, query) {
+			res = append(res, pkg)
+		}
+	}
+	return res
+}
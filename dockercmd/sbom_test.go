@@ -0,0 +1,262 @@
+package dockercmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testDpkgStatus = `Package: bash
+Status: install ok installed
+Architecture: amd64
+Version: 5.2.15-2+b2
+Description: GNU Bourne Again SHell
+ Bash is an sh-compatible command language interpreter.
+
+Package: removed
+Status: deinstall ok config-files
+Version: 1.0
+`
+
+const testCopyright = `Format: https://www.debian.org/doc/packaging-manuals/copyright-format/1.0/
+
+Files: *
+License: GPL-3+
+ This program is free software; you can redistribute it and/or modify
+ it under the terms of the GNU General Public License, or (at your option) any later version.
+
+Files: lib/*
+License: BSD-3-clause
+`
+
+const testPackageLock = `{
+	"name": "app",
+	"version": "1.0.0",
+	"lockfileVersion": 3,
+	"packages": {
+		"": {"name": "app", "version": "1.0.0"},
+		"node_modules/express": {"version": "4.18.2", "license": "MIT"},
+		"node_modules/express/node_modules/@types/node": {"version": "20.1.0", "license": {"type": "MIT"}},
+		"node_modules/local": {"resolved": "../local", "link": true}
+	}
+}`
+
+const testPythonMetadata = `Metadata-Version: 2.1
+Name: requests
+Version: 2.31.0
+License: Apache 2.0
+Classifier: License :: OSI Approved :: Apache Software License
+
+Requests is an HTTP library.
+`
+
+func TestScanImageTar(t *testing.T) {
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	goBinary, err := os.ReadFile(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layers := [][]byte{
+		makeTar(t, []tarEntry{
+			{name: "etc/os-release", content: "ID=debian\nVERSION_ID=\"12\"\nPRETTY_NAME=\"Debian GNU/Linux 12 (bookworm)\"\n"},
+			{name: "var/lib/dpkg/status", content: testDpkgStatus},
+			{name: "usr/share/doc/bash/copyright", content: testCopyright},
+			{name: "opt/old/package-lock.json", content: `{"lockfileVersion": 1, "dependencies": {"left-pad": {"version": "1.3.0"}}}`},
+			{name: "var/lib/rpm/rpmdb.sqlite", content: "SQLite format 3"},
+			{name: "usr/local/bin/replaced", content: string(goBinary), mode: 0755},
+		}),
+		makeTar(t, []tarEntry{
+			{name: "opt/.wh.old"},
+			{name: "usr/local/bin/replaced", content: "#!/bin/sh\nexec tool\n", mode: 0755},
+			{name: "var/lib/rpm/.wh.rpmdb.sqlite"},
+			{name: "app/package-lock.json", content: testPackageLock},
+			{name: "app/node_modules/.package-lock.json", content: testPackageLock},
+			{name: "usr/lib/python3/site-packages/requests-2.31.0.dist-info/METADATA", content: testPythonMetadata},
+			{name: "usr/local/bin/tool", content: string(goBinary), mode: 0755},
+			{name: "usr/local/bin/script", content: "#!/bin/sh\necho hi\n", mode: 0755},
+		}),
+	}
+
+	sbom, err := ScanImageTar(bytes.NewReader(makeImageArchive(t, layers, []byte("{}"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sbom.OS != (OSRelease{ID: "debian", VersionID: "12", PrettyName: "Debian GNU/Linux 12 (bookworm)"}) {
+		t.Errorf("unexpected os release %+v", sbom.OS)
+	}
+	if len(sbom.Warnings) != 0 {
+		t.Errorf("the rpm database was removed, got warnings %v", sbom.Warnings)
+	}
+
+	find := func(pkgType PackageType, name string) (Package, bool) {
+		for _, pkg := range sbom.Packages {
+			if pkg.Type == pkgType && pkg.Name == name {
+				return pkg, true
+			}
+		}
+		return Package{}, false
+	}
+
+	wants := []Package{
+		{Name: "bash", Version: "5.2.15-2+b2", Type: PackageDeb, Arch: "amd64", License: "GPL-3+ AND BSD-3-clause", Source: "var/lib/dpkg/status"},
+		{Name: "app", Version: "1.0.0", Type: PackageNpm, Source: "app/node_modules/.package-lock.json"},
+		{Name: "express", Version: "4.18.2", Type: PackageNpm, License: "MIT", Source: "app/node_modules/.package-lock.json"},
+		{Name: "@types/node", Version: "20.1.0", Type: PackageNpm, License: "MIT", Source: "app/node_modules/.package-lock.json"},
+		{Name: "requests", Version: "2.31.0", Type: PackagePython, License: "Apache 2.0", Source: "usr/lib/python3/site-packages/requests-2.31.0.dist-info/METADATA"},
+		{Name: "stdlib", Version: runtime.Version(), Type: PackageGo, Source: "usr/local/bin/tool"},
+	}
+	for _, want := range wants {
+		if got, ok := find(want.Type, want.Name); !ok || got != want {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+	}
+
+	for _, missing := range []string{"removed", "left-pad", "local"} {
+		if pkg, ok := find(PackageDeb, missing); ok {
+			t.Errorf("%s should not be listed: %+v", missing, pkg)
+		}
+		if pkg, ok := find(PackageNpm, missing); ok {
+			t.Errorf("%s should not be listed: %+v", missing, pkg)
+		}
+	}
+
+	for _, pkg := range sbom.Packages {
+		if pkg.Source == "usr/local/bin/replaced" {
+			t.Errorf("the go binary was replaced by a script, got %+v", pkg)
+		}
+	}
+
+	// both lockfiles list the same packages
+	npmCount := 0
+	for _, pkg := range sbom.Packages {
+		if pkg.Type == PackageNpm {
+			npmCount++
+		}
+	}
+	if npmCount != 3 {
+		t.Errorf("expected 3 npm packages, got %d", npmCount)
+	}
+
+	if filtered := FilterPackages(sbom.Packages, "EXPRESS"); len(filtered) != 1 || filtered[0].Name != "express" {
+		t.Errorf("unexpected search result %+v", filtered)
+	}
+}
+
+func TestScanImageTarRpmWarning(t *testing.T) {
+	layers := [][]byte{makeTar(t, []tarEntry{{name: "var/lib/rpm/rpmdb.sqlite", content: "SQLite format 3"}})}
+
+	sbom, err := ScanImageTar(bytes.NewReader(makeImageArchive(t, layers, []byte("{}"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sbom.Warnings) != 1 || !strings.Contains(sbom.Warnings[0], "rpm") {
+		t.Errorf("expected an rpm warning, got %v", sbom.Warnings)
+	}
+}
+
+func TestPackagePURL(t *testing.T) {
+	debian := OSRelease{ID: "debian", VersionID: "12"}
+
+	cases := []struct {
+		pkg  Package
+		want string
+	}{
+		{Package{Name: "libc6", Version: "1:2.36-9", Type: PackageDeb, Arch: "amd64"}, "pkg:deb/debian/libc6@1%3A2.36-9?arch=amd64&distro=debian-12"},
+		{Package{Name: "musl", Version: "1.2.4-r2", Type: PackageApk}, "pkg:apk/alpine/musl@1.2.4-r2"},
+		{Package{Name: "github.com/spf13/cobra", Version: "v1.8.0", Type: PackageGo}, "pkg:golang/github.com/spf13/cobra@v1.8.0"},
+		{Package{Name: "@types/node", Version: "20.1.0", Type: PackageNpm}, "pkg:npm/%40types/node@20.1.0"},
+		{Package{Name: "Flask_Login", Version: "0.6.3", Type: PackagePython}, "pkg:pypi/flask-login@0.6.3"},
+	}
+
+	for _, c := range cases {
+		osRelease := debian
+		if c.pkg.Type == PackageApk {
+			osRelease = OSRelease{}
+		}
+		if got := c.pkg.PURL(osRelease); got != c.want {
+			t.Errorf("got %s, want %s", got, c.want)
+		}
+	}
+}
+
+func TestIsSPDXExpression(t *testing.T) {
+	valid := []string{"MIT", "MIT OR Apache-2.0", "(MIT AND BSD-3-Clause) OR GPL-2.0+", "GPL-2.0 WITH Classpath-exception-2.0"}
+	invalid := []string{"", "GPL-2+ or Artistic", "Apache 2.0", "MIT AND", "AND MIT"}
+
+	for _, s := range valid {
+		if !isSPDXExpression(s) {
+			t.Errorf("%q should be valid", s)
+		}
+	}
+	for _, s := range invalid {
+		if isSPDXExpression(s) {
+			t.Errorf("%q should be invalid", s)
+		}
+	}
+}
+
+func TestSBOMExport(t *testing.T) {
+	sbom := &SBOM{
+		Image:   "app:latest",
+		ImageID: "sha256:abc",
+		OS:      OSRelease{ID: "alpine", VersionID: "3.19"},
+		Packages: []Package{
+			{Name: "musl", Version: "1.2.4-r2", Type: PackageApk, License: "MIT", Source: "lib/apk/db/installed"},
+			{Name: "requests", Version: "2.31.0", Type: PackagePython, License: "Apache 2.0", Source: "METADATA"},
+		},
+	}
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	data, err := sbom.SPDX(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spdx spdxDocument
+	if err := json.Unmarshal(data, &spdx); err != nil {
+		t.Fatal(err)
+	}
+	if spdx.SPDXVersion != "SPDX-2.3" || spdx.CreationInfo.Created != "2024-05-01T12:00:00Z" || len(spdx.Packages) != 3 || len(spdx.Relationships) != 3 {
+		t.Errorf("unexpected spdx document %s", data)
+	}
+	if musl := spdx.Packages[1]; musl.LicenseDeclared != "MIT" || musl.ExternalRefs[0].ReferenceLocator != "pkg:apk/alpine/musl@1.2.4-r2?distro=alpine-3.19" {
+		t.Errorf("unexpected package %+v", musl)
+	}
+	if requests := spdx.Packages[2]; requests.LicenseDeclared != "NOASSERTION" || requests.LicenseComments != "declared license: Apache 2.0" {
+		t.Errorf("invalid expressions should be kept as a comment, got %+v", requests)
+	}
+
+	data, err = sbom.CycloneDX(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cdx cycloneDXDocument
+	if err := json.Unmarshal(data, &cdx); err != nil {
+		t.Fatal(err)
+	}
+	if cdx.BOMFormat != "CycloneDX" || cdx.Metadata.Component.Name != "app:latest" || !strings.HasPrefix(cdx.SerialNumber, "urn:uuid:") {
+		t.Errorf("unexpected cyclonedx document %s", data)
+	}
+
+	var names []string
+	for _, c := range cdx.Components {
+		names = append(names, c.Type+":"+c.Name)
+	}
+	if want := []string{"operating-system:alpine", "library:musl", "library:requests"}; !slices.Equal(names, want) {
+		t.Errorf("got components %v, want %v", names, want)
+	}
+	if license := cdx.Components[2].Licenses[0]; license.License == nil || license.License.Name != "Apache 2.0" {
+		t.Errorf("unexpected license %+v", license)
+	}
+}
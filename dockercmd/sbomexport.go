@@ -0,0 +1,326 @@
+package dockercmd
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+type SBOMFormat int
+
+const (
+	SPDXFormat SBOMFormat = iota
+	CycloneDXFormat
+)
+
+const sbomToolName = "gomanagedocker"
+
+// Package URL (https://github.com/package-url/purl-spec) of the package, os packages are qualified by the distro
+func (p Package) PURL(osRelease OSRelease) string {
+	var purl string
+
+	switch p.Type {
+	case PackageDeb, PackageApk:
+		distro := osRelease.ID
+		if distro == "" {
+			distro = map[PackageType]string{PackageDeb: "debian", PackageApk: "alpine"}[p.Type]
+		}
+		purl = fmt.Sprintf("pkg:%s/%s/%s@%s", p.Type, purlEscape(distro), purlEscape(p.Name), purlEscape(p.Version))
+
+		var qualifiers []string
+		if p.Arch != "" {
+			qualifiers = append(qualifiers, "arch="+purlEscape(p.Arch))
+		}
+		if osRelease.ID != "" && osRelease.VersionID != "" {
+			qualifiers = append(qualifiers, "distro="+purlEscape(osRelease.ID+"-"+osRelease.VersionID))
+		}
+		if len(qualifiers) > 0 {
+			purl += "?" + strings.Join(qualifiers, "&")
+		}
+		return purl
+
+	case PackageGo:
+		// the module path is the namespace and name, its slashes stay
+		segments := strings.Split(p.Name, "/")
+		for i := range segments {
+			segments[i] = purlEscape(segments[i])
+		}
+		purl = "pkg:golang/" + strings.Join(segments, "/")
+
+	case PackageNpm:
+		// scoped packages: pkg:npm/%40scope/name
+		purl = "pkg:npm/" + strings.Replace(purlEscape(p.Name), "%2F", "/", 1)
+
+	case PackagePython:
+		// names are normalized to lowercase with dashes
+		purl = "pkg:pypi/" + purlEscape(strings.ToLower(strings.ReplaceAll(p.Name, "_", "-")))
+	}
+
+	if p.Version != "" {
+		purl += "@" + purlEscape(p.Version)
+	}
+	return purl
+}
+
+// percent encodes everything but unreserved characters (and +, which versions use a lot)
+func purlEscape(s string) string {
+	var res strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z', b >= '0' && b <= '9', strings.IndexByte(".-_~+", b) >= 0:
+			res.WriteByte(b)
+		default:
+			fmt.Fprintf(&res, "%%%02X", b)
+		}
+	}
+	return res.String()
+}
+
+var spdxLicenseToken = regexp.MustCompile(`^[A-Za-z0-9.+-]+$`)
+
+// Checks the syntax of an SPDX license expression (eg: MIT OR Apache-2.0), the license ids are not checked against the
+// SPDX license list. Licenses declared by packages often are not expressions, eg: "GPL-2+ or Artistic".
+func isSPDXExpression(s string) bool {
+	tokens := strings.Fields(strings.NewReplacer("(", " ", ")", " ").Replace(s))
+	if len(tokens) == 0 {
+		return false
+	}
+
+	// licenses and operators have to alternate
+	for i, token := range tokens {
+		isOperator := token == "AND" || token == "OR" || token == "WITH"
+		if isOperator != (i%2 == 1) || (!isOperator && !spdxLicenseToken.MatchString(token)) {
+			return false
+		}
+	}
+	return len(tokens)%2 == 1
+}
+
+// Writes the SBOM to a new file at path, an existing file is never overwritten
+func (s *SBOM) Write(path string, format SBOMFormat) error {
+	var data []byte
+	var err error
+
+	if format == CycloneDXFormat {
+		data, err = s.CycloneDX(time.Now())
+	} else {
+		data, err = s.SPDX(time.Now())
+	}
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	LicenseComments  string            `json:"licenseComments,omitempty"`
+	CopyrightText    string            `json:"copyrightText"`
+	SourceInfo       string            `json:"sourceInfo,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// SPDX 2.3 JSON document, the image is a package that contains every package found in it
+func (s *SBOM) SPDX(created time.Time) ([]byte, error) {
+	const imageSPDXID = "SPDXRef-Image"
+
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              s.Image,
+		DocumentNamespace: fmt.Sprintf("https://%s/spdx/%s-%s", sbomToolName, strings.TrimPrefix(s.ImageID, "sha256:"), newUUID()),
+		CreationInfo: spdxCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: " + sbomToolName},
+		},
+		Packages: []spdxPackage{{
+			Name:             s.Image,
+			SPDXID:           imageSPDXID,
+			VersionInfo:      s.ImageID,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  "NOASSERTION",
+			CopyrightText:    "NOASSERTION",
+		}},
+		Relationships: []spdxRelationship{{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: imageSPDXID}},
+	}
+
+	for i, pkg := range s.Packages {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+
+		spdxPkg := spdxPackage{
+			Name:             pkg.Name,
+			SPDXID:           id,
+			VersionInfo:      pkg.Version,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  "NOASSERTION",
+			CopyrightText:    "NOASSERTION",
+			SourceInfo:       "found in /" + pkg.Source,
+			ExternalRefs: []spdxExternalRef{{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  pkg.PURL(s.OS),
+			}},
+		}
+
+		// licenses that are not valid expressions would make the document invalid, they are kept as a comment
+		if isSPDXExpression(pkg.License) {
+			spdxPkg.LicenseDeclared = pkg.License
+		} else if pkg.License != "" {
+			spdxPkg.LicenseComments = "declared license: " + pkg.License
+		}
+
+		doc.Packages = append(doc.Packages, spdxPkg)
+		doc.Relationships = append(doc.Relationships, spdxRelationship{SPDXElementID: imageSPDXID, RelationshipType: "CONTAINS", RelatedSPDXElement: id})
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type cycloneDXDocument struct {
+	BOMFormat    string               `json:"bomFormat"`
+	SpecVersion  string               `json:"specVersion"`
+	SerialNumber string               `json:"serialNumber"`
+	Version      int                  `json:"version"`
+	Metadata     cycloneDXMetadata    `json:"metadata"`
+	Components   []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string `json:"timestamp"`
+	Tools     struct {
+		Components []cycloneDXComponent `json:"components"`
+	} `json:"tools"`
+	Component cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXComponent struct {
+	Type       string               `json:"type"`
+	BOMRef     string               `json:"bom-ref,omitempty"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version,omitempty"`
+	PURL       string               `json:"purl,omitempty"`
+	Licenses   []cycloneDXLicense   `json:"licenses,omitempty"`
+	Properties []cycloneDXProperty  `json:"properties,omitempty"`
+	Components []cycloneDXComponent `json:"components,omitempty"`
+}
+
+// either an SPDX expression or a license by name
+type cycloneDXLicense struct {
+	Expression string                `json:"expression,omitempty"`
+	License    *cycloneDXLicenseName `json:"license,omitempty"`
+}
+
+type cycloneDXLicenseName struct {
+	Name string `json:"name"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CycloneDX 1.5 JSON document, the image is the metadata component
+func (s *SBOM) CycloneDX(created time.Time) ([]byte, error) {
+	doc := cycloneDXDocument{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Components:   []cycloneDXComponent{},
+	}
+
+	doc.Metadata.Timestamp = created.UTC().Format(time.RFC3339)
+	doc.Metadata.Tools.Components = []cycloneDXComponent{{Type: "application", Name: sbomToolName}}
+	doc.Metadata.Component = cycloneDXComponent{Type: "container", BOMRef: s.ImageID, Name: s.Image, Version: s.ImageID}
+
+	if s.OS.ID != "" {
+		doc.Components = append(doc.Components, cycloneDXComponent{
+			Type:    "operating-system",
+			BOMRef:  "os:" + s.OS.ID,
+			Name:    s.OS.ID,
+			Version: s.OS.VersionID,
+		})
+	}
+
+	for _, pkg := range s.Packages {
+		component := cycloneDXComponent{
+			Type:       "library",
+			BOMRef:     pkg.PURL(s.OS),
+			Name:       pkg.Name,
+			Version:    pkg.Version,
+			PURL:       pkg.PURL(s.OS),
+			Properties: []cycloneDXProperty{{Name: sbomToolName + ":source", Value: "/" + pkg.Source}},
+		}
+
+		if isSPDXExpression(pkg.License) {
+			component.Licenses = []cycloneDXLicense{{Expression: pkg.License}}
+		} else if pkg.License != "" {
+			component.Licenses = []cycloneDXLicense{{License: &cycloneDXLicenseName{Name: pkg.License}}}
+		}
+
+		doc.Components = append(doc.Components, component)
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// random (version 4) uuid
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
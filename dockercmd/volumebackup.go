@@ -0,0 +1,191 @@
+package dockercmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+)
+
+// written next to backup archives, so restores can recreate the volume as it was
+type VolumeBackupMetadata struct {
+	Name      string
+	Driver    string
+	Labels    map[string]string
+	Options   map[string]string
+	CreatedAt string
+}
+
+func VolumeBackupMetadataPath(archivePath string) string {
+	return archivePath + ".meta.json"
+}
+
+func (dc DockerClient) InspectVolume(name string) (*volume.Volume, error) {
+	res, err := dc.cli.VolumeInspect(context.Background(), name)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// returns false (and no error) when the volume does not exist
+func (dc DockerClient) VolumeExists(name string) (bool, error) {
+	_, err := dc.cli.VolumeInspect(context.Background(), name)
+	if client.IsErrNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Writes the volume's content to a gzipped tarball at archivePath, along with a metadata sidecar
+func (dc DockerClient) BackupVolume(name string, archivePath string, progress ProgressFunc) (err error) {
+	vol, err := dc.InspectVolume(name)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := json.MarshalIndent(VolumeBackupMetadata{
+		Name:      vol.Name,
+		Driver:    vol.Driver,
+		Labels:    vol.Labels,
+		Options:   vol.Options,
+		CreatedAt: vol.CreatedAt,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	helper, err := dc.CreateVolumeHelper(name, true)
+	if err != nil {
+		return err
+	}
+	defer dc.RemoveVolumeHelper(helper)
+
+	rc, _, err := dc.cli.CopyFromContainer(context.Background(), helper, VolumeHelperMountPath)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		// do not leave half written archives around
+		if err != nil {
+			os.Remove(archivePath)
+		}
+	}()
+
+	gz := gzip.NewWriter(f)
+	if err := rebaseTar(gz, &progressReader{r: rc, progress: progress}, path.Base(VolumeHelperMountPath)); err != nil {
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(VolumeBackupMetadataPath(archivePath), metadata, 0644)
+}
+
+// Restores an archive created by BackupVolume into the volume. When the volume does not exist it is created using
+// the metadata sidecar (if present). Files in the archive overwrite files already present in the volume.
+func (dc DockerClient) RestoreVolume(archivePath string, name string, progress ProgressFunc) error {
+	exists, err := dc.VolumeExists(name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		opts := volume.CreateOptions{Name: name}
+
+		if data, err := os.ReadFile(VolumeBackupMetadataPath(archivePath)); err == nil {
+			var metadata VolumeBackupMetadata
+			if err := json.Unmarshal(data, &metadata); err != nil {
+				return err
+			}
+
+			opts.Driver = metadata.Driver
+			opts.Labels = metadata.Labels
+			opts.DriverOpts = metadata.Options
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		if _, err := dc.cli.VolumeCreate(context.Background(), opts); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(&progressReader{r: f, progress: progress})
+	if err != nil {
+		return err
+	}
+
+	helper, err := dc.CreateVolumeHelper(name, false)
+	if err != nil {
+		return err
+	}
+	defer dc.RemoveVolumeHelper(helper)
+
+	return dc.cli.CopyToContainer(context.Background(), helper, VolumeHelperMountPath, gz, types.CopyToContainerOptions{
+		CopyUIDGID: true,
+	})
+}
+
+// copies a tarball, stripping the leading `root/` from every entry (and dropping root itself)
+func rebaseTar(w io.Writer, r io.Reader, root string) error {
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name, ok := strings.CutPrefix(strings.TrimPrefix(hdr.Name, "/"), root+"/")
+		if !ok || name == "" {
+			continue
+		}
+		hdr.Name = name
+
+		// hardlinks point to other entries of the same archive
+		if hdr.Typeflag == tar.TypeLink {
+			hdr.Linkname = strings.TrimPrefix(strings.TrimPrefix(hdr.Linkname, "/"), root+"/")
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
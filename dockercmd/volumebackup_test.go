@@ -0,0 +1,28 @@
+package dockercmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRebaseTar(t *testing.T) {
+	archive := makeTar(t, []tarEntry{
+		{name: "data/", isDir: true},
+		{name: "data/config.yml", size: 4},
+		{name: "data/database/", isDir: true},
+	})
+
+	var buf bytes.Buffer
+	if err := rebaseTar(&buf, bytes.NewReader(archive), "data"); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := readLayerFiles(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 || files[0].Path != "config.yml" || files[1].Path != "database" {
+		t.Errorf("unexpected entries after rebase: %#v", files)
+	}
+}
@@ -0,0 +1,159 @@
+package dockercmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// returned by RenameVolume when containers still reference the volume
+type VolumeInUseError struct {
+	Volume     string
+	Containers []string
+}
+
+func (e VolumeInUseError) Error() string {
+	return fmt.Sprintf("volume %s is in use by: %s", e.Volume, strings.Join(e.Containers, ", "))
+}
+
+// Lists containers (running or not) that mount the volume, our own helper containers are left out
+func (dc DockerClient) ContainersUsingVolume(name string) ([]types.Container, error) {
+	containers, err := dc.cli.ContainerList(context.Background(), container.ListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("volume", name)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]types.Container, 0, len(containers))
+	for _, c := range containers {
+		if _, isHelper := c.Labels[volumeHelperLabel]; !isHelper {
+			res = append(res, c)
+		}
+	}
+
+	return res, nil
+}
+
+// Creates dst with the same driver, labels and options as src and copies src's content into it
+func (dc DockerClient) CloneVolume(src string, dst string, progress ProgressFunc) (err error) {
+	vol, err := dc.InspectVolume(src)
+	if err != nil {
+		return err
+	}
+
+	exists, err := dc.VolumeExists(dst)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("volume %s already exists", dst)
+	}
+
+	_, err = dc.cli.VolumeCreate(context.Background(), volume.CreateOptions{
+		Name:       dst,
+		Driver:     vol.Driver,
+		DriverOpts: vol.Options,
+		Labels:     vol.Labels,
+	})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// do not leave half copied volumes around
+		if err != nil {
+			dc.cli.VolumeRemove(context.Background(), dst, true)
+		}
+	}()
+
+	if sharesStorage(vol) {
+		return nil
+	}
+
+	return copyVolumeData(dc, src, dc, dst, progress)
+}
+
+// Clones the volume to newName and removes the original. Docker has no native rename, so containers referencing the
+// volume would keep pointing to the old name, renaming is refused while any container (even a stopped one) uses it.
+func (dc DockerClient) RenameVolume(name string, newName string, progress ProgressFunc) error {
+	users, err := dc.ContainersUsingVolume(name)
+	if err != nil {
+		return err
+	}
+
+	if len(users) > 0 {
+		inUseErr := VolumeInUseError{Volume: name}
+		for _, c := range users {
+			inUseErr.Containers = append(inUseErr.Containers, ContainerName(c))
+		}
+		return inUseErr
+	}
+
+	if err := dc.CloneVolume(name, newName, progress); err != nil {
+		return err
+	}
+
+	if err := dc.cli.VolumeRemove(context.Background(), name, false); err != nil {
+		return fmt.Errorf("volume was copied to %s, but removing %s failed: %w", newName, name, err)
+	}
+
+	return nil
+}
+
+// streams src's content straight into dst, both are accessed through helper containers. The volumes can live on
+// different daemons.
+func copyVolumeData(from DockerClient, src string, to DockerClient, dst string, progress ProgressFunc) error {
+	srcHelper, err := from.CreateVolumeHelper(src, true)
+	if err != nil {
+		return err
+	}
+	defer from.RemoveVolumeHelper(srcHelper)
+
+	dstHelper, err := to.CreateVolumeHelper(dst, false)
+	if err != nil {
+		return err
+	}
+	defer to.RemoveVolumeHelper(dstHelper)
+
+	rc, _, err := from.cli.CopyFromContainer(context.Background(), srcHelper, VolumeHelperMountPath)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// entries are prefixed with `data/`, they have to land directly in the destination's mount path
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(rebaseTar(pw, &progressReader{r: rc, progress: progress}, path.Base(VolumeHelperMountPath)))
+	}()
+
+	err = to.cli.CopyToContainer(context.Background(), dstHelper, VolumeHelperMountPath, pr, types.CopyToContainerOptions{
+		CopyUIDGID: true,
+	})
+	// unblocks the writer if the daemon bailed out early
+	pr.Close()
+	return err
+}
+
+// local volumes with a `device` option (bind mounts, nfs, ...) keep their data outside of docker, a volume created
+// with the same options already sees the same files, copying would write the data onto itself
+func sharesStorage(vol *volume.Volume) bool {
+	return vol.Driver == "local" && vol.Options["device"] != ""
+}
+
+// first name of the container without the leading slash, the short id for containers without names
+func ContainerName(c types.Container) string {
+	if len(c.Names) == 0 {
+		return c.ID[:min(12, len(c.ID))]
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
@@ -2,10 +2,25 @@ package dockercmd
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"strings"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+)
+
+const (
+	// image used for short lived containers that give us access to volume contents
+	VolumeHelperImage = "busybox:latest"
+	// path the volume is mounted at inside helper containers
+	VolumeHelperMountPath = "/data"
+	volumeHelperLabel     = "gomanagedocker.volume-helper"
 )
 
 func (dc DockerClient) ListVolumes() ([]*volume.Volume, error) {
@@ -26,6 +41,189 @@ func (dc DockerClient) PruneVolumes() (*types.VolumesPruneReport, error) {
 	return &res, nil
 }
 
+func (dc DockerClient) CreateVolume(opts volume.CreateOptions) (*volume.Volume, error) {
+	res, err := dc.cli.VolumeCreate(context.Background(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// names of the volume drivers installed on the daemon, `local` is always there
+func (dc DockerClient) VolumeDrivers() ([]string, error) {
+	info, err := dc.cli.Info(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return info.Plugins.Volume, nil
+}
+
+// Parses whitespace separated `key=value` pairs, as used for driver options and labels. Only the first `=` splits,
+// so values like `o=addr=10.0.0.1,rw` are kept intact.
+func ParseKeyValuePairs(s string) (map[string]string, error) {
+	res := make(map[string]string)
+
+	for _, pair := range strings.Fields(s) {
+		key, value, found := strings.Cut(pair, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid key=value pair: %q", pair)
+		}
+		res[key] = value
+	}
+
+	return res, nil
+}
+
 func (dc DockerClient) DeleteVolume(id string, force bool) error {
 	return dc.cli.VolumeRemove(context.Background(), id, force)
 }
+
+// a container mounting a volume
+type VolumeMount struct {
+	ContainerID   string
+	ContainerName string
+	// container state, eg: running, exited
+	State       string
+	Destination string
+	RW          bool
+}
+
+// Maps volume names to the containers (running or not) mounting them. Volumes missing from the map are orphaned.
+func (dc DockerClient) VolumeMounts() (map[string][]VolumeMount, error) {
+	containers, err := dc.cli.ContainerList(context.Background(), container.ListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return indexVolumeMounts(containers), nil
+}
+
+func indexVolumeMounts(containers []types.Container) map[string][]VolumeMount {
+	res := make(map[string][]VolumeMount)
+
+	for _, c := range containers {
+		// our own helpers would make every browsed volume look used
+		if _, isHelper := c.Labels[volumeHelperLabel]; isHelper {
+			continue
+		}
+
+		for _, m := range c.Mounts {
+			if m.Type != mount.TypeVolume || m.Name == "" {
+				continue
+			}
+
+			res[m.Name] = append(res[m.Name], VolumeMount{
+				ContainerID:   c.ID,
+				ContainerName: ContainerName(c),
+				State:         c.State,
+				Destination:   m.Destination,
+				RW:            m.RW,
+			})
+		}
+	}
+
+	return res
+}
+
+// Force removes every container using the volume (running ones are stopped first), then removes the volume
+func (dc DockerClient) DeleteVolumeAndUsers(name string) error {
+	users, err := dc.ContainersUsingVolume(name)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range users {
+		if c.State == "running" {
+			if err := dc.cli.ContainerStop(context.Background(), c.ID, container.StopOptions{}); err != nil {
+				return err
+			}
+		}
+
+		if err := dc.cli.ContainerRemove(context.Background(), c.ID, container.RemoveOptions{Force: true}); err != nil {
+			return err
+		}
+	}
+
+	return dc.DeleteVolume(name, false)
+}
+
+// Creates (but does not start) a container with the volume mounted at VolumeHelperMountPath, the engine lets us
+// copy files from/to stopped containers so this is enough to access the volume's content.
+func (dc DockerClient) CreateVolumeHelper(volumeName string, readOnly bool) (string, error) {
+	return dc.createVolumeHelper(volumeName, readOnly, []string{"true"})
+}
+
+func (dc DockerClient) createVolumeHelper(volumeName string, readOnly bool, cmd []string) (string, error) {
+	if err := dc.ensureImage(VolumeHelperImage); err != nil {
+		return "", err
+	}
+
+	res, err := dc.cli.ContainerCreate(context.Background(), &container.Config{
+		Image:  VolumeHelperImage,
+		Cmd:    cmd,
+		Labels: map[string]string{volumeHelperLabel: volumeName},
+	}, &container.HostConfig{
+		Mounts: []mount.Mount{{
+			Type:     mount.TypeVolume,
+			Source:   volumeName,
+			Target:   VolumeHelperMountPath,
+			ReadOnly: readOnly,
+		}},
+	}, nil, nil, "")
+
+	if err != nil {
+		return "", err
+	}
+
+	return res.ID, nil
+}
+
+func (dc DockerClient) RemoveVolumeHelper(id string) error {
+	return dc.cli.ContainerRemove(context.Background(), id, container.RemoveOptions{Force: true})
+}
+
+// Deletes the volume's content, the volume itself is kept so containers using it do not have to be recreated
+func (dc DockerClient) EmptyVolume(name string) error {
+	ctx := context.Background()
+
+	// the globs match hidden files too, globs without a match stay as they are and rm -f ignores them
+	script := fmt.Sprintf("rm -rf %[1]s/* %[1]s/.[!.]* %[1]s/..?*", VolumeHelperMountPath)
+	helper, err := dc.createVolumeHelper(name, false, []string{"sh", "-c", script})
+	if err != nil {
+		return err
+	}
+	defer dc.RemoveVolumeHelper(helper)
+
+	waitC, errC := dc.cli.ContainerWait(ctx, helper, container.WaitConditionNextExit)
+	if err := dc.cli.ContainerStart(ctx, helper, container.StartOptions{}); err != nil {
+		return err
+	}
+
+	select {
+	case res := <-waitC:
+		if res.StatusCode != 0 {
+			return fmt.Errorf("could not empty volume %s, rm exited with %d", name, res.StatusCode)
+		}
+		return nil
+	case err := <-errC:
+		return err
+	}
+}
+
+// pulls the image if it is not present locally
+func (dc DockerClient) ensureImage(ref string) error {
+	_, _, err := dc.cli.ImageInspectWithRaw(context.Background(), ref)
+	if err == nil || !client.IsErrNotFound(err) {
+		return err
+	}
+
+	rc, err := dc.cli.ImagePull(context.Background(), ref, image.PullOptions{})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// the pull only completes once the progress stream is drained
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
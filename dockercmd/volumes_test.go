@@ -1,7 +1,13 @@
 package dockercmd
 
 import (
+	"maps"
+	"reflect"
 	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
 )
 
 func TestListVolumes(t *testing.T) {
@@ -15,3 +21,93 @@ func TestListVolumes(t *testing.T) {
 	}
 	// assert.Equal(t, len(containersList), 1, "Not all containers detected")
 }
+
+func TestSharesStorage(t *testing.T) {
+	tests := []struct {
+		name string
+		vol  volume.Volume
+		want bool
+	}{
+		{"plain local volume", volume.Volume{Driver: "local"}, false},
+		{"nfs volume", volume.Volume{Driver: "local", Options: map[string]string{"type": "nfs", "o": "addr=10.0.0.1", "device": ":/export"}}, true},
+		{"bind volume", volume.Volume{Driver: "local", Options: map[string]string{"o": "bind", "device": "/srv/data"}}, true},
+		{"plugin volume", volume.Volume{Driver: "rexray", Options: map[string]string{"device": "/dev/xvdf"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sharesStorage(&tt.vol); got != tt.want {
+				t.Errorf("sharesStorage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeyValuePairs(t *testing.T) {
+	got, err := ParseKeyValuePairs("type=nfs  o=addr=10.0.0.1,rw,nfsvers=4 device=:/export/data empty=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"type":   "nfs",
+		"o":      "addr=10.0.0.1,rw,nfsvers=4",
+		"device": ":/export/data",
+		"empty":  "",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if got, err := ParseKeyValuePairs(""); err != nil || len(got) != 0 {
+		t.Errorf("empty input: got %v, %v", got, err)
+	}
+
+	for _, invalid := range []string{"novalue", "=value"} {
+		if _, err := ParseKeyValuePairs(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestIndexVolumeMounts(t *testing.T) {
+	containers := []types.Container{
+		{
+			ID:    "c1",
+			Names: []string{"/web"},
+			State: "running",
+			Mounts: []types.MountPoint{
+				{Type: mount.TypeVolume, Name: "data", Destination: "/var/lib/data", RW: true},
+				{Type: mount.TypeBind, Source: "/etc/hosts", Destination: "/etc/hosts"},
+				{Type: mount.TypeVolume, Name: "config", Destination: "/config", RW: false},
+			},
+		},
+		{
+			ID:     "c2",
+			Names:  []string{"/backup"},
+			State:  "exited",
+			Mounts: []types.MountPoint{{Type: mount.TypeVolume, Name: "data", Destination: "/backup", RW: false}},
+		},
+		{
+			ID:     "helper",
+			Labels: map[string]string{volumeHelperLabel: "data"},
+			Mounts: []types.MountPoint{{Type: mount.TypeVolume, Name: "data", Destination: VolumeHelperMountPath}},
+		},
+	}
+
+	got := indexVolumeMounts(containers)
+
+	want := map[string][]VolumeMount{
+		"data": {
+			{ContainerID: "c1", ContainerName: "web", State: "running", Destination: "/var/lib/data", RW: true},
+			{ContainerID: "c2", ContainerName: "backup", State: "exited", Destination: "/backup", RW: false},
+		},
+		"config": {
+			{ContainerID: "c1", ContainerName: "web", State: "running", Destination: "/config", RW: false},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
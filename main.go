@@ -22,7 +22,7 @@ func main() {
 		log.SetOutput(io.Discard)
 	}
 
-	tabs := []string{"Images", "Containers", "Volumes"}
+	tabs := []string{"Images", "Containers", "Volumes", "Build Cache", "Networks"}
 	m := tui.NewModel(tabs)
 	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
 		fmt.Println("Error running program:", err)
@@ -0,0 +1,156 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// a line of output, or the final result once the command exits
+type commandOutputMsg struct {
+	line string
+	done bool
+	err  error
+	// channel the message was read from, so output of a closed view can be told apart
+	output chan commandOutputMsg
+}
+
+// Streams the output of a long running command (eg: docker compose) line by line. Like TransferModel, closing the
+// view does not stop the command, Model keeps draining its output and reports a failure as a dialog.
+type CommandOutputModel struct {
+	title    string
+	output   chan commandOutputMsg
+	lines    []string
+	done     bool
+	err      error
+	viewport viewport.Model
+	help     help.Model
+}
+
+func NewCommandOutputModel(title string, width int, height int, fn func(output io.Writer) error) CommandOutputModel {
+	vp := viewport.New(width-4, height-8)
+	vp.Style = viewPaneStyle
+
+	return CommandOutputModel{
+		title:    title,
+		output:   startCommand(fn),
+		viewport: vp,
+		help:     help.New(),
+	}
+}
+
+func (m CommandOutputModel) Init() tea.Cmd {
+	return waitForCommandOutput(m.output)
+}
+
+func (m CommandOutputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case commandOutputMsg:
+		if msg.output != m.output {
+			return m, nil
+		}
+
+		if msg.done {
+			m.done = true
+			m.err = msg.err
+			return m, nil
+		}
+
+		// follow the output, unless the user scrolled up
+		follow := m.viewport.AtBottom()
+		m.lines = append(m.lines, msg.line)
+		m.viewport.SetContent(strings.Join(m.lines, "\n"))
+		if follow {
+			m.viewport.GotoBottom()
+		}
+
+		return m, waitForCommandOutput(m.output)
+
+	case tea.KeyMsg:
+		if key.Matches(msg, CommandOutputKeymap.Back) {
+			return m, closeView
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m CommandOutputModel) View() string {
+	var status string
+	switch {
+	case !m.done:
+		status = "running, esc continues in the background"
+	case m.err != nil:
+		status = fileRemovedStyle.Render("failed: " + m.err.Error())
+	default:
+		status = fileAddedStyle.Render("done")
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, viewTitleStyle.Render(m.title), m.viewport.View(), status, m.help.View(CommandOutputKeymap))
+}
+
+// runs fn on a seperate goroutine, everything it writes is delivered line by line
+func startCommand(fn func(output io.Writer) error) chan commandOutputMsg {
+	output := make(chan commandOutputMsg, 100)
+	pr, pw := io.Pipe()
+
+	go func() {
+		pw.CloseWithError(fn(pw))
+	}()
+
+	go func() {
+		scanner := bufio.NewScanner(pr)
+		scanner.Split(scanOutputLines)
+		for scanner.Scan() {
+			output <- commandOutputMsg{line: scanner.Text(), output: output}
+		}
+
+		err := scanner.Err()
+		// unblocks fn if scanning failed (eg: a line longer than the scanner's buffer)
+		pr.CloseWithError(err)
+		output <- commandOutputMsg{done: true, err: err, output: output}
+	}()
+
+	return output
+}
+
+func waitForCommandOutput(output chan commandOutputMsg) tea.Cmd {
+	return func() tea.Msg {
+		return <-output
+	}
+}
+
+// like bufio.ScanLines, but progress updates that only use `\r` are split as well
+func scanOutputLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		// might be the first half of `\r\n`
+		if data[i] == '\r' && i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+
+		advance = i + 1
+		if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+			advance++
+		}
+		return advance, data[:i], nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
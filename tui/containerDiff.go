@@ -0,0 +1,211 @@
+package tui
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// files bigger than this are only partially previewed
+const filePreviewLimit = 1 << 20
+
+type containerDiffLoaded struct {
+	changes []dockercmd.LayerFile
+	err     error
+}
+
+type containerFileLoaded struct {
+	path      string
+	content   []byte
+	truncated bool
+	err       error
+}
+
+// shows what the container changed relative to its image
+type ContainerDiffModel struct {
+	dockerClient  dockercmd.DockerClient
+	containerId   string
+	containerName string
+	changes       []dockercmd.LayerFile
+	counts        map[dockercmd.FileChangeKind]int
+	// FileUnchanged means no filter
+	filter      dockercmd.FileChangeKind
+	err         error
+	tree        fileTree
+	showPreview bool
+	previewPath string
+	preview     viewport.Model
+	help        help.Model
+	width       int
+	height      int
+}
+
+func NewContainerDiffModel(client dockercmd.DockerClient, containerId string, containerName string, width int, height int) ContainerDiffModel {
+	m := ContainerDiffModel{
+		dockerClient:  client,
+		containerId:   containerId,
+		containerName: containerName,
+		preview:       viewport.New(0, 0),
+		help:          help.New(),
+	}
+	m.setSize(width, height)
+	return m
+}
+
+func (m ContainerDiffModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		changes, err := m.dockerClient.ContainerDiff(m.containerId)
+		return containerDiffLoaded{changes: changes, err: err}
+	}
+}
+
+func (m ContainerDiffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case containerDiffLoaded:
+		m.err = msg.err
+		m.changes = msg.changes
+		m.counts = make(map[dockercmd.FileChangeKind]int)
+		for _, change := range m.changes {
+			m.counts[change.Kind] += 1
+		}
+		m.rebuildTree()
+
+	case containerFileLoaded:
+		m.previewPath = msg.path
+		m.showPreview = true
+		m.preview.GotoTop()
+		m.preview.SetContent(previewContent(msg.content, msg.truncated, msg.err))
+
+	case tea.WindowSizeMsg:
+		m.setSize(msg.Width, msg.Height)
+
+	case tea.KeyMsg:
+		if m.showPreview {
+			if key.Matches(msg, ContainerDiffKeymap.Back) {
+				m.showPreview = false
+				return m, nil
+			}
+
+			var cmd tea.Cmd
+			m.preview, cmd = m.preview.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case key.Matches(msg, ContainerDiffKeymap.Back):
+			return m, closeView
+		case key.Matches(msg, ContainerDiffKeymap.CycleFilter):
+			m.filter = (m.filter + 1) % (dockercmd.FileRemoved + 1)
+			m.rebuildTree()
+			return m, nil
+		case key.Matches(msg, ContainerDiffKeymap.Open):
+			node := m.tree.selected()
+			if node != nil && !node.isDir && node.kind != dockercmd.FileRemoved {
+				return m, m.openFile("/" + node.path)
+			}
+		}
+
+		var cmd tea.Cmd
+		m.tree, cmd = m.tree.Update(msg)
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+func (m ContainerDiffModel) View() string {
+	title := viewTitleStyle.Render("Changes: " + m.containerName)
+
+	if m.err != nil {
+		return lipgloss.JoinVertical(lipgloss.Left, title, "Error: "+m.err.Error(), m.help.View(ContainerDiffKeymap))
+	}
+
+	if m.counts == nil {
+		return lipgloss.JoinVertical(lipgloss.Left, title, "Loading...")
+	}
+
+	if m.showPreview {
+		body := viewActivePaneStyle.Width(m.width - 4).Height(m.paneHeight()).Render(m.preview.View())
+		return lipgloss.JoinVertical(lipgloss.Left, title, m.previewPath, body, m.help.View(ContainerDiffKeymap))
+	}
+
+	filter := "all"
+	switch m.filter {
+	case dockercmd.FileAdded:
+		filter = "added"
+	case dockercmd.FileModified:
+		filter = "changed"
+	case dockercmd.FileRemoved:
+		filter = "deleted"
+	}
+
+	summary := fmt.Sprintf("%s    %s    %s    Showing: %s",
+		fileAddedStyle.Render(fmt.Sprintf("Added: %d", m.counts[dockercmd.FileAdded])),
+		fileModifiedStyle.Render(fmt.Sprintf("Changed: %d", m.counts[dockercmd.FileModified])),
+		fileRemovedStyle.Render(fmt.Sprintf("Deleted: %d", m.counts[dockercmd.FileRemoved])),
+		filter,
+	)
+
+	body := viewActivePaneStyle.Width(m.width - 4).Height(m.paneHeight()).Render(m.tree.View())
+	return lipgloss.JoinVertical(lipgloss.Left, title, summary, body, m.help.View(ContainerDiffKeymap))
+}
+
+// helpers
+
+func (m *ContainerDiffModel) setSize(width int, height int) {
+	m.width = width
+	m.height = height
+	m.help.Width = width
+	m.tree.setSize(width-4, m.paneHeight())
+	m.preview.Width = width - 4
+	m.preview.Height = m.paneHeight()
+}
+
+func (m ContainerDiffModel) paneHeight() int {
+	return max(m.height-10, 5)
+}
+
+func (m *ContainerDiffModel) rebuildTree() {
+	entries := m.changes
+	if m.filter != dockercmd.FileUnchanged {
+		entries = make([]dockercmd.LayerFile, 0, len(m.changes))
+		for _, change := range m.changes {
+			if change.Kind == m.filter {
+				entries = append(entries, change)
+			}
+		}
+	}
+
+	m.tree = newFileTree(entries, m.width-4, m.paneHeight())
+	m.tree.hideSizes = true
+}
+
+func (m ContainerDiffModel) openFile(path string) tea.Cmd {
+	return func() tea.Msg {
+		content, truncated, err := m.dockerClient.ReadContainerFile(m.containerId, path, filePreviewLimit)
+		return containerFileLoaded{path: path, content: content, truncated: truncated, err: err}
+	}
+}
+
+func previewContent(content []byte, truncated bool, err error) string {
+	if err != nil {
+		return "Error: " + err.Error()
+	}
+
+	// same heuristic git uses to detect binary files
+	if bytes.IndexByte(content[:min(len(content), 8000)], 0) != -1 {
+		return fmt.Sprintf("Binary file (%s)", humanSize(int64(len(content))))
+	}
+
+	res := string(content)
+	if truncated {
+		res += "\n" + mutedStyle.Render(fmt.Sprintf("... truncated, only the first %s are shown", humanSize(filePreviewLimit)))
+	}
+	return res
+}
@@ -0,0 +1,526 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type filesInputMode int
+
+const (
+	filesInputNone filesInputMode = iota
+	filesInputDownload
+	filesInputUpload
+)
+
+type containerFileCheckedOut struct {
+	file *dockercmd.CheckedOutFile
+	err  error
+}
+
+type editorClosed struct {
+	file *dockercmd.CheckedOutFile
+	err  error
+}
+
+type containerFileWrittenBack struct {
+	file    *dockercmd.CheckedOutFile
+	written bool
+	err     error
+}
+
+type volumeHelperReady struct {
+	id  string
+	err error
+}
+
+type containerDirLoaded struct {
+	dir   string
+	files []dockercmd.ContainerFile
+	err   error
+}
+
+// browses the filesystem of a container, files can be previewed and copied in and out
+type ContainerFilesModel struct {
+	dockerClient  dockercmd.DockerClient
+	containerId   string
+	containerName string
+	cwd           string
+	files         []dockercmd.ContainerFile
+	cursor        int
+	offset        int
+	loading       bool
+	status        string
+	showPreview   bool
+	previewPath   string
+	preview       viewport.Model
+	inputMode     filesInputMode
+	input         textinput.Model
+	transfer      chan transferUpdate
+	lastTransfer  transferUpdate
+	progressBar   progress.Model
+	// set when the edited file changed inside the container, waits for the user to confirm the overwrite
+	pendingOverwrite *dockercmd.CheckedOutFile
+	// set when browsing a volume, the volume is accessed through a helper container that lives as long as the view
+	volumeName string
+	// navigation never goes above root
+	root string
+	// copy of ContainerFilesKeymap, so actions can be disabled per instance
+	keys   containerFilesKeymap
+	help   help.Model
+	width  int
+	height int
+}
+
+func NewContainerFilesModel(client dockercmd.DockerClient, containerId string, containerName string, width int, height int) ContainerFilesModel {
+	m := ContainerFilesModel{
+		dockerClient:  client,
+		containerId:   containerId,
+		containerName: containerName,
+		cwd:           "/",
+		root:          "/",
+		loading:       true,
+		keys:          ContainerFilesKeymap,
+		preview:       viewport.New(0, 0),
+		input:         textinput.New(),
+		progressBar:   progress.New(progress.WithDefaultGradient()),
+		help:          help.New(),
+	}
+	m.setSize(width, height)
+	return m
+}
+
+// browses a volume read only, through a helper container that is removed when the view is closed
+func NewVolumeFilesModel(client dockercmd.DockerClient, volumeName string, width int, height int) ContainerFilesModel {
+	m := NewContainerFilesModel(client, "", volumeName, width, height)
+	m.volumeName = volumeName
+	m.root = dockercmd.VolumeHelperMountPath
+	m.cwd = dockercmd.VolumeHelperMountPath
+
+	m.keys.Edit.SetEnabled(false)
+	m.keys.Upload.SetEnabled(false)
+	return m
+}
+
+func (m ContainerFilesModel) Init() tea.Cmd {
+	if m.volumeName != "" {
+		return func() tea.Msg {
+			id, err := m.dockerClient.CreateVolumeHelper(m.volumeName, true)
+			return volumeHelperReady{id: id, err: err}
+		}
+	}
+
+	return m.loadDir(m.cwd)
+}
+
+func (m ContainerFilesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case volumeHelperReady:
+		if msg.err != nil {
+			m.loading = false
+			m.status = "Could not create helper container: " + msg.err.Error()
+			return m, nil
+		}
+
+		m.containerId = msg.id
+		return m, m.loadDir(m.cwd)
+
+	case containerDirLoaded:
+		m.loading = false
+		if msg.err != nil {
+			m.status = "Error: " + msg.err.Error()
+			return m, nil
+		}
+
+		m.cwd = msg.dir
+		m.files = msg.files
+		m.cursor = 0
+		m.offset = 0
+		return m, nil
+
+	case containerFileLoaded:
+		m.previewPath = msg.path
+		m.showPreview = true
+		m.preview.GotoTop()
+		m.preview.SetContent(previewContent(msg.content, msg.truncated, msg.err))
+		return m, nil
+
+	case transferUpdate:
+		m.lastTransfer = msg
+		if !msg.done {
+			return m, waitForTransfer(m.transfer)
+		}
+
+		m.transfer = nil
+		if msg.err != nil {
+			m.status = "Transfer failed: " + msg.err.Error()
+			return m, nil
+		}
+
+		m.status = fmt.Sprintf("Transfer complete (%s)", humanSize(msg.transferred))
+		// uploads change the current directory
+		return m, m.loadDir(m.cwd)
+
+	case containerFileCheckedOut:
+		if msg.err != nil {
+			m.status = "Could not open file: " + msg.err.Error()
+			return m, nil
+		}
+
+		file := msg.file
+		return m, tea.ExecProcess(editorCommand(file.LocalPath), func(err error) tea.Msg {
+			return editorClosed{file: file, err: err}
+		})
+
+	case editorClosed:
+		if msg.err != nil {
+			msg.file.Discard()
+			m.status = "Editor failed: " + msg.err.Error()
+			return m, nil
+		}
+		return m, m.writeBack(msg.file, false)
+
+	case containerFileWrittenBack:
+		switch {
+		case errors.Is(msg.err, dockercmd.ErrContainerFileChanged):
+			m.pendingOverwrite = msg.file
+			m.status = msg.file.ContainerPath + " changed inside the container while you were editing, overwrite it? (y/n)"
+			return m, nil
+		case msg.err != nil:
+			m.status = "Could not write back file: " + msg.err.Error() + ", your copy is kept at " + msg.file.LocalPath
+			return m, nil
+		case msg.written:
+			m.status = "Saved " + msg.file.ContainerPath
+		default:
+			m.status = "No changes to " + msg.file.ContainerPath
+		}
+
+		msg.file.Discard()
+		return m, m.loadDir(m.cwd)
+
+	case tea.WindowSizeMsg:
+		m.setSize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.inputMode != filesInputNone {
+			return m.updateInput(msg)
+		}
+
+		if file := m.pendingOverwrite; file != nil {
+			switch {
+			case key.Matches(msg, m.keys.ConfirmOverwrite):
+				m.pendingOverwrite = nil
+				return m, m.writeBack(file, true)
+			case key.Matches(msg, m.keys.CancelOverwrite):
+				m.pendingOverwrite = nil
+				m.status = "Not saved, your copy is kept at " + file.LocalPath
+			}
+			return m, nil
+		}
+
+		if m.showPreview {
+			if key.Matches(msg, m.keys.Back) {
+				m.showPreview = false
+				return m, nil
+			}
+
+			var cmd tea.Cmd
+			m.preview, cmd = m.preview.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Back):
+			return m, m.close()
+		case m.loading:
+			return m, nil
+		case key.Matches(msg, NavKeymap.NextItem):
+			m.moveCursor(1)
+		case key.Matches(msg, NavKeymap.PrevItem):
+			m.moveCursor(-1)
+		case key.Matches(msg, m.keys.Parent):
+			if m.cwd != m.root {
+				m.loading = true
+				return m, m.loadDir(path.Dir(m.cwd))
+			}
+		case key.Matches(msg, m.keys.Open):
+			file, ok := m.selected()
+			if !ok {
+				return m, nil
+			}
+
+			if file.IsDir() {
+				m.loading = true
+				return m, m.loadDir(file.Path)
+			}
+
+			return m, m.openFile(file.Path)
+
+		case key.Matches(msg, m.keys.Edit):
+			file, ok := m.selected()
+			if ok && file.Mode.IsRegular() {
+				m.status = "Opening " + file.Path + " in editor..."
+				return m, m.checkoutFile(file.Path)
+			}
+
+		case key.Matches(msg, m.keys.Download):
+			if _, ok := m.selected(); ok && m.transfer == nil {
+				return m, m.startInput(filesInputDownload, "Download to local directory: ", ".")
+			}
+		case key.Matches(msg, m.keys.Upload):
+			if m.transfer == nil {
+				return m, m.startInput(filesInputUpload, "Upload local file or directory: ", "")
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m ContainerFilesModel) View() string {
+	title := viewTitleStyle.Render("Files: " + m.containerName)
+	if m.volumeName != "" {
+		title = viewTitleStyle.Render("Volume: " + m.volumeName)
+	}
+
+	var body string
+	if m.showPreview {
+		body = lipgloss.JoinVertical(lipgloss.Left, m.previewPath, viewActivePaneStyle.Width(m.width-4).Height(m.paneHeight()).Render(m.preview.View()))
+	} else {
+		body = lipgloss.JoinVertical(lipgloss.Left, m.displayPath(m.cwd), viewActivePaneStyle.Width(m.width-4).Height(m.paneHeight()).Render(m.filesView()))
+	}
+
+	footer := mutedStyle.Render(m.status)
+	if m.inputMode != filesInputNone {
+		footer = m.input.View()
+	} else if m.transfer != nil {
+		footer = renderTransfer(m.progressBar, m.lastTransfer)
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, body, footer, m.help.View(m.keys))
+}
+
+// helpers
+
+func (m *ContainerFilesModel) setSize(width int, height int) {
+	m.width = width
+	m.height = height
+	m.help.Width = width
+	m.preview.Width = width - 4
+	m.preview.Height = m.paneHeight()
+	m.progressBar.Width = max(width/3, 10)
+	m.input.Width = max(width-40, 10)
+}
+
+func (m ContainerFilesModel) paneHeight() int {
+	return max(m.height-10, 5)
+}
+
+// closes the view, removing the helper container first when browsing a volume
+func (m ContainerFilesModel) close() tea.Cmd {
+	if m.volumeName == "" {
+		return closeView
+	}
+
+	// the helper is still being created, closing now would leak it
+	if m.containerId == "" && m.loading {
+		return nil
+	}
+
+	return func() tea.Msg {
+		if m.containerId != "" {
+			if err := m.dockerClient.RemoveVolumeHelper(m.containerId); err != nil {
+				log.Println("could not remove volume helper container: ", err)
+			}
+		}
+		return closeViewMsg{}
+	}
+}
+
+// paths inside volumes are shown relative to the volume
+func (m ContainerFilesModel) displayPath(p string) string {
+	if m.volumeName == "" {
+		return p
+	}
+	return path.Join("/", strings.TrimPrefix(p, m.root))
+}
+
+func (m ContainerFilesModel) selected() (dockercmd.ContainerFile, bool) {
+	if m.cursor >= len(m.files) {
+		return dockercmd.ContainerFile{}, false
+	}
+	return m.files[m.cursor], true
+}
+
+func (m *ContainerFilesModel) moveCursor(delta int) {
+	m.cursor = max(min(m.cursor+delta, len(m.files)-1), 0)
+
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+m.paneHeight() {
+		m.offset = m.cursor - m.paneHeight() + 1
+	}
+}
+
+func (m ContainerFilesModel) loadDir(dir string) tea.Cmd {
+	return func() tea.Msg {
+		files, err := m.dockerClient.ListContainerDir(m.containerId, dir)
+		return containerDirLoaded{dir: dir, files: files, err: err}
+	}
+}
+
+func (m ContainerFilesModel) openFile(path string) tea.Cmd {
+	return func() tea.Msg {
+		content, truncated, err := m.dockerClient.ReadContainerFile(m.containerId, path, filePreviewLimit)
+		return containerFileLoaded{path: path, content: content, truncated: truncated, err: err}
+	}
+}
+
+func (m ContainerFilesModel) checkoutFile(path string) tea.Cmd {
+	return func() tea.Msg {
+		file, err := m.dockerClient.CheckoutContainerFile(m.containerId, path)
+		return containerFileCheckedOut{file: file, err: err}
+	}
+}
+
+func (m ContainerFilesModel) writeBack(file *dockercmd.CheckedOutFile, force bool) tea.Cmd {
+	return func() tea.Msg {
+		written, err := m.dockerClient.WriteBackContainerFile(m.containerId, file, force)
+		return containerFileWrittenBack{file: file, written: written, err: err}
+	}
+}
+
+// uses $EDITOR, which may contain arguments (eg: `code --wait`), and falls back to vi
+func editorCommand(path string) *exec.Cmd {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	return exec.Command(editor[0], append(editor[1:], path)...)
+}
+
+func (m *ContainerFilesModel) startInput(mode filesInputMode, prompt string, value string) tea.Cmd {
+	m.inputMode = mode
+	m.input.Prompt = prompt
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+func (m ContainerFilesModel) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Back):
+		m.inputMode = filesInputNone
+		m.input.Blur()
+		return m, nil
+
+	case key.Matches(msg, NavKeymap.Enter):
+		mode := m.inputMode
+		value := strings.TrimSpace(m.input.Value())
+		m.inputMode = filesInputNone
+		m.input.Blur()
+
+		if value == "" {
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		switch mode {
+		case filesInputDownload:
+			file, _ := m.selected()
+			m.status = fmt.Sprintf("Downloading %s to %s", file.Path, value)
+			m.transfer, cmd = startTransfer(file.Size, func(progress dockercmd.ProgressFunc) error {
+				return m.dockerClient.CopyFromContainer(m.containerId, file.Path, value, progress)
+			})
+
+		case filesInputUpload:
+			m.status = fmt.Sprintf("Uploading %s to %s", value, m.displayPath(m.cwd))
+			dst := m.cwd
+			m.transfer, cmd = startTransfer(localSize(value), func(progress dockercmd.ProgressFunc) error {
+				return m.dockerClient.CopyToContainer(m.containerId, value, dst, progress)
+			})
+		}
+
+		m.lastTransfer = transferUpdate{}
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m ContainerFilesModel) filesView() string {
+	if m.loading {
+		return "Loading..."
+	}
+
+	if len(m.files) == 0 {
+		return "Empty directory"
+	}
+
+	var res strings.Builder
+	end := min(m.offset+m.paneHeight(), len(m.files))
+
+	for i := m.offset; i < end; i++ {
+		file := m.files[i]
+
+		name := file.Name
+		if file.IsDir() {
+			name += "/"
+		} else if file.LinkTarget != "" {
+			name += " -> " + file.LinkTarget
+		}
+
+		// directory sizes are only known when listing falls back to the tarball
+		size := "-"
+		if file.Size >= 0 {
+			size = humanSize(file.Size)
+		}
+
+		line := fmt.Sprintf("%s  %9s  %s  %s", file.Mode.String(), size, file.ModTime.Format("2006-01-02 15:04"), name)
+		if i == m.cursor {
+			line = treeCursorStyle.Render(line)
+		}
+
+		res.WriteString(line)
+		if i < end-1 {
+			res.WriteString("\n")
+		}
+	}
+
+	return res.String()
+}
+
+// total size of a local file or directory, used as the upload progress total
+func localSize(p string) int64 {
+	var total int64
+	filepath.WalkDir(p, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
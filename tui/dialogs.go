@@ -1,6 +1,11 @@
 package tui
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
 	teadialog "github.com/ajayd-san/teaDialog"
 )
 
@@ -11,6 +16,30 @@ const (
 	dialogPruneImages
 	dialogPruneVolumes
 	dialogRemoveVolumes
+	dialogRemoveBuildCache
+	dialogPruneBuildCache
+	dialogBackupVolume
+	dialogRestoreVolume
+	dialogConfirmRestoreVolume
+	dialogCloneVolume
+	dialogRenameVolume
+	dialogCreateVolume
+	dialogRemoveVolumeInUse
+	dialogJumpTo
+	dialogRemoveComposeProject
+	dialogCompose
+	dialogRecreateContainer
+	dialogUpdateImages
+	dialogRegistryLogin
+	dialogRegistryLogout
+	dialogPushImage
+	dialogBrowseRegistry
+	dialogSaveImages
+	dialogLoadImages
+	dialogImportImage
+	dialogExportContainer
+	dialogCommitContainer
+	dialogMigrateContainer
 )
 
 func getRemoveContainerDialog(storage map[string]string) teadialog.Dialog {
@@ -64,3 +93,266 @@ func getPruneVolumesDialog(storage map[string]string) teadialog.Dialog {
 
 	return teadialog.InitDialogue("Prune Containers: ", prompts, dialogPruneVolumes, storage)
 }
+
+func getRemoveBuildCacheDialog(storage map[string]string) teadialog.Dialog {
+	prompts := []teadialog.Prompt{
+		teadialog.MakeOptionPrompt("confirm", "This will remove the selected cache record, are you sure?", []string{"Yes", "No"}),
+	}
+
+	return teadialog.InitDialogue("Remove Build Cache Record:", prompts, dialogRemoveBuildCache, storage)
+}
+
+// keep storage choices offered in the prune build cache dialog, in bytes
+func getPruneBuildCacheDialog(storage map[string]string) teadialog.Dialog {
+	prompts := []teadialog.Prompt{
+		teadialog.MakeTogglePrompt("all", "Remove all cache records, not just unused ones"),
+		teadialog.MakeOptionPrompt("keepStorage", "Keep storage:", []string{"0", "1GB", "5GB", "10GB", "20GB"}),
+		teadialog.MakeOptionPrompt("until", "Only records unused for longer than:", []string{dockercmd.BuildCacheAnyAge, "1h", "24h", "72h", "168h", "720h"}),
+		teadialog.MakeOptionPrompt("confirm", "This will prune the build cache, are you sure?", []string{"Yes", "No"}),
+	}
+
+	return teadialog.InitDialogue("Prune Build Cache: ", prompts, dialogPruneBuildCache, storage)
+}
+
+func getBackupVolumeDialog(storage map[string]string) FormDialog {
+	defaultPath := fmt.Sprintf("./%s-%s.tar.gz", storage["ID"], time.Now().Format("20060102-150405"))
+
+	fields := []formField{
+		makeTextField("path", "Archive path (a .meta.json file is written next to it):", defaultPath),
+	}
+
+	return makeFormDialog("Backup Volume: "+storage["ID"], fields, dialogBackupVolume, storage)
+}
+
+func getRestoreVolumeDialog(storage map[string]string) FormDialog {
+	fields := []formField{
+		makeTextField("path", "Archive path:", ""),
+		makeTextField("volume", "Restore into volume (created when missing):", storage["ID"]),
+	}
+
+	return makeFormDialog("Restore Volume", fields, dialogRestoreVolume, storage)
+}
+
+func getConfirmRestoreVolumeDialog(storage map[string]string) teadialog.Dialog {
+	prompts := []teadialog.Prompt{
+		teadialog.MakeOptionPrompt(
+			"confirm",
+			fmt.Sprintf("Volume %s already exists, files from the archive will overwrite its content. Continue?", storage["volume"]),
+			[]string{"Yes", "No"},
+		),
+	}
+
+	return teadialog.InitDialogue("Restore Volume:", prompts, dialogConfirmRestoreVolume, storage)
+}
+
+func getCloneVolumeDialog(storage map[string]string) FormDialog {
+	fields := []formField{
+		makeTextField("name", "New volume name:", storage["ID"]+"-copy"),
+	}
+
+	return makeFormDialog("Clone Volume: "+storage["ID"], fields, dialogCloneVolume, storage)
+}
+
+func getRenameVolumeDialog(storage map[string]string) FormDialog {
+	fields := []formField{
+		makeTextField("name", "New volume name (data is copied, then the original is removed):", storage["ID"]),
+	}
+
+	return makeFormDialog("Rename Volume: "+storage["ID"], fields, dialogRenameVolume, storage)
+}
+
+func getCreateVolumeDialog(drivers []string, storage map[string]string) FormDialog {
+	driverLabel := "Driver:"
+	if len(drivers) > 0 {
+		driverLabel = fmt.Sprintf("Driver (available: %s):", strings.Join(drivers, ", "))
+	}
+
+	fields := []formField{
+		makeTextField("name", "Name (leave empty for a generated one):", ""),
+		makeTextField("driver", driverLabel, "local"),
+		makeTextField("driverOpts", "Driver options, space separated key=value (eg: type=nfs o=addr=10.0.0.1,rw device=:/export):", ""),
+		makeTextField("labels", "Labels, space separated key=value:", ""),
+	}
+
+	return makeFormDialog("Create Volume", fields, dialogCreateVolume, storage)
+}
+
+func getRemoveVolumeInUseDialog(users []string, storage map[string]string) teadialog.Dialog {
+	prompts := []teadialog.Prompt{
+		teadialog.MakeOptionPrompt(
+			"confirm",
+			fmt.Sprintf("Volume is used by: %s. Stop and remove these containers, then delete the volume?", strings.Join(users, ", ")),
+			[]string{"Yes", "No"},
+		),
+	}
+
+	return teadialog.InitDialogue("Remove Volume In Use:", prompts, dialogRemoveVolumeInUse, storage)
+}
+
+func getJumpToDialog(targets []jumpTarget, storage map[string]string) teadialog.Dialog {
+	labels := make([]string, len(targets))
+	for i, target := range targets {
+		labels[i] = target.label
+	}
+
+	prompts := []teadialog.Prompt{
+		teadialog.MakeOptionPrompt("target", "Related objects:", labels),
+	}
+
+	return teadialog.InitDialogue("Jump To:", prompts, dialogJumpTo, storage)
+}
+
+func getRemoveComposeProjectDialog(storage map[string]string) teadialog.Dialog {
+	prompts := []teadialog.Prompt{
+		teadialog.MakeTogglePrompt("remVols", "Remove anonymous volumes?"),
+		teadialog.MakeTogglePrompt("force", "Force (removes running containers)?"),
+	}
+
+	return teadialog.InitDialogue("Remove Compose Project "+storage["project"]+":", prompts, dialogRemoveComposeProject, storage)
+}
+
+func getComposeDialog(storage map[string]string) FormDialog {
+	actions := make([]string, len(dockercmd.ComposeActions))
+	for i, action := range dockercmd.ComposeActions {
+		actions[i] = string(action)
+	}
+
+	fields := []formField{
+		makeOptionField("action", "Action:", actions),
+		makeTextField("files", "Compose files, space separated:", storage["files"]),
+		makeTextField("project", "Project name (optional):", storage["project"]),
+		makeTextField("services", "Services, space separated (all when empty):", ""),
+		makeTextField("scale", "Scale, space separated service=replicas (up only):", ""),
+		makeToggleField("forceRecreate", "Recreate unchanged services too (up only)", false),
+		makeToggleField("removeVolumes", "Remove named volumes (down only)", false),
+		makeToggleField("removeOrphans", "Remove containers of services not in the files (up/down)", false),
+	}
+
+	return makeFormDialog("Docker Compose", fields, dialogCompose, storage)
+}
+
+func getRecreateContainerDialog(storage map[string]string) FormDialog {
+	fields := []formField{
+		makeToggleField("pull", "Pull the image tag first", true),
+		makeTextField("healthTimeout", "Seconds to wait for the new container to become healthy:", "60"),
+	}
+
+	return makeFormDialog("Recreate Container: "+storage["name"], fields, dialogRecreateContainer, storage)
+}
+
+func getUpdateImagesDialog(refs []string, storage map[string]string) teadialog.Dialog {
+	prompts := []teadialog.Prompt{
+		teadialog.MakeOptionPrompt("confirm", "Pull "+strings.Join(refs, ", ")+" and recreate the containers using them?", []string{"Yes", "No"}),
+	}
+
+	return teadialog.InitDialogue("Update Outdated Images: ", prompts, dialogUpdateImages, storage)
+}
+
+func getRegistryLoginDialog(storage map[string]string) FormDialog {
+	fields := []formField{
+		makeTextField("server", "Registry (empty for docker hub)", storage["server"]),
+		makeTextField("username", "Username", ""),
+		makePasswordField("password", "Password or access token"),
+	}
+
+	return makeFormDialog("Registry Login", fields, dialogRegistryLogin, storage)
+}
+
+func getRegistryLogoutDialog(servers []string, storage map[string]string) FormDialog {
+	fields := []formField{
+		makeOptionField("server", "Registry", servers),
+	}
+
+	return makeFormDialog("Registry Logout", fields, dialogRegistryLogout, storage)
+}
+
+// tags are the image's tags, the image is tagged as `as` first if that is set
+func getPushImageDialog(tags []string, storage map[string]string) FormDialog {
+	fields := []formField{
+		makeOptionField("tag", "Tag", tags),
+		makeTextField("as", "Push as (optional, eg: ghcr.io/user/app:1.0)", ""),
+	}
+
+	return makeFormDialog("Push Image", fields, dialogPushImage, storage)
+}
+
+func getBrowseRegistryDialog(storage map[string]string) FormDialog {
+	fields := []formField{
+		makeTextField("server", "Registry (eg: localhost:5000, http:// or https:// to force a scheme)", storage["server"]),
+	}
+
+	return makeFormDialog("Browse Registry", fields, dialogBrowseRegistry, storage)
+}
+
+func getSaveImagesDialog(storage map[string]string) FormDialog {
+	defaultPath := fmt.Sprintf("./images-%s.tar.gz", time.Now().Format("20060102-150405"))
+
+	fields := []formField{
+		makeTextField("images", "Images (names or ids, space separated):", storage["images"]),
+		makeTextField("path", "Archive path (.tar, or .tar.gz to compress):", defaultPath),
+	}
+
+	return makeFormDialog("Save Images", fields, dialogSaveImages, storage)
+}
+
+func getLoadImagesDialog(storage map[string]string) FormDialog {
+	fields := []formField{
+		makeTextField("path", "Archive path (created by docker save):", ""),
+	}
+
+	return makeFormDialog("Load Images", fields, dialogLoadImages, storage)
+}
+
+func getImportImageDialog(storage map[string]string) FormDialog {
+	fields := []formField{
+		makeTextField("path", "Filesystem tarball (eg: from docker export):", ""),
+		makeTextField("ref", "Repository:tag (optional):", ""),
+		makeTextField("message", "Commit message (optional):", ""),
+		makeTextField("changes", `Dockerfile instructions, ; separated (eg: CMD ["/bin/sh"]; ENV A=1):`, ""),
+	}
+
+	return makeFormDialog("Import Image", fields, dialogImportImage, storage)
+}
+
+func getExportContainerDialog(storage map[string]string) FormDialog {
+	defaultPath := fmt.Sprintf("./%s-%s.tar.gz", storage["baseName"], time.Now().Format("20060102-150405"))
+
+	fields := []formField{
+		makeTextField("path", "Tarball path (.tar, or .tar.gz to compress), volumes are not included:", defaultPath),
+	}
+
+	return makeFormDialog("Export Container: "+storage["name"], fields, dialogExportContainer, storage)
+}
+
+func getCommitContainerDialog(storage map[string]string) FormDialog {
+	fields := []formField{
+		// container names may contain upper case letters, repository names may not
+		makeTextField("ref", "Repository:tag (optional):", strings.ToLower(storage["baseName"])+":latest"),
+		makeTextField("author", "Author (optional, eg: Jane Doe <jane@example.com>):", ""),
+		makeTextField("message", "Commit message (optional):", ""),
+		makeToggleField("pause", "Pause the container while committing", true),
+		makeTextField("changes", `Dockerfile instructions, ; separated (eg: CMD ["/bin/sh"]; ENV A=1):`, ""),
+	}
+
+	return makeFormDialog("Commit Container: "+storage["name"], fields, dialogCommitContainer, storage)
+}
+
+// hosts are the docker contexts, an address typed in takes precedence
+func getMigrateContainerDialog(hosts []dockercmd.DockerHost, storage map[string]string) FormDialog {
+	var fields []formField
+
+	if len(hosts) > 0 {
+		options := make([]string, 0, len(hosts))
+		for _, host := range hosts {
+			options = append(options, host.String())
+		}
+		fields = append(fields, makeOptionField("context", "Target (docker context):", options))
+	}
+
+	fields = append(fields,
+		makeTextField("host", "Or target address (eg: ssh://user@host, tcp://10.0.0.2:2375):", ""),
+		makeToggleField("remove", "Remove the container from this host afterwards (volumes are kept)", false),
+	)
+
+	return makeFormDialog("Migrate Container: "+storage["name"], fields, dialogMigrateContainer, storage)
+}
@@ -0,0 +1,248 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type fileTreeNode struct {
+	name      string
+	path      string
+	isDir     bool
+	size      int64
+	kind      dockercmd.FileChangeKind
+	collapsed bool
+	children  []*fileTreeNode
+	// only used while building the tree, so lookups in big directories stay cheap
+	childIndex map[string]*fileTreeNode
+}
+
+type visibleNode struct {
+	node  *fileTreeNode
+	depth int
+}
+
+// collapsible tree of paths, used wherever we need to display filesystem changes
+type fileTree struct {
+	root    *fileTreeNode
+	visible []visibleNode
+	cursor  int
+	offset  int
+	height  int
+	width   int
+	// sizes are not known for some sources, eg: container diffs
+	hideSizes bool
+}
+
+func newFileTree(entries []dockercmd.LayerFile, width int, height int) fileTree {
+	root := &fileTreeNode{isDir: true}
+
+	for _, entry := range entries {
+		cur := root
+		parts := strings.Split(strings.Trim(entry.Path, "/"), "/")
+
+		for i, part := range parts {
+			if cur.childIndex == nil {
+				cur.childIndex = make(map[string]*fileTreeNode)
+			}
+
+			next, ok := cur.childIndex[part]
+			if !ok {
+				next = &fileTreeNode{name: part, path: strings.Join(parts[:i+1], "/"), isDir: true}
+				cur.children = append(cur.children, next)
+				cur.childIndex[part] = next
+			}
+			cur = next
+		}
+
+		cur.isDir = entry.IsDir
+		cur.size = entry.Size
+		cur.kind = entry.Kind
+	}
+
+	sortAndSizeTree(root)
+
+	tree := fileTree{root: root, width: width, height: height}
+	tree.refresh()
+	return tree
+}
+
+// sorts directories before files and sums up directory sizes
+func sortAndSizeTree(node *fileTreeNode) int64 {
+	node.childIndex = nil
+	// sources like container diffs do not tell us whether a path is a directory
+	for _, child := range node.children {
+		if len(child.children) > 0 {
+			child.isDir = true
+		}
+	}
+	slices.SortFunc(node.children, func(a, b *fileTreeNode) int {
+		if a.isDir != b.isDir {
+			if a.isDir {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Compare(a.name, b.name)
+	})
+
+	if !node.isDir {
+		return node.size
+	}
+
+	var total int64
+	for _, child := range node.children {
+		total += sortAndSizeTree(child)
+	}
+
+	// removed directories already carry the size of what they hid
+	if node.kind != dockercmd.FileRemoved {
+		node.size = total
+	}
+
+	return node.size
+}
+
+func (t *fileTree) refresh() {
+	t.visible = t.visible[:0]
+
+	var walk func(node *fileTreeNode, depth int)
+	walk = func(node *fileTreeNode, depth int) {
+		for _, child := range node.children {
+			t.visible = append(t.visible, visibleNode{node: child, depth: depth})
+			if child.isDir && !child.collapsed {
+				walk(child, depth+1)
+			}
+		}
+	}
+	walk(t.root, 0)
+
+	t.cursor = min(t.cursor, max(len(t.visible)-1, 0))
+	t.scrollToCursor()
+}
+
+func (t *fileTree) scrollToCursor() {
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	} else if t.cursor >= t.offset+t.height {
+		t.offset = t.cursor - t.height + 1
+	}
+}
+
+func (t fileTree) selected() *fileTreeNode {
+	if len(t.visible) == 0 {
+		return nil
+	}
+	return t.visible[t.cursor].node
+}
+
+func (t *fileTree) setSize(width int, height int) {
+	t.width = width
+	t.height = max(height, 1)
+	t.scrollToCursor()
+}
+
+func (t fileTree) Update(msg tea.Msg) (fileTree, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(msg, NavKeymap.NextItem):
+			if t.cursor < len(t.visible)-1 {
+				t.cursor += 1
+			}
+		case key.Matches(msg, NavKeymap.PrevItem):
+			if t.cursor > 0 {
+				t.cursor -= 1
+			}
+		case key.Matches(msg, NavKeymap.NextPage):
+			t.cursor = min(t.cursor+t.height, max(len(t.visible)-1, 0))
+		case key.Matches(msg, NavKeymap.PrevPage):
+			t.cursor = max(t.cursor-t.height, 0)
+		case key.Matches(msg, FileTreeKeymap.ToggleCollapse):
+			if node := t.selected(); node != nil && node.isDir {
+				node.collapsed = !node.collapsed
+				t.refresh()
+			}
+		}
+		t.scrollToCursor()
+	}
+
+	return t, nil
+}
+
+func (t fileTree) View() string {
+	if len(t.visible) == 0 {
+		return "No files"
+	}
+
+	var res strings.Builder
+	end := min(t.offset+t.height, len(t.visible))
+
+	for i := t.offset; i < end; i++ {
+		entry := t.visible[i]
+		node := entry.node
+
+		icon := "  "
+		if node.isDir {
+			icon = "▾ "
+			if node.collapsed {
+				icon = "▸ "
+			}
+		}
+
+		name := node.name
+		if node.isDir {
+			name += "/"
+		}
+
+		line := fmt.Sprintf("%s %s%s%s", changeMarker(node.kind), strings.Repeat("  ", entry.depth), icon, name)
+		if !t.hideSizes {
+			sizeStr := humanSize(node.size)
+			padding := max(t.width-len([]rune(line))-len(sizeStr), 1)
+			line = line + strings.Repeat(" ", padding) + sizeStr
+		}
+
+		if i == t.cursor {
+			line = treeCursorStyle.Render(line)
+		} else {
+			line = changeStyle(node.kind).Render(line)
+		}
+
+		res.WriteString(line)
+		if i < end-1 {
+			res.WriteString("\n")
+		}
+	}
+
+	return res.String()
+}
+
+func changeMarker(kind dockercmd.FileChangeKind) string {
+	switch kind {
+	case dockercmd.FileAdded:
+		return "+"
+	case dockercmd.FileModified:
+		return "~"
+	case dockercmd.FileRemoved:
+		return "-"
+	}
+	return " "
+}
+
+func changeStyle(kind dockercmd.FileChangeKind) lipgloss.Style {
+	switch kind {
+	case dockercmd.FileAdded:
+		return fileAddedStyle
+	case dockercmd.FileModified:
+		return fileModifiedStyle
+	case dockercmd.FileRemoved:
+		return fileRemovedStyle
+	}
+	return fileUnchangedStyle
+}
@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	teadialog "github.com/ajayd-san/teaDialog"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type formFieldKind int
+
+const (
+	formFieldText formFieldKind = iota
+	formFieldToggle
+	formFieldOption
+)
+
+type formField struct {
+	id      string
+	label   string
+	kind    formFieldKind
+	input   textinput.Model
+	checked bool
+	options []string
+	// index into options
+	selected int
+}
+
+func makeTextField(id string, label string, value string) formField {
+	input := textinput.New()
+	input.Prompt = ""
+	input.SetValue(value)
+	input.Width = 50
+	return formField{id: id, label: label, kind: formFieldText, input: input}
+}
+
+// like a text field, but the value is masked
+func makePasswordField(id string, label string) formField {
+	field := makeTextField(id, label, "")
+	field.input.EchoMode = textinput.EchoPassword
+	field.input.EchoCharacter = '*'
+	return field
+}
+
+func makeToggleField(id string, label string, checked bool) formField {
+	return formField{id: id, label: label, kind: formFieldToggle, checked: checked}
+}
+
+func makeOptionField(id string, label string, options []string) formField {
+	return formField{id: id, label: label, kind: formFieldOption, options: options}
+}
+
+// Like teadialog.Dialog but supports free text, teaDialog only has option/toggle prompts.
+// Results are sent as teadialog.DialogSelectionResult, text fields map to strings and toggles to bools.
+type FormDialog struct {
+	title   string
+	kind    teadialog.DialogType
+	fields  []formField
+	active  int
+	storage map[string]string
+	help    help.Model
+}
+
+func makeFormDialog(title string, fields []formField, kind teadialog.DialogType, storage map[string]string) FormDialog {
+	d := FormDialog{
+		title:   title,
+		kind:    kind,
+		fields:  fields,
+		storage: storage,
+		help:    help.New(),
+	}
+	d.focus(0)
+	return d
+}
+
+func (d FormDialog) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (d FormDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		// forward cursor blinks to the focused input
+		field := &d.fields[d.active]
+		if field.kind != formFieldText {
+			return d, nil
+		}
+
+		var cmd tea.Cmd
+		field.input, cmd = field.input.Update(msg)
+		return d, cmd
+	}
+
+	switch {
+	case key.Matches(keyMsg, FormDialogKeymap.Next):
+		return d, d.focus(min(d.active+1, len(d.fields)-1))
+	case key.Matches(keyMsg, FormDialogKeymap.Prev):
+		return d, d.focus(max(d.active-1, 0))
+	case key.Matches(keyMsg, FormDialogKeymap.Submit):
+		return d, d.result
+	}
+
+	field := &d.fields[d.active]
+	switch field.kind {
+	case formFieldToggle:
+		if key.Matches(keyMsg, FormDialogKeymap.Toggle) {
+			field.checked = !field.checked
+		}
+	case formFieldOption:
+		switch {
+		case key.Matches(keyMsg, FormDialogKeymap.NextOption, FormDialogKeymap.Toggle):
+			field.selected = (field.selected + 1) % len(field.options)
+		case key.Matches(keyMsg, FormDialogKeymap.PrevOption):
+			field.selected = (field.selected - 1 + len(field.options)) % len(field.options)
+		}
+	case formFieldText:
+		var cmd tea.Cmd
+		field.input, cmd = field.input.Update(keyMsg)
+		return d, cmd
+	}
+
+	return d, nil
+}
+
+func (d FormDialog) View() string {
+	var res strings.Builder
+	res.WriteString(d.title + "\n\n")
+
+	for i, field := range d.fields {
+		var line string
+		switch field.kind {
+		case formFieldText:
+			line = fmt.Sprintf("%s\n%s", field.label, formInputStyle.Render(field.input.View()))
+		case formFieldToggle:
+			checkbox := "[ ]"
+			if field.checked {
+				checkbox = "[x]"
+			}
+			line = checkbox + " " + field.label
+		case formFieldOption:
+			options := make([]string, len(field.options))
+			for i, option := range field.options {
+				if i == field.selected {
+					options[i] = formInputStyle.Render("[" + option + "]")
+				} else {
+					options[i] = mutedStyle.Render(" " + option + " ")
+				}
+			}
+			line = fmt.Sprintf("%s\n%s", field.label, strings.Join(options, " "))
+		}
+
+		if i == d.active {
+			line = formActiveFieldStyle.Render(line)
+		} else {
+			line = formFieldStyle.Render(line)
+		}
+
+		res.WriteString(line + "\n")
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Center, formDialogStyle.Render(res.String()), d.help.View(FormDialogKeymap))
+}
+
+// helpers
+
+func (d *FormDialog) focus(index int) tea.Cmd {
+	if d.fields[d.active].kind == formFieldText {
+		d.fields[d.active].input.Blur()
+	}
+
+	d.active = index
+	if d.fields[index].kind == formFieldText {
+		return d.fields[index].input.Focus()
+	}
+	return nil
+}
+
+func (d FormDialog) result() tea.Msg {
+	choices := make(map[string]any, len(d.fields))
+
+	for _, field := range d.fields {
+		switch field.kind {
+		case formFieldText:
+			if field.input.EchoMode == textinput.EchoPassword {
+				// passwords are taken as typed
+				choices[field.id] = field.input.Value()
+			} else {
+				choices[field.id] = strings.TrimSpace(field.input.Value())
+			}
+		case formFieldToggle:
+			choices[field.id] = field.checked
+		case formFieldOption:
+			choices[field.id] = field.options[field.selected]
+		}
+	}
+
+	return teadialog.DialogSelectionResult{
+		Kind:        d.kind,
+		UserChoices: choices,
+		UserStorage: d.storage,
+	}
+}
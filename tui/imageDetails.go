@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/docker/docker/api/types"
+)
+
+type imageDetailsLoaded struct {
+	inspect    *types.ImageInspect
+	dockerfile string
+	err        error
+}
+
+// shows the full image config along with a Dockerfile reconstructed from the image history
+type ImageDetailsModel struct {
+	dockerClient dockercmd.DockerClient
+	imageId      string
+	imageName    string
+	inspect      *types.ImageInspect
+	dockerfile   string
+	err          error
+	status       string
+	viewport     viewport.Model
+	help         help.Model
+}
+
+func NewImageDetailsModel(client dockercmd.DockerClient, imageId string, imageName string, width int, height int) ImageDetailsModel {
+	m := ImageDetailsModel{
+		dockerClient: client,
+		imageId:      imageId,
+		imageName:    imageName,
+		viewport:     viewport.New(width, max(height-6, 5)),
+		help:         help.New(),
+	}
+	m.help.Width = width
+	return m
+}
+
+func (m ImageDetailsModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		inspect, err := m.dockerClient.InspectImage(m.imageId)
+		if err != nil {
+			return imageDetailsLoaded{err: err}
+		}
+
+		history, err := m.dockerClient.ImageHistory(m.imageId)
+		if err != nil {
+			return imageDetailsLoaded{err: err}
+		}
+
+		return imageDetailsLoaded{inspect: inspect, dockerfile: dockercmd.ReconstructDockerfile(history)}
+	}
+}
+
+func (m ImageDetailsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case imageDetailsLoaded:
+		m.inspect = msg.inspect
+		m.dockerfile = msg.dockerfile
+		m.err = msg.err
+		if m.inspect != nil {
+			m.viewport.SetContent(m.content())
+		}
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		m.viewport.Width = msg.Width
+		m.viewport.Height = max(msg.Height-6, 5)
+		m.help.Width = msg.Width
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, ImageDetailsKeymap.Back):
+			return m, closeView
+		case key.Matches(msg, ImageDetailsKeymap.CopyDockerfile):
+			if m.dockerfile == "" {
+				return m, nil
+			}
+
+			if err := clipboard.WriteAll(m.dockerfile); err != nil {
+				m.status = "Could not copy to clipboard: " + err.Error()
+			} else {
+				m.status = "Dockerfile copied to clipboard"
+			}
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m ImageDetailsModel) View() string {
+	title := viewTitleStyle.Render("Image: " + m.imageName)
+
+	var body string
+	switch {
+	case m.err != nil:
+		body = "Error: " + m.err.Error()
+	case m.inspect == nil:
+		body = "Loading..."
+	default:
+		body = m.viewport.View()
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, body, mutedStyle.Render(m.status), m.help.View(ImageDetailsKeymap))
+}
+
+// helpers
+
+func (m ImageDetailsModel) content() string {
+	var res strings.Builder
+	inspect := m.inspect
+
+	addDetail(&res, "ID", inspect.ID)
+	addDetail(&res, "Tags", strings.Join(inspect.RepoTags, ", "))
+	addDetail(&res, "Digests", strings.Join(inspect.RepoDigests, ", "))
+	addDetail(&res, "Created", inspect.Created)
+	addDetail(&res, "Author", inspect.Author)
+	addDetail(&res, "Size", humanSize(inspect.Size))
+
+	platform := inspect.Os + "/" + inspect.Architecture
+	if inspect.Variant != "" {
+		platform += "/" + inspect.Variant
+	}
+	addDetail(&res, "Platform", platform)
+
+	if config := inspect.Config; config != nil {
+		res.WriteString("\n" + viewTitleStyle.Render("Config") + "\n")
+		addDetail(&res, "Entrypoint", fmt.Sprintf("%q", []string(config.Entrypoint)))
+		addDetail(&res, "Cmd", fmt.Sprintf("%q", []string(config.Cmd)))
+		addDetail(&res, "Working Dir", config.WorkingDir)
+		addDetail(&res, "User", config.User)
+		addDetail(&res, "Stop Signal", config.StopSignal)
+
+		ports := make([]string, 0, len(config.ExposedPorts))
+		for port := range config.ExposedPorts {
+			ports = append(ports, string(port))
+		}
+		slices.Sort(ports)
+		addDetail(&res, "Exposed Ports", strings.Join(ports, ", "))
+
+		volumes := make([]string, 0, len(config.Volumes))
+		for volume := range config.Volumes {
+			volumes = append(volumes, volume)
+		}
+		slices.Sort(volumes)
+		addDetail(&res, "Volumes", strings.Join(volumes, ", "))
+
+		addDetailList(&res, "Env", config.Env)
+
+		labels := make([]string, 0, len(config.Labels))
+		for label, value := range config.Labels {
+			labels = append(labels, label+"="+value)
+		}
+		slices.Sort(labels)
+		addDetailList(&res, "Labels", labels)
+	}
+
+	res.WriteString("\n" + viewTitleStyle.Render("Dockerfile (reconstructed)") + "\n")
+	res.WriteString(m.dockerfile)
+
+	return res.String()
+}
+
+// like addEntry but without margins, since views are scrolled line by line
+func addDetail(res *strings.Builder, label string, val string) {
+	if val == "" {
+		val = mutedStyle.Render("<none>")
+	}
+	res.WriteString(infoEntryLabel.Render(label+": ") + val + "\n")
+}
+
+func addDetailList(res *strings.Builder, label string, vals []string) {
+	if len(vals) == 0 {
+		addDetail(res, label, "")
+		return
+	}
+
+	res.WriteString(infoEntryLabel.Render(label+":") + "\n")
+	for _, val := range vals {
+		res.WriteString("  " + val + "\n")
+	}
+}
@@ -0,0 +1,213 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type imageLayersLoaded struct {
+	analysis *dockercmd.ImageLayerAnalysis
+	err      error
+}
+
+// dive style explorer, lists layers of an image along with the files each layer changed
+type ImageLayersModel struct {
+	dockerClient dockercmd.DockerClient
+	imageId      string
+	imageName    string
+	analysis     *dockercmd.ImageLayerAnalysis
+	err          error
+	cursor       int
+	focusTree    bool
+	showWasted   bool
+	tree         fileTree
+	wasted       viewport.Model
+	help         help.Model
+	width        int
+	height       int
+}
+
+func NewImageLayersModel(client dockercmd.DockerClient, imageId string, imageName string, width int, height int) ImageLayersModel {
+	m := ImageLayersModel{
+		dockerClient: client,
+		imageId:      imageId,
+		imageName:    imageName,
+		help:         help.New(),
+		wasted:       viewport.New(0, 0),
+	}
+	m.setSize(width, height)
+	return m
+}
+
+func (m ImageLayersModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		// saving the image may take a while for big images, this runs on bubbletea's goroutine
+		analysis, err := m.dockerClient.AnalyzeImageLayers(m.imageId)
+		return imageLayersLoaded{analysis: analysis, err: err}
+	}
+}
+
+func (m ImageLayersModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case imageLayersLoaded:
+		m.analysis = msg.analysis
+		m.err = msg.err
+		if m.analysis != nil {
+			m.wasted.SetContent(m.wastedContent())
+			m.selectLayer(len(m.analysis.Layers) - 1)
+		}
+
+	case tea.WindowSizeMsg:
+		m.setSize(msg.Width, msg.Height)
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, LayerExplorerKeymap.Back):
+			return m, closeView
+		case m.analysis == nil:
+			return m, nil
+		case key.Matches(msg, LayerExplorerKeymap.SwitchPane):
+			m.focusTree = !m.focusTree
+		case key.Matches(msg, LayerExplorerKeymap.ToggleWasted):
+			m.showWasted = !m.showWasted
+		case m.focusTree && m.showWasted:
+			var cmd tea.Cmd
+			m.wasted, cmd = m.wasted.Update(msg)
+			return m, cmd
+		case m.focusTree:
+			var cmd tea.Cmd
+			m.tree, cmd = m.tree.Update(msg)
+			return m, cmd
+		case key.Matches(msg, NavKeymap.NextItem):
+			m.selectLayer(m.cursor + 1)
+		case key.Matches(msg, NavKeymap.PrevItem):
+			m.selectLayer(m.cursor - 1)
+		}
+	}
+
+	return m, nil
+}
+
+func (m ImageLayersModel) View() string {
+	title := viewTitleStyle.Render("Layers: " + m.imageName)
+
+	if m.err != nil {
+		return lipgloss.JoinVertical(lipgloss.Left, title, "Error: "+m.err.Error(), m.help.View(LayerExplorerKeymap))
+	}
+
+	if m.analysis == nil {
+		return lipgloss.JoinVertical(lipgloss.Left, title, "Saving and analyzing image, this may take a while for big images...")
+	}
+
+	efficiency := 100.0
+	if m.analysis.TotalSize > 0 {
+		efficiency = 100 * (1 - float64(m.analysis.WastedBytes)/float64(m.analysis.TotalSize))
+	}
+
+	summary := fmt.Sprintf("Total size: %s    Potential wasted space: %s    Efficiency: %.2f%%",
+		humanSize(m.analysis.TotalSize), humanSize(m.analysis.WastedBytes), efficiency)
+
+	leftStyle, rightStyle := viewActivePaneStyle, viewPaneStyle
+	if m.focusTree {
+		leftStyle, rightStyle = viewPaneStyle, viewActivePaneStyle
+	}
+
+	paneHeight := m.paneHeight()
+	left := leftStyle.Width(m.layerPaneWidth()).Height(paneHeight).Render(m.layersView())
+
+	var right string
+	if m.showWasted {
+		right = rightStyle.Width(m.treePaneWidth()).Height(paneHeight).Render(m.wasted.View())
+	} else {
+		right = rightStyle.Width(m.treePaneWidth()).Height(paneHeight).Render(m.tree.View())
+	}
+
+	panes := lipgloss.JoinHorizontal(lipgloss.Top, left, right)
+	return lipgloss.JoinVertical(lipgloss.Left, title, summary, panes, m.help.View(LayerExplorerKeymap))
+}
+
+// helpers
+
+func (m *ImageLayersModel) setSize(width int, height int) {
+	m.width = width
+	m.height = height
+	m.help.Width = width
+	m.tree.setSize(m.treePaneWidth(), m.paneHeight())
+	m.wasted.Width = m.treePaneWidth()
+	m.wasted.Height = m.paneHeight()
+}
+
+func (m ImageLayersModel) layerPaneWidth() int {
+	return max(m.width*2/5, 20)
+}
+
+func (m ImageLayersModel) treePaneWidth() int {
+	return max(m.width-m.layerPaneWidth()-8, 20)
+}
+
+func (m ImageLayersModel) paneHeight() int {
+	return max(m.height-10, 5)
+}
+
+func (m *ImageLayersModel) selectLayer(index int) {
+	if index < 0 || index >= len(m.analysis.Layers) {
+		return
+	}
+
+	m.cursor = index
+	m.tree = newFileTree(m.analysis.Layers[index].Files, m.treePaneWidth(), m.paneHeight())
+}
+
+func (m ImageLayersModel) layersView() string {
+	var res strings.Builder
+	width := m.layerPaneWidth()
+	height := m.paneHeight()
+
+	// keep the selected layer visible
+	offset := max(m.cursor-height+1, 0)
+	end := min(offset+height, len(m.analysis.Layers))
+
+	for i := offset; i < end; i++ {
+		layer := m.analysis.Layers[i]
+		command := strings.Join(strings.Fields(strings.TrimPrefix(layer.CreatedBy, "/bin/sh -c #(nop) ")), " ")
+
+		line := fmt.Sprintf("%3d %9s  %s", i, humanSize(layer.Size), command)
+		if runes := []rune(line); len(runes) > width {
+			line = string(runes[:width-1]) + "…"
+		}
+
+		switch {
+		case i == m.cursor:
+			line = treeCursorStyle.Render(line)
+		case layer.Empty:
+			line = mutedStyle.Render(line)
+		}
+
+		res.WriteString(line)
+		if i < end-1 {
+			res.WriteString("\n")
+		}
+	}
+
+	return res.String()
+}
+
+func (m ImageLayersModel) wastedContent() string {
+	if len(m.analysis.Wasted) == 0 {
+		return "No wasted space found"
+	}
+
+	var res strings.Builder
+	for _, file := range m.analysis.Wasted {
+		res.WriteString(fmt.Sprintf("%9s  layer %d -> %d  %s\n", humanSize(file.Size), file.AddedIn, file.HiddenIn, file.Path))
+	}
+
+	return res.String()
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/docker/docker/api/types"
 )
@@ -19,16 +20,32 @@ func PopulateInfoBox(tab tabId, item list.Item) string {
 		if it, ok := temp.(imageItem); ok {
 			return populateImageInfoBox(it)
 		}
+		if ct, ok := temp.(imageChainItem); ok {
+			return populateImageChainInfoBox(ct)
+		}
 
 	case containers:
 		if ct, ok := temp.(containerItem); ok {
 			return populateContainerInfoBox(ct)
 		}
+		if pt, ok := temp.(composeProjectItem); ok {
+			return populateComposeProjectInfoBox(pt)
+		}
 
 	case volumes:
 		if vt, ok := temp.(VolumeItem); ok {
 			return populateVolumeInfoBox(vt)
 		}
+
+	case buildCache:
+		if bt, ok := temp.(buildCacheItem); ok {
+			return populateBuildCacheInfoBox(bt)
+		}
+
+	case networks:
+		if nt, ok := temp.(networkItem); ok {
+			return populateNetworkInfoBox(nt)
+		}
 	}
 	return ""
 }
@@ -43,6 +60,31 @@ func populateImageInfoBox(imageinfo imageItem) string {
 		addEntry(&res, "Containers: ", strconv.Itoa(int(imageinfo.Containers)))
 	}
 	addEntry(&res, "Created: ", time.Unix(imageinfo.Created, 0).Format(time.UnixDate))
+	if status, ok := getImageUpdateStatus(imageinfo.ID); ok {
+		addEntry(&res, "Update: ", imageUpdateString(status))
+	}
+	if findings, ok := getLintFindings(imageLintKey(imageinfo.Summary)); ok && len(findings) > 0 {
+		addEntry(&res, "Lint: ", lintFindingsString(findings))
+	}
+
+	if tree := imageinfo.tree; tree.shown {
+		addEntry(&res, "Layers: ", fmt.Sprintf("%d (%d from the parent)", tree.layers, tree.sharedLayers))
+		if tree.uniqueSize >= 0 {
+			addEntry(&res, "Unique Size: ", humanSize(tree.uniqueSize)+" (freed by deleting it)")
+			addEntry(&res, "Shared Size: ", humanSize(imageinfo.SharedSize)+" (used by other images too)")
+		}
+		if tree.descendants > 0 {
+			addEntry(&res, "Children: ", fmt.Sprintf("%d, their layers on top of this one are only freed with them", tree.descendants))
+		}
+	}
+	return res.String()
+}
+
+func populateImageChainInfoBox(chain imageChainItem) string {
+	var res strings.Builder
+	addEntry(&res, "Shared Layers: ", strconv.Itoa(chain.tree.layers))
+	addEntry(&res, "Used By: ", fmt.Sprintf("%d images", chain.tree.descendants))
+	res.WriteString("\nThe layers are not an image themselves (eg: the base image was removed or never pulled), they are freed once every image above is deleted.")
 	return res.String()
 }
 
@@ -54,12 +96,98 @@ func populateVolumeInfoBox(volumeInfo VolumeItem) string {
 	addEntry(&res, "Driver: ", volumeInfo.Driver)
 	addEntry(&res, "Mount Point: ", volumeInfo.Mountpoint)
 
+	if len(volumeInfo.Options) > 0 {
+		addEntry(&res, "Options: ", formatKeyValues(volumeInfo.Options))
+	}
+
+	if len(volumeInfo.Labels) > 0 {
+		addEntry(&res, "Labels: ", formatKeyValues(volumeInfo.Labels))
+	}
+
 	if size := volumeInfo.getSize(); size != -1 {
 		addEntry(&res, "Size: ", fmt.Sprintf("%f", size))
 	} else {
 		addEntry(&res, "Size: ", "Not Available")
 	}
 
+	if !volumeInfo.mountsKnown {
+		addEntry(&res, "Used By: ", "unknown, listing containers failed")
+	} else if len(volumeInfo.mounts) == 0 {
+		addEntry(&res, "Used By: ", "none (orphaned)")
+	} else {
+		users := make([]string, len(volumeInfo.mounts))
+		for i, m := range volumeInfo.mounts {
+			mode := "RO"
+			if m.RW {
+				mode = "RW"
+			}
+			users[i] = fmt.Sprintf("%s (%s) at %s [%s]", m.ContainerName, m.State, m.Destination, mode)
+		}
+		addEntry(&res, "Used By: ", "\n"+strings.Join(users, "\n"))
+	}
+
+	return res.String()
+}
+
+func populateComposeProjectInfoBox(project composeProjectItem) string {
+	var res strings.Builder
+
+	addEntry(&res, "Compose Project: ", project.name)
+	addEntry(&res, "Running: ", fmt.Sprintf("%d/%d", project.running(), len(project.members)))
+
+	if len(project.containers) > 0 {
+		labels := project.containers[0].Labels
+		if workingDir := labels[dockercmd.ComposeWorkingDirLabel]; workingDir != "" {
+			addEntry(&res, "Working Dir: ", workingDir)
+		}
+		if configFiles := labels[dockercmd.ComposeConfigFilesLabel]; configFiles != "" {
+			addEntry(&res, "Config Files: ", configFiles)
+		}
+	}
+
+	services := make([]string, len(project.containers))
+	for i, c := range project.containers {
+		services[i] = fmt.Sprintf("%s #%s (%s)", c.Labels[dockercmd.ComposeServiceLabel], c.Labels[dockercmd.ComposeContainerNumberLabel], c.State)
+	}
+	addEntry(&res, "Services: ", "\n"+strings.Join(services, "\n"))
+
+	return res.String()
+}
+
+func populateBuildCacheInfoBox(cacheInfo buildCacheItem) string {
+	var res strings.Builder
+
+	addEntry(&res, "ID: ", cacheInfo.ID)
+	addEntry(&res, "Type: ", cacheInfo.Type)
+	addEntry(&res, "Description: ", cacheInfo.getLabel())
+	addEntry(&res, "Size: ", humanSize(cacheInfo.Size))
+	addEntry(&res, "Created: ", cacheInfo.CreatedAt.Format(time.UnixDate))
+	addEntry(&res, "Last Used: ", cacheInfo.lastUsed().Format(time.UnixDate))
+	addEntry(&res, "Usage Count: ", strconv.Itoa(cacheInfo.UsageCount))
+	addEntry(&res, "Shared: ", strconv.FormatBool(cacheInfo.Shared))
+	addEntry(&res, "In Use: ", strconv.FormatBool(cacheInfo.InUse))
+
+	if len(cacheInfo.Parents) > 0 {
+		addEntry(&res, "Parents: ", strings.Join(cacheInfo.Parents, ", "))
+	} else if cacheInfo.Parent != "" {
+		addEntry(&res, "Parents: ", cacheInfo.Parent)
+	}
+
+	return res.String()
+}
+
+func populateNetworkInfoBox(networkInfo networkItem) string {
+	var res strings.Builder
+
+	addEntry(&res, "ID: ", networkInfo.ID)
+	addEntry(&res, "Name: ", networkInfo.Name)
+	addEntry(&res, "Driver: ", networkInfo.Driver)
+	addEntry(&res, "Scope: ", networkInfo.Scope)
+	addEntry(&res, "Created: ", networkInfo.Created.Format(time.UnixDate))
+	addEntry(&res, "Internal: ", strconv.FormatBool(networkInfo.Internal))
+	if subnets := networkInfo.subnets(); len(subnets) > 0 {
+		addEntry(&res, "Subnets: ", strings.Join(subnets, ", "))
+	}
 	return res.String()
 }
 
@@ -89,6 +217,14 @@ func populateContainerInfoBox(containerInfo containerItem) string {
 		addEntry(&res, "SizeRw: ", "Calculating...")
 	}
 
+	if status, ok := getImageUpdateStatus(containerInfo.ImageID); ok {
+		addEntry(&res, "Image Update: ", imageUpdateString(status))
+	}
+
+	if findings, ok := getLintFindings(containerInfo.ID); ok && len(findings) > 0 {
+		addEntry(&res, "Lint: ", lintFindingsString(findings))
+	}
+
 	addEntry(&res, "Command: ", containerInfo.Command)
 	addEntry(&res, "State: ", containerInfo.State)
 
@@ -99,12 +235,36 @@ func populateContainerInfoBox(containerInfo containerItem) string {
 }
 
 // UTIL
+// counts per severity and the worst finding, eg: 1 high, 2 low (runs privileged)
+func lintFindingsString(findings []dockercmd.LintFinding) string {
+	worst := findings[0]
+	return dockercmd.LintSummary(findings) + " (" + lintSeverityStyle(worst.Severity).Render(worst.Summary) + ")"
+}
+
 func addEntry(res *strings.Builder, label string, val string) {
 	label = infoEntryLabel.Render(label)
 	entry := infoEntry.Render(label + val)
 	res.WriteString(entry)
 }
 
+// formats byte count to a human readable string, eg: 1.50MB
+func humanSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(size)
+	unit := 0
+
+	for value >= 1000 && unit < len(units)-1 {
+		value /= 1000
+		unit += 1
+	}
+
+	if unit == 0 {
+		return strconv.FormatInt(size, 10) + units[unit]
+	}
+
+	return strconv.FormatFloat(value, 'f', 2, 64) + units[unit]
+}
+
 func mountPointString(mounts []types.MountPoint) string {
 
 	var res strings.Builder
@@ -132,3 +292,24 @@ func mapToString(m map[string]string) string {
 	}
 	return res.String()
 }
+
+// sorted `key=value` pairs, space separated (same format the create volume dialog takes)
+func formatKeyValues(m map[string]string) string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, " ")
+}
+
+func imageUpdateString(status dockercmd.ImageUpdateStatus) string {
+	switch {
+	case status.Err != nil:
+		return "unknown (" + status.Err.Error() + ")"
+	case status.Outdated:
+		return imageOutdatedStyle.Render("newer " + status.Ref + " available")
+	default:
+		return "up to date"
+	}
+}
@@ -0,0 +1,239 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	teadialog "github.com/ajayd-san/teaDialog"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/mount"
+)
+
+// an item in some tab that can be jumped to
+type jumpTarget struct {
+	tab   tabId
+	id    string
+	label string
+}
+
+// where we were before jumping, so we can return. Jumping can change how tabs are listed to reveal the target, the
+// previous settings are restored when going back
+type jumpLocation struct {
+	tab int
+	id  string
+	// whether stopped containers were listed
+	listAll bool
+	// per tab, whether only risky items were listed
+	onlyRisky []bool
+}
+
+// related objects of the selected item: a container's image, volumes and networks, an image's containers, a volume's
+// containers and a network's containers
+func (m Model) jumpTargets(item dockerRes) ([]jumpTarget, error) {
+	var res []jumpTarget
+
+	switch item := item.(type) {
+	case containerItem:
+		res = append(res, jumpTarget{tab: images, id: item.ImageID, label: "image: " + item.Image})
+
+		for _, mp := range item.Mounts {
+			if mp.Type == mount.TypeVolume && mp.Name != "" {
+				res = append(res, jumpTarget{tab: volumes, id: mp.Name, label: "volume: " + mp.Name})
+			}
+		}
+
+		if item.NetworkSettings != nil {
+			names := make([]string, 0, len(item.NetworkSettings.Networks))
+			for name := range item.NetworkSettings.Networks {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+
+			for _, name := range names {
+				res = append(res, jumpTarget{tab: networks, id: item.NetworkSettings.Networks[name].NetworkID, label: "network: " + name})
+			}
+		}
+
+	case imageItem:
+		users, err := m.dockerClient.ContainersUsingImage(item.getId())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range users {
+			res = append(res, jumpTarget{tab: containers, id: c.ID, label: "container: " + dockercmd.ContainerName(c)})
+		}
+
+	case networkItem:
+		members, err := m.dockerClient.ContainersOnNetwork(item.getId())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range members {
+			res = append(res, jumpTarget{tab: containers, id: c.ID, label: "container: " + dockercmd.ContainerName(c)})
+		}
+
+	case VolumeItem:
+		for _, mount := range item.mounts {
+			res = append(res, jumpTarget{tab: containers, id: mount.ContainerID, label: "container: " + mount.ContainerName})
+		}
+	}
+
+	return res, nil
+}
+
+// jumps straight to the only related object, otherwise lets the user pick one
+func (m *Model) showJumpTargets(item dockerRes) tea.Cmd {
+	targets, err := m.jumpTargets(item)
+	if err == nil && len(targets) == 0 {
+		err = fmt.Errorf("%s has no related objects", item.getName())
+	}
+
+	if err == nil && len(targets) == 1 {
+		err = m.jumpTo(targets[0])
+		if err == nil {
+			return nil
+		}
+	}
+
+	if err != nil {
+		m.activeDialog = teadialog.NewErrorDialog(err.Error(), m.width)
+	} else {
+		m.activeDialog = getJumpToDialog(targets, encodeJumpTargets(targets))
+	}
+
+	m.showDialog = true
+	return m.activeDialog.Init()
+}
+
+// switches to the target's tab and selects it, the current location is pushed onto the back stack
+func (m *Model) jumpTo(target jumpTarget) error {
+	// taken first, selecting the target can change how tabs are listed
+	loc := m.currentLocation()
+
+	if !m.selectItem(target.tab, target.id) {
+		m.restoreListing(loc)
+		return fmt.Errorf("could not find %s", strings.TrimSpace(target.label))
+	}
+
+	m.jumpHistory = append(m.jumpHistory, loc)
+	m.activeTab = int(target.tab)
+	return nil
+}
+
+func (m *Model) jumpBack() {
+	if len(m.jumpHistory) == 0 {
+		return
+	}
+
+	prev := m.jumpHistory[len(m.jumpHistory)-1]
+	m.jumpHistory = m.jumpHistory[:len(m.jumpHistory)-1]
+
+	m.restoreListing(prev)
+
+	m.activeTab = prev.tab
+	// the item might be gone by now, staying on its tab is good enough
+	if prev.id != "" {
+		m.selectItem(tabId(prev.tab), prev.id)
+	}
+}
+
+// undoes the listing changes selectItem made to reveal a jump target
+func (m *Model) restoreListing(loc jumpLocation) {
+	if m.dockerClient.IsListingAllContainers() != loc.listAll {
+		m.dockerClient.ToggleContainerListAll()
+		*m = m.updateContent(int(containers))
+	}
+	for tab, onlyRisky := range loc.onlyRisky {
+		if m.TabContent[tab].onlyRisky != onlyRisky {
+			m.setOnlyRisky(tabId(tab), onlyRisky)
+		}
+	}
+}
+
+func (m Model) currentLocation() jumpLocation {
+	loc := jumpLocation{tab: m.activeTab, listAll: m.dockerClient.IsListingAllContainers()}
+	for _, content := range m.TabContent {
+		loc.onlyRisky = append(loc.onlyRisky, content.onlyRisky)
+	}
+
+	if item, ok := m.getSelectedItem().(dockerRes); ok {
+		loc.id = item.getId()
+	}
+	return loc
+}
+
+// selects the item with the given id in tab, the tab's filter is cleared since it might hide the item
+func (m *Model) selectItem(tab tabId, id string) bool {
+	find := func() int {
+		for i, item := range m.getList(int(tab)).Items() {
+			if item.(dockerRes).getId() == id {
+				return i
+			}
+		}
+		return -1
+	}
+
+	index := find()
+
+	// stopped containers are hidden unless listing all
+	if index == -1 && tab == containers && !m.dockerClient.IsListingAllContainers() {
+		m.dockerClient.ToggleContainerListAll()
+		*m = m.updateContent(int(containers))
+		index = find()
+	}
+
+	// containers of collapsed compose projects are not listed
+	if index == -1 && tab == containers {
+		for _, item := range m.getList(int(containers)).Items() {
+			if project, ok := item.(composeProjectItem); ok && project.collapsed && slices.ContainsFunc(project.containers, func(c types.Container) bool { return c.ID == id }) {
+				m.TabContent[containers].collapsedProjects[project.name] = false
+				*m = m.updateContent(int(containers))
+				index = find()
+				break
+			}
+		}
+	}
+
+	// the risky filter might hide it
+	if index == -1 && m.TabContent[tab].onlyRisky {
+		m.setOnlyRisky(tab, false)
+		index = find()
+	}
+
+	if index == -1 {
+		return false
+	}
+
+	m.getList(int(tab)).ResetFilter()
+	m.getList(int(tab)).Select(index)
+	return true
+}
+
+// jump targets are passed through the dialog's storage, keyed by their label
+func encodeJumpTargets(targets []jumpTarget) map[string]string {
+	res := make(map[string]string, len(targets))
+	for _, target := range targets {
+		res[target.label] = strconv.Itoa(int(target.tab)) + ":" + target.id
+	}
+	return res
+}
+
+func decodeJumpTarget(label string, storage map[string]string) (jumpTarget, bool) {
+	tab, id, found := strings.Cut(storage[label], ":")
+	if !found {
+		return jumpTarget{}, false
+	}
+
+	tabIndex, err := strconv.Atoi(tab)
+	if err != nil {
+		return jumpTarget{}, false
+	}
+
+	return jumpTarget{tab: tabId(tabIndex), id: id, label: label}, true
+}
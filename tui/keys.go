@@ -14,15 +14,32 @@ type navigationKeymap struct {
 	PrevItem key.Binding
 	PrevPage key.Binding
 	NextPage key.Binding
+	JumpTo   key.Binding
+	JumpBack key.Binding
 }
 
 type imgKeymap struct {
 	Create key.Binding
 	Rename key.Binding
 	// Pull        key.Binding
-	Prune       key.Binding
-	Delete      key.Binding
-	DeleteForce key.Binding
+	Prune         key.Binding
+	Delete        key.Binding
+	DeleteForce   key.Binding
+	ExploreLayers key.Binding
+	Inspect       key.Binding
+	CheckUpdates  key.Binding
+	Update        key.Binding
+	Push          key.Binding
+	Login         key.Binding
+	Logout        key.Binding
+	Browse        key.Binding
+	Save          key.Binding
+	Load          key.Binding
+	Import        key.Binding
+	ToggleTree    key.Binding
+	SBOM          key.Binding
+	Lint          key.Binding
+	OnlyRisky     key.Binding
 }
 
 type contKeymap struct {
@@ -34,9 +51,114 @@ type contKeymap struct {
 	DeleteForce     key.Binding
 	Exec            key.Binding
 	Prune           key.Binding
+	Diff            key.Binding
+	BrowseFiles     key.Binding
+	ToggleProject   key.Binding
+	Compose         key.Binding
+	RunSpec         key.Binding
+	Recreate        key.Binding
+	Export          key.Binding
+	Commit          key.Binding
+	Migrate         key.Binding
+	Lint            key.Binding
+	OnlyRisky       key.Binding
 }
 
 type volKeymap struct {
+	Create  key.Binding
+	Delete  key.Binding
+	Prune   key.Binding
+	Browse  key.Binding
+	Backup  key.Binding
+	Restore key.Binding
+	Clone   key.Binding
+	Rename  key.Binding
+}
+
+type fileTreeKeymap struct {
+	ToggleCollapse key.Binding
+}
+
+type layerExplorerKeymap struct {
+	SwitchPane   key.Binding
+	ToggleWasted key.Binding
+	Back         key.Binding
+}
+
+type imageDetailsKeymap struct {
+	CopyDockerfile key.Binding
+	Back           key.Binding
+}
+
+type runSpecKeymap struct {
+	SwitchFormat key.Binding
+	Copy         key.Binding
+	Save         key.Binding
+	Back         key.Binding
+}
+
+type containerDiffKeymap struct {
+	CycleFilter key.Binding
+	Open        key.Binding
+	Back        key.Binding
+}
+
+type containerFilesKeymap struct {
+	Open             key.Binding
+	Parent           key.Binding
+	Edit             key.Binding
+	Download         key.Binding
+	Upload           key.Binding
+	ConfirmOverwrite key.Binding
+	CancelOverwrite  key.Binding
+	Back             key.Binding
+}
+
+type formDialogKeymap struct {
+	Next       key.Binding
+	Prev       key.Binding
+	Toggle     key.Binding
+	NextOption key.Binding
+	PrevOption key.Binding
+	Submit     key.Binding
+	Cancel     key.Binding
+}
+
+type registryBrowserKeymap struct {
+	SwitchPane     key.Binding
+	Open           key.Binding
+	Pull           key.Binding
+	DeleteTag      key.Binding
+	DeleteManifest key.Binding
+	Refresh        key.Binding
+	Confirm        key.Binding
+	Back           key.Binding
+}
+
+type sbomKeymap struct {
+	Search          key.Binding
+	ExportSPDX      key.Binding
+	ExportCycloneDX key.Binding
+	Back            key.Binding
+}
+
+type lintKeymap struct {
+	Jump        key.Binding
+	MinSeverity key.Binding
+	Rescan      key.Binding
+	Back        key.Binding
+}
+
+type transferKeymap struct {
+	Back key.Binding
+}
+
+type commandOutputKeymap struct {
+	Scroll key.Binding
+	Back   key.Binding
+}
+
+type buildCacheKeymap struct {
 	Delete key.Binding
 	Prune  key.Binding
 }
@@ -66,6 +188,66 @@ var ImageKeymap = imgKeymap{
 		key.WithKeys("p"),
 		key.WithHelp("p", "Prune images"),
 	),
+	ExploreLayers: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "explore layers"),
+	),
+	Inspect: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "inspect"),
+	),
+	CheckUpdates: key.NewBinding(
+		key.WithKeys("U"),
+		key.WithHelp("U", "check for updates"),
+	),
+	Update: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "update outdated"),
+	),
+	Push: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "push"),
+	),
+	Login: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "registry login"),
+	),
+	Logout: key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "registry logout"),
+	),
+	Browse: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "browse registry"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "save to archive"),
+	),
+	Load: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "load archive"),
+	),
+	Import: key.NewBinding(
+		key.WithKeys("I"),
+		key.WithHelp("I", "import filesystem tarball"),
+	),
+	ToggleTree: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "toggle layer tree"),
+	),
+	SBOM: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "list packages (SBOM)"),
+	),
+	Lint: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "security lint"),
+	),
+	OnlyRisky: key.NewBinding(
+		key.WithKeys("!"),
+		key.WithHelp("!", "toggle only risky images"),
+	),
 }
 
 func (m imgKeymap) FullHelp() [][]key.Binding {
@@ -73,7 +255,22 @@ func (m imgKeymap) FullHelp() [][]key.Binding {
 		{m.Create,
 			m.Delete,
 			m.DeleteForce,
-			m.Prune},
+			m.Prune,
+			m.ExploreLayers,
+			m.Inspect,
+			m.CheckUpdates,
+			m.Update,
+			m.Push,
+			m.Login,
+			m.Logout,
+			m.Browse,
+			m.Save,
+			m.Load,
+			m.Import,
+			m.ToggleTree,
+			m.SBOM,
+			m.Lint,
+			m.OnlyRisky},
 	}
 }
 
@@ -81,7 +278,9 @@ func (m imgKeymap) ShortHelp() []key.Binding {
 	return []key.Binding{m.Create,
 		m.Delete,
 		m.DeleteForce,
-		m.Prune}
+		m.Prune,
+		m.ExploreLayers,
+		m.Inspect}
 
 }
 
@@ -118,6 +317,50 @@ var ContainerKeymap = contKeymap{
 		key.WithKeys("x"),
 		key.WithHelp("x", "exec"),
 	),
+	Diff: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "fs changes"),
+	),
+	BrowseFiles: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "browse files"),
+	),
+	ToggleProject: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "collapse/expand project"),
+	),
+	Compose: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "compose up/down"),
+	),
+	RunSpec: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "run command/compose"),
+	),
+	Recreate: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "recreate"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "export filesystem"),
+	),
+	Commit: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "commit to image"),
+	),
+	Migrate: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "migrate to host"),
+	),
+	Lint: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "security lint"),
+	),
+	OnlyRisky: key.NewBinding(
+		key.WithKeys("!"),
+		key.WithHelp("!", "toggle only risky containers"),
+	),
 }
 
 func (m contKeymap) FullHelp() [][]key.Binding {
@@ -125,10 +368,14 @@ func (m contKeymap) FullHelp() [][]key.Binding {
 }
 
 func (m contKeymap) ShortHelp() []key.Binding {
-	return []key.Binding{m.ToggleListAll, m.ToggleStartStop, m.Restart, m.TogglePause, m.Delete, m.DeleteForce, m.Prune, m.Exec}
+	return []key.Binding{m.ToggleListAll, m.ToggleStartStop, m.Restart, m.TogglePause, m.Delete, m.DeleteForce, m.Prune, m.Exec, m.Diff, m.BrowseFiles, m.ToggleProject, m.Compose, m.RunSpec, m.Recreate, m.Export, m.Commit, m.Migrate, m.Lint, m.OnlyRisky}
 }
 
 var VolumeKeymap = volKeymap{
+	Create: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "create"),
+	),
 	Delete: key.NewBinding(
 		key.WithKeys("d"),
 		key.WithHelp("d", "delete"),
@@ -137,6 +384,26 @@ var VolumeKeymap = volKeymap{
 		key.WithKeys("p"),
 		key.WithHelp("p", "prune"),
 	),
+	Browse: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "browse files"),
+	),
+	Backup: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "backup"),
+	),
+	Restore: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "restore"),
+	),
+	Clone: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "clone"),
+	),
+	Rename: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "rename"),
+	),
 }
 
 func (m volKeymap) FullHelp() [][]key.Binding {
@@ -144,9 +411,339 @@ func (m volKeymap) FullHelp() [][]key.Binding {
 }
 
 func (m volKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{m.Create, m.Delete, m.Prune, m.Browse, m.Backup, m.Restore, m.Clone, m.Rename}
+}
+
+var BuildCacheKeymap = buildCacheKeymap{
+	Delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete record"),
+	),
+	Prune: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "prune"),
+	),
+}
+
+func (m buildCacheKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m buildCacheKeymap) ShortHelp() []key.Binding {
 	return []key.Binding{m.Delete, m.Prune}
 }
 
+var FileTreeKeymap = fileTreeKeymap{
+	ToggleCollapse: key.NewBinding(
+		key.WithKeys(" ", "enter"),
+		key.WithHelp("space/enter", "collapse/expand"),
+	),
+}
+
+var LayerExplorerKeymap = layerExplorerKeymap{
+	SwitchPane: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "switch pane"),
+	),
+	ToggleWasted: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "toggle wasted space"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+}
+
+func (m layerExplorerKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m layerExplorerKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{NavKeymap.NextItem, NavKeymap.PrevItem, m.SwitchPane, FileTreeKeymap.ToggleCollapse, m.ToggleWasted, m.Back}
+}
+
+var ImageDetailsKeymap = imageDetailsKeymap{
+	CopyDockerfile: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy Dockerfile"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+}
+
+func (m imageDetailsKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m imageDetailsKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{NavKeymap.NextItem, NavKeymap.PrevItem, m.CopyDockerfile, m.Back}
+}
+
+var RunSpecKeymap = runSpecKeymap{
+	SwitchFormat: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "docker run/compose"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "save to file"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+}
+
+func (m runSpecKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m runSpecKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{NavKeymap.NextItem, NavKeymap.PrevItem, m.SwitchFormat, m.Copy, m.Save, m.Back}
+}
+
+var ContainerDiffKeymap = containerDiffKeymap{
+	CycleFilter: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "cycle filter"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("enter", "o"),
+		key.WithHelp("enter/o", "open file"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+}
+
+func (m containerDiffKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m containerDiffKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{NavKeymap.NextItem, NavKeymap.PrevItem, FileTreeKeymap.ToggleCollapse, m.Open, m.CycleFilter, m.Back}
+}
+
+var ContainerFilesKeymap = containerFilesKeymap{
+	Open: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "open"),
+	),
+	Parent: key.NewBinding(
+		key.WithKeys("backspace"),
+		key.WithHelp("backspace", "parent dir"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "edit"),
+	),
+	Download: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "download"),
+	),
+	Upload: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "upload here"),
+	),
+	ConfirmOverwrite: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "overwrite"),
+	),
+	CancelOverwrite: key.NewBinding(
+		key.WithKeys("n", "esc"),
+		key.WithHelp("n", "keep container version"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+}
+
+func (m containerFilesKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m containerFilesKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{NavKeymap.NextItem, NavKeymap.PrevItem, m.Open, m.Parent, m.Edit, m.Download, m.Upload, m.Back}
+}
+
+var FormDialogKeymap = formDialogKeymap{
+	Next: key.NewBinding(
+		key.WithKeys("tab", "down"),
+		key.WithHelp("tab/↓", "next field"),
+	),
+	Prev: key.NewBinding(
+		key.WithKeys("shift+tab", "up"),
+		key.WithHelp("shift+tab/↑", "prev field"),
+	),
+	Toggle: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "toggle"),
+	),
+	NextOption: key.NewBinding(
+		key.WithKeys("right"),
+		key.WithHelp("←/→", "change option"),
+	),
+	PrevOption: key.NewBinding(
+		key.WithKeys("left"),
+	),
+	Submit: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "submit"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+}
+
+func (m formDialogKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m formDialogKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{m.Next, m.Prev, m.Toggle, m.NextOption, m.Submit, m.Cancel}
+}
+
+var RegistryBrowserKeymap = registryBrowserKeymap{
+	SwitchPane: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "switch pane"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "open"),
+	),
+	Pull: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "pull tag"),
+	),
+	DeleteTag: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete tag"),
+	),
+	DeleteManifest: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "delete manifest"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh"),
+	),
+	Confirm: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "confirm"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+}
+
+func (m registryBrowserKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m registryBrowserKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{NavKeymap.NextItem, NavKeymap.PrevItem, m.SwitchPane, m.Open, m.Pull, m.DeleteTag, m.DeleteManifest, m.Refresh, m.Back}
+}
+
+var SBOMKeymap = sbomKeymap{
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	ExportSPDX: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "export SPDX"),
+	),
+	ExportCycloneDX: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "export CycloneDX"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+}
+
+func (m sbomKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m sbomKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{NavKeymap.NextItem, NavKeymap.PrevItem, m.Search, m.ExportSPDX, m.ExportCycloneDX, m.Back}
+}
+
+var LintKeymap = lintKeymap{
+	Jump: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "go to item"),
+	),
+	MinSeverity: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "cycle min severity"),
+	),
+	Rescan: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "rescan"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+}
+
+func (m lintKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m lintKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{NavKeymap.NextItem, NavKeymap.PrevItem, m.Jump, m.MinSeverity, m.Rescan, m.Back}
+}
+
+var TransferKeymap = transferKeymap{
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+}
+
+func (m transferKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m transferKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{m.Back}
+}
+
+var CommandOutputKeymap = commandOutputKeymap{
+	Scroll: key.NewBinding(
+		key.WithKeys("up", "down", "pgup", "pgdown"),
+		key.WithHelp("↑/↓", "scroll"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+}
+
+func (m commandOutputKeymap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{}
+}
+
+func (m commandOutputKeymap) ShortHelp() []key.Binding {
+	return []key.Binding{m.Scroll, m.Back}
+}
+
 var NavKeymap = navigationKeymap{
 	Enter: key.NewBinding(
 		key.WithKeys("enter"),
@@ -184,6 +781,14 @@ var NavKeymap = navigationKeymap{
 		key.WithKeys("]"),
 		key.WithHelp("]", "next page"),
 	),
+	JumpTo: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "jump to related"),
+	),
+	JumpBack: key.NewBinding(
+		key.WithKeys("backspace"),
+		key.WithHelp("backspace", "jump back"),
+	),
 }
 
 func (m navigationKeymap) FullHelp() [][]key.Binding {
@@ -191,13 +796,26 @@ func (m navigationKeymap) FullHelp() [][]key.Binding {
 }
 
 func (m navigationKeymap) ShortHelp() []key.Binding {
-	return []key.Binding{m.NextItem, m.PrevItem, m.NextTab, m.PrevTab, m.PrevPage, m.NextPage, m.Enter, m.Quit}
+	return []key.Binding{m.NextItem, m.PrevItem, m.NextTab, m.PrevTab, m.PrevPage, m.NextPage, m.JumpTo, m.JumpBack, m.Enter, m.Quit}
 }
 
 func getVolumeKeymap() []key.Binding {
 	return []key.Binding{
+		VolumeKeymap.Create,
 		VolumeKeymap.Delete,
 		VolumeKeymap.Prune,
+		VolumeKeymap.Browse,
+		VolumeKeymap.Backup,
+		VolumeKeymap.Restore,
+		VolumeKeymap.Clone,
+		VolumeKeymap.Rename,
+	}
+}
+
+func getBuildCacheKeymap() []key.Binding {
+	return []key.Binding{
+		BuildCacheKeymap.Delete,
+		BuildCacheKeymap.Prune,
 	}
 }
 
@@ -206,6 +824,21 @@ func getImageKeymap() []key.Binding {
 		ImageKeymap.Delete,
 		ImageKeymap.DeleteForce,
 		ImageKeymap.Prune,
+		ImageKeymap.ExploreLayers,
+		ImageKeymap.Inspect,
+		ImageKeymap.CheckUpdates,
+		ImageKeymap.Update,
+		ImageKeymap.Push,
+		ImageKeymap.Login,
+		ImageKeymap.Logout,
+		ImageKeymap.Browse,
+		ImageKeymap.Save,
+		ImageKeymap.Load,
+		ImageKeymap.Import,
+		ImageKeymap.ToggleTree,
+		ImageKeymap.SBOM,
+		ImageKeymap.Lint,
+		ImageKeymap.OnlyRisky,
 		// ImageKeymap.Pull,
 	}
 }
@@ -218,5 +851,16 @@ func getContainerKeymap() []key.Binding {
 		ContainerKeymap.DeleteForce,
 		ContainerKeymap.Prune,
 		ContainerKeymap.Exec,
+		ContainerKeymap.Diff,
+		ContainerKeymap.BrowseFiles,
+		ContainerKeymap.ToggleProject,
+		ContainerKeymap.Compose,
+		ContainerKeymap.RunSpec,
+		ContainerKeymap.Recreate,
+		ContainerKeymap.Export,
+		ContainerKeymap.Commit,
+		ContainerKeymap.Migrate,
+		ContainerKeymap.Lint,
+		ContainerKeymap.OnlyRisky,
 	}
 }
@@ -0,0 +1,342 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+)
+
+// a container or image with its lint findings
+type lintTarget struct {
+	tab      tabId
+	id       string
+	name     string
+	findings []dockercmd.LintFinding
+}
+
+type lintScanned struct {
+	targets []lintTarget
+}
+
+// sent by LintModel, Model closes the view and selects the item in its tab
+type lintJumpMsg struct {
+	target jumpTarget
+}
+
+// a single finding in the list, targets are shown once per finding
+type lintRow struct {
+	target  *lintTarget
+	finding dockercmd.LintFinding
+}
+
+// findings below are not worth flagging by default, low ones (eg: no cpu limit) apply to most containers
+const defaultLintSeverity = dockercmd.LintMedium
+
+// Lists risky settings of all containers and images, most severe first. Each finding comes with an explanation,
+// enter jumps to the offending item.
+type LintModel struct {
+	dockerClient dockercmd.DockerClient
+	targets      []lintTarget
+	rows         []lintRow
+	// findings below are hidden
+	minSeverity dockercmd.LintSeverity
+	cursor      int
+	busy        bool
+	help        help.Model
+	width       int
+	height      int
+}
+
+func NewLintModel(client dockercmd.DockerClient, width int, height int) LintModel {
+	m := LintModel{
+		dockerClient: client,
+		minSeverity:  defaultLintSeverity,
+		busy:         true,
+		help:         help.New(),
+		width:        width,
+		height:       height,
+	}
+	m.help.Width = width
+	return m
+}
+
+func (m LintModel) Init() tea.Cmd {
+	client := m.dockerClient
+	return func() tea.Msg {
+		return lintScanned{targets: lintAll(client)}
+	}
+}
+
+func (m LintModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case lintScanned:
+		m.busy = false
+		m.targets = msg.targets
+		m.buildRows()
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.help.Width = msg.Width
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, LintKeymap.Back):
+			return m, closeView
+
+		case m.busy:
+			return m, nil
+
+		case key.Matches(msg, NavKeymap.NextItem):
+			m.cursor = min(m.cursor+1, max(len(m.rows)-1, 0))
+
+		case key.Matches(msg, NavKeymap.PrevItem):
+			m.cursor = max(m.cursor-1, 0)
+
+		case key.Matches(msg, LintKeymap.MinSeverity):
+			m.minSeverity = (m.minSeverity + 1) % (dockercmd.LintHigh + 1)
+			m.buildRows()
+
+		case key.Matches(msg, LintKeymap.Rescan):
+			m.busy = true
+			return m, m.Init()
+
+		case key.Matches(msg, LintKeymap.Jump):
+			if len(m.rows) > 0 {
+				target := m.rows[m.cursor].target
+				jump := jumpTarget{tab: target.tab, id: target.id, label: target.name}
+				return m, func() tea.Msg { return lintJumpMsg{target: jump} }
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m LintModel) View() string {
+	title := viewTitleStyle.Render("Security Lint")
+
+	if m.busy {
+		return lipgloss.JoinVertical(lipgloss.Left, title, "Inspecting containers and images...")
+	}
+
+	var all []dockercmd.LintFinding
+	risky := map[tabId]int{}
+	for _, target := range m.targets {
+		all = append(all, target.findings...)
+		if isRisky(target.findings) {
+			risky[target.tab]++
+		}
+	}
+
+	var summary strings.Builder
+	addEntry(&summary, "Findings: ", cmp.Or(dockercmd.LintSummary(all), "none"))
+	addEntry(&summary, "Risky: ", fmt.Sprintf("%d containers, %d images", risky[containers], risky[images]))
+	addEntry(&summary, "Showing: ", m.minSeverity.String()+" severity and above")
+
+	rows := make([]string, len(m.rows))
+	for i, row := range m.rows {
+		kind := "image"
+		if row.target.tab == containers {
+			kind = "container"
+		}
+		rows[i] = fmt.Sprintf("%-6s  %-9s  %-30s  %s", row.finding.Severity, kind, row.target.name, row.finding.Summary)
+	}
+
+	height := max(m.height-18, 5)
+	list := renderCursorList(rows, m.cursor, height, max(m.width-4, 20), "nothing risky found")
+
+	var details string
+	if len(m.rows) > 0 {
+		row := m.rows[m.cursor]
+		details = lipgloss.JoinVertical(
+			lipgloss.Left,
+			lintSeverityStyle(row.finding.Severity).Render(strings.ToUpper(row.finding.Severity.String()))+"  "+row.target.name+" "+row.finding.Summary,
+			lipgloss.NewStyle().Width(max(m.width-4, 20)).Render(row.finding.Explanation),
+		)
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		summary.String(),
+		list,
+		"",
+		details,
+		"",
+		m.help.View(LintKeymap),
+	)
+}
+
+// helpers
+
+func (m *LintModel) buildRows() {
+	m.rows = nil
+	for i := range m.targets {
+		for _, finding := range m.targets[i].findings {
+			if finding.Severity >= m.minSeverity {
+				m.rows = append(m.rows, lintRow{target: &m.targets[i], finding: finding})
+			}
+		}
+	}
+
+	// targets are ordered by their worst finding, the rows by severity first
+	slices.SortStableFunc(m.rows, func(a lintRow, b lintRow) int {
+		return cmp.Compare(b.finding.Severity, a.finding.Severity)
+	})
+	m.cursor = max(min(m.cursor, len(m.rows)-1), 0)
+}
+
+func lintSeverityStyle(severity dockercmd.LintSeverity) lipgloss.Style {
+	switch severity {
+	case dockercmd.LintHigh:
+		return lintHighStyle
+	case dockercmd.LintMedium:
+		return lintMediumStyle
+	default:
+		return lintLowStyle
+	}
+}
+
+// lints every listed container and every image, previous results are replaced since containers can be updated (eg:
+// docker update --memory) without changing their id
+func lintAll(client dockercmd.DockerClient) []lintTarget {
+	var res []lintTarget
+
+	for _, c := range client.ListContainers(false) {
+		findings, err := client.LintContainer(c.ID)
+		if err != nil {
+			// removed meanwhile
+			continue
+		}
+		setLintFindings(c.ID, findings)
+		res = append(res, lintTarget{tab: containers, id: c.ID, name: dockercmd.ContainerName(c), findings: findings})
+	}
+
+	for _, img := range client.ListImages() {
+		findings, err := client.LintImage(img.ID)
+		if err != nil {
+			continue
+		}
+		setLintFindings(imageLintKey(img), findings)
+		res = append(res, lintTarget{tab: images, id: img.ID, name: imageLintName(img), findings: findings})
+	}
+
+	slices.SortStableFunc(res, func(a lintTarget, b lintTarget) int {
+		severityA, okA := dockercmd.MaxLintSeverity(a.findings)
+		severityB, okB := dockercmd.MaxLintSeverity(b.findings)
+		if okA != okB {
+			if okA {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(severityB, severityA); c != 0 {
+			return c
+		}
+		return strings.Compare(a.name, b.name)
+	})
+
+	return res
+}
+
+// the latest tag check depends on the tags, so retagging an image has to lint it again
+func imageLintKey(img image.Summary) string {
+	return img.ID + " " + strings.Join(img.RepoTags, " ")
+}
+
+func imageLintName(img image.Summary) string {
+	if tags := pushableTags(img.RepoTags); len(tags) > 0 {
+		return tags[0]
+	}
+	return strings.TrimPrefix(img.ID, "sha256:")[:12]
+}
+
+type lintEntry struct {
+	findings []dockercmd.LintFinding
+	lintedAt time.Time
+}
+
+// containers keep their id when their settings change (eg: docker update --memory), so the risky filter lints them
+// again after this long. An image's config never changes, its entries are kept
+const containerLintMaxAge = 30 * time.Second
+
+func getLintFindings(key string) ([]dockercmd.LintFinding, bool) {
+	entry, ok := getLintEntry(key)
+	return entry.findings, ok
+}
+
+func getLintEntry(key string) (lintEntry, bool) {
+	lintFindingsMap_Mutex.Lock()
+	defer lintFindingsMap_Mutex.Unlock()
+
+	entry, ok := lintFindingsMap[key]
+	return entry, ok
+}
+
+func setLintFindings(key string, findings []dockercmd.LintFinding) {
+	lintFindingsMap_Mutex.Lock()
+	defer lintFindingsMap_Mutex.Unlock()
+
+	lintFindingsMap[key] = lintEntry{findings: findings, lintedAt: time.Now()}
+}
+
+// findings of key, linted with fn if there are none or they are older than maxAge (0 never expires them). ok is
+// false if fn failed
+func lintFindingsOrLint(key string, maxAge time.Duration, fn func() ([]dockercmd.LintFinding, error)) ([]dockercmd.LintFinding, bool) {
+	if entry, ok := getLintEntry(key); ok && (maxAge == 0 || time.Since(entry.lintedAt) < maxAge) {
+		return entry.findings, true
+	}
+
+	findings, err := fn()
+	if err != nil {
+		return nil, false
+	}
+	setLintFindings(key, findings)
+	return findings, true
+}
+
+// containers with a finding of at least defaultLintSeverity, containers that can not be inspected are dropped
+func riskyContainers(client dockercmd.DockerClient, list []types.Container) []types.Container {
+	return slices.DeleteFunc(list, func(c types.Container) bool {
+		findings, ok := lintFindingsOrLint(c.ID, containerLintMaxAge, func() ([]dockercmd.LintFinding, error) { return client.LintContainer(c.ID) })
+		return !ok || !isRisky(findings)
+	})
+}
+
+// images with a finding of at least defaultLintSeverity, images that can not be inspected are dropped
+func riskyImages(client dockercmd.DockerClient, list []image.Summary) []image.Summary {
+	return slices.DeleteFunc(list, func(img image.Summary) bool {
+		findings, ok := lintFindingsOrLint(imageLintKey(img), 0, func() ([]dockercmd.LintFinding, error) { return client.LintImage(img.ID) })
+		return !ok || !isRisky(findings)
+	})
+}
+
+func isRisky(findings []dockercmd.LintFinding) bool {
+	severity, ok := dockercmd.MaxLintSeverity(findings)
+	return ok && severity >= defaultLintSeverity
+}
+
+// toggles listing only items with lint findings in the images or containers tab
+func (m *Model) setOnlyRisky(tab tabId, onlyRisky bool) {
+	m.TabContent[tab].onlyRisky = onlyRisky
+
+	// the item count in the status bar tells the filter is on
+	if onlyRisky {
+		m.getList(int(tab)).SetStatusBarItemName("risky item", "risky items")
+	} else {
+		m.getList(int(tab)).SetStatusBarItemName("item", "items")
+	}
+
+	*m = m.updateContent(int(tab))
+}
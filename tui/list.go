@@ -2,16 +2,36 @@ package tui
 
 import (
 	"slices"
+	"strings"
+	"time"
 
 	"github.com/ajayd-san/gomanagedocker/dockercmd"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
 )
 
 type listModel struct {
 	list        list.Model
 	previousIds map[string]struct{}
+	// compose projects whose containers are hidden in the containers tab
+	collapsedProjects map[string]bool
+	// images tab shows images as a tree of the layers they share
+	imageTree bool
+	// images with their shared size for the tree. Calculating it makes the daemon walk every layer, so it is only
+	// listed again when the images (ids and tags) change
+	sharedSizeImages []image.Summary
+	sharedSizeKey    string
+	// volumes tab: containers mounting each volume. Finding them lists every container, so they are only listed again
+	// when the volumes change or after volumeMountsMaxAge (containers can be created outside of this app)
+	volumeMounts    map[string][]dockercmd.VolumeMount
+	volumeMountsKey string
+	volumeMountsAt  time.Time
+	// images and containers tabs only list items with lint findings
+	onlyRisky bool
 }
 
 func (m listModel) Init() tea.Cmd {
@@ -38,7 +58,11 @@ func (m listModel) View() string {
 func InitList(tab tabId) listModel {
 
 	items := make([]list.Item, 0)
-	m := listModel{list: list.New(items, list.NewDefaultDelegate(), 10, 30), previousIds: make(map[string]struct{})}
+	m := listModel{
+		list:              list.New(items, list.NewDefaultDelegate(), 10, 30),
+		previousIds:       make(map[string]struct{}),
+		collapsedProjects: make(map[string]bool),
+	}
 
 	m.list.SetShowTitle(false)
 	m.list.DisableQuitKeybindings()
@@ -53,6 +77,11 @@ func InitList(tab tabId) listModel {
 		m.list.AdditionalFullHelpKeys = getContainerKeymap
 	case volumes:
 		m.list.AdditionalFullHelpKeys = getVolumeKeymap
+	case buildCache:
+		m.list.AdditionalFullHelpKeys = getBuildCacheKeymap
+	case networks:
+		// read only, jumping to a network's containers is part of NavKeymap
+		m.list.AdditionalFullHelpKeys = nil
 	}
 	return m
 }
@@ -73,14 +102,26 @@ func (m listModel) updateTab(dockerClient dockercmd.DockerClient, id tabId) list
 	var newlist []dockerRes
 	switch id {
 	case images:
-		newImgs := dockerClient.ListImages()
-		newlist = makeImageItems(newImgs)
+		if m.onlyRisky {
+			// the tree would need the images the risky ones build on, so the filter shows a flat list
+			newlist = makeImageItems(riskyImages(dockerClient, dockerClient.ListImages()))
+		} else if m.imageTree {
+			newlist = makeImageTreeItems(dockercmd.BuildImageTree(m.listImagesWithLayers(dockerClient)))
+		} else {
+			newImgs := dockerClient.ListImages()
+			newlist = makeImageItems(newImgs)
+		}
 	case containers:
 		newContainers := dockerClient.ListContainers(showContainerSize)
-		newlist = makeContainerItems(newContainers)
+		// taken before the risky filter, which would skew the counts
+		projectMembers := composeProjectMembers(dockerClient, newContainers)
+		if m.onlyRisky {
+			newContainers = riskyContainers(dockerClient, newContainers)
+		}
+		newlist = makeContainerItems(newContainers, projectMembers, m.collapsedProjects)
 
-		for _, newContainer := range newlist {
-			id := newContainer.getId()
+		for _, newContainer := range newContainers {
+			id := newContainer.ID
 			if _, ok := m.previousIds[id]; !ok {
 				go func() {
 					containerInfo, err := dockerClient.InspectContainer(id)
@@ -96,29 +137,69 @@ func (m listModel) updateTab(dockerClient dockercmd.DockerClient, id tabId) list
 	case volumes:
 		//TODO: handle errors
 		newVolumes, _ := dockerClient.ListVolumes()
-		newlist = makeVolumeItem(newVolumes)
+		newlist = makeVolumeItem(newVolumes, m.listVolumeMounts(dockerClient, newVolumes))
+	case buildCache:
+		//TODO: handle errors
+		newRecords, _ := dockerClient.ListBuildCache()
+		newlist = makeBuildCacheItems(newRecords)
+	case networks:
+		//TODO: handle errors
+		newNetworks, _ := dockerClient.ListNetworks()
+		newlist = makeNetworkItems(newNetworks)
 	}
 
 	comparisionFunc := func(a dockerRes, b list.Item) bool {
 		switch id {
 		case images:
-			newA := a.(imageItem)
-			newB := b.(imageItem)
+			switch newA := a.(type) {
+			case imageItem:
+				newB, ok := b.(imageItem)
+				if !ok || newA.Containers != newB.Containers || newA.ID != newB.ID || newA.tree != newB.tree {
+					return false
+				}
+			case imageChainItem:
+				newB, ok := b.(imageChainItem)
+				if !ok || newA.tree != newB.tree {
+					return false
+				}
+			}
+		case containers:
+			switch newA := a.(type) {
+			case containerItem:
+				newB, ok := b.(containerItem)
+				if !ok || newA.ID != newB.ID || newA.State != newB.State {
+					return false
+				}
+			case composeProjectItem:
+				newB, ok := b.(composeProjectItem)
+				if !ok || newA.name != newB.name || newA.collapsed != newB.collapsed ||
+					newA.running() != newB.running() || len(newA.members) != len(newB.members) || len(newA.containers) != len(newB.containers) {
+					return false
+				}
+			}
+		case volumes:
+			newA := a.(VolumeItem)
+			newB := b.(VolumeItem)
 
-			if newA.Containers != newB.Containers {
+			if newA.Name != newB.Name || newA.mountsKnown != newB.mountsKnown || !slices.Equal(newA.mounts, newB.mounts) {
 				return false
 			}
-		case containers:
-			newA := a.(containerItem)
-			newB := b.(containerItem)
 
-			if newA.State != newB.State {
+		case buildCache:
+			newA := a.(buildCacheItem)
+			newB := b.(buildCacheItem)
+
+			if newA.ID != newB.ID || newA.InUse != newB.InUse || newA.UsageCount != newB.UsageCount || newA.Size != newB.Size {
 				return false
 			}
-		case volumes:
-			// newA := a.(VolumeItem)
-			// newB := b.(VolumeItem)
 
+		case networks:
+			newA := a.(networkItem)
+			newB := b.(networkItem)
+
+			if newA.ID != newB.ID || newA.Name != newB.Name {
+				return false
+			}
 		}
 
 		return true
@@ -136,5 +217,94 @@ func (m listModel) updateTab(dockerClient dockercmd.DockerClient, id tabId) list
 func (m *listModel) updateIds(newlistItems []dockerRes) {
 	for _, item := range newlistItems {
 		m.previousIds[item.getId()] = struct{}{}
+
+		// containers of collapsed projects are not list items themselves
+		if project, ok := item.(composeProjectItem); ok {
+			for _, c := range project.containers {
+				m.previousIds[c.ID] = struct{}{}
+			}
+		}
+	}
+}
+
+const volumeMountsMaxAge = 5 * time.Second
+
+// containers mounting each volume, nil on errors so volumes are not marked orphaned
+func (m *listModel) listVolumeMounts(dockerClient dockercmd.DockerClient, volumes []*volume.Volume) map[string][]dockercmd.VolumeMount {
+	var key strings.Builder
+	for _, vol := range volumes {
+		key.WriteString(vol.Name + "\n")
+	}
+
+	if key.String() == m.volumeMountsKey && time.Since(m.volumeMountsAt) < volumeMountsMaxAge {
+		return m.volumeMounts
+	}
+
+	mounts, err := dockerClient.VolumeMounts()
+	if err != nil {
+		// asked again on the next update
+		m.volumeMountsKey = ""
+		return nil
+	}
+
+	m.volumeMounts = mounts
+	m.volumeMountsKey = key.String()
+	m.volumeMountsAt = time.Now()
+	return mounts
+}
+
+// all containers of each listed compose project, the running counts include stopped containers. Listing all
+// containers already returns them, otherwise they are listed separately, but only if there are compose containers at
+// all since this runs on every tick. nil on errors, the counts fall back to the listed containers
+func composeProjectMembers(dockerClient dockercmd.DockerClient, listed []types.Container) map[string][]types.Container {
+	if !slices.ContainsFunc(listed, func(c types.Container) bool { return c.Labels[dockercmd.ComposeProjectLabel] != "" }) {
+		return nil
+	}
+
+	if dockerClient.IsListingAllContainers() {
+		return dockercmd.GroupByComposeProject(listed)
 	}
+
+	members, _ := dockerClient.ComposeContainers()
+	return members
+}
+
+// images with their shared size and layer ids, layers are only inspected for images that are new to imageLayersMap
+func (m *listModel) listImagesWithLayers(dockerClient dockercmd.DockerClient) ([]image.Summary, map[string][]string) {
+	images := dockerClient.ListImages()
+
+	var key strings.Builder
+	for _, img := range images {
+		key.WriteString(img.ID + " " + strings.Join(img.RepoTags, " ") + "\n")
+	}
+
+	if key.String() == m.sharedSizeKey {
+		images = m.sharedSizeImages
+	} else if withSharedSize, err := dockerClient.ListImagesWithSharedSize(); err == nil {
+		images = withSharedSize
+		m.sharedSizeImages = withSharedSize
+		m.sharedSizeKey = key.String()
+	}
+	// on errors the tree still works without shared sizes, they are asked for again on the next update
+
+	layers := make(map[string][]string, len(images))
+
+	imageLayersMap_Mutex.Lock()
+	defer imageLayersMap_Mutex.Unlock()
+
+	for _, img := range images {
+		ids, ok := imageLayersMap[img.ID]
+		if !ok {
+			// images that can not be inspected (eg: removed meanwhile) end up as roots
+			var err error
+			ids, err = dockerClient.ImageLayerIDs(img.ID)
+			if err != nil {
+				continue
+			}
+			imageLayersMap[img.ID] = ids
+		}
+		layers[img.ID] = ids
+	}
+
+	return images, layers
 }
@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// the steps of a running migration, or the final result
+type migrationUpdate struct {
+	steps []dockercmd.MigrationStep
+	done  bool
+	err   error
+	// channel the update was read from, so updates of a closed view can be told apart
+	updates chan migrationUpdate
+}
+
+// Shows every step of a container migration. Like TransferModel, closing the view does not stop the migration, Model
+// keeps draining its updates and reports a failure as a dialog.
+type MigrationModel struct {
+	name        string
+	host        dockercmd.DockerHost
+	updates     chan migrationUpdate
+	steps       []dockercmd.MigrationStep
+	done        bool
+	err         error
+	progressBar progress.Model
+	help        help.Model
+}
+
+func NewMigrationModel(client dockercmd.DockerClient, id string, name string, host dockercmd.DockerHost, opts dockercmd.MigrateOpts, width int) MigrationModel {
+	bar := progress.New(progress.WithDefaultGradient())
+	bar.Width = min(width-40, 60)
+
+	return MigrationModel{
+		name:        name,
+		host:        host,
+		updates:     startMigration(client, id, host, opts),
+		progressBar: bar,
+		help:        help.New(),
+	}
+}
+
+func (m MigrationModel) Init() tea.Cmd {
+	return waitForMigration(m.updates)
+}
+
+func (m MigrationModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case migrationUpdate:
+		if msg.updates != m.updates {
+			return m, nil
+		}
+
+		if msg.done {
+			m.done = true
+			m.err = msg.err
+			return m, nil
+		}
+
+		m.steps = msg.steps
+		return m, waitForMigration(m.updates)
+
+	case tea.KeyMsg:
+		if key.Matches(msg, TransferKeymap.Back) {
+			return m, closeView
+		}
+	}
+
+	return m, nil
+}
+
+func (m MigrationModel) View() string {
+	var res strings.Builder
+
+	for _, step := range m.steps {
+		var line string
+		switch step.State {
+		case dockercmd.MigrationPending:
+			line = mutedStyle.Render("  " + step.Name)
+		case dockercmd.MigrationRunning:
+			line = "▸ " + step.Name
+		case dockercmd.MigrationDone:
+			line = fileAddedStyle.Render("✓ " + step.Name)
+		case dockercmd.MigrationSkipped:
+			line = fileAddedStyle.Render("✓ "+step.Name) + mutedStyle.Render(" (skipped)")
+		case dockercmd.MigrationFailed:
+			line = fileRemovedStyle.Render("✗ " + step.Name)
+		}
+
+		if step.Resumed {
+			line += mutedStyle.Render(" (previous run)")
+		}
+		res.WriteString(line + "\n")
+
+		if step.State == dockercmd.MigrationRunning && step.Transferred > 0 {
+			res.WriteString("  " + renderTransfer(m.progressBar, transferUpdate{transferred: step.Transferred, total: step.Total}) + "\n")
+		}
+		if step.Note != "" {
+			res.WriteString(mutedStyle.Render("  "+step.Note) + "\n")
+		}
+		if step.Err != nil {
+			res.WriteString(fileRemovedStyle.Render("  "+step.Err.Error()) + "\n")
+		}
+	}
+
+	var status string
+	switch {
+	case !m.done && len(m.steps) == 0:
+		status = "connecting to " + m.host.String() + "..."
+	case !m.done:
+		status = "in progress, esc continues in the background"
+	case m.err != nil:
+		status = fileRemovedStyle.Render("failed: "+m.err.Error()) + "\nmigrating again continues with the unfinished steps"
+	default:
+		status = fileAddedStyle.Render(m.name + " now runs on " + m.host.String())
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		viewTitleStyle.Render(fmt.Sprintf("Migrating %s to %s", m.name, m.host)),
+		res.String(),
+		status,
+		"",
+		m.help.View(TransferKeymap),
+	)
+}
+
+// runs the migration on a seperate goroutine, updates are delivered by the cmd returned from waitForMigration
+func startMigration(client dockercmd.DockerClient, id string, host dockercmd.DockerHost, opts dockercmd.MigrateOpts) chan migrationUpdate {
+	updates := make(chan migrationUpdate, 100)
+
+	go func() {
+		var lastStates []dockercmd.MigrationStepState
+		err := client.MigrateContainer(id, host, opts, func(steps []dockercmd.MigrationStep) {
+			states := make([]dockercmd.MigrationStepState, len(steps))
+			for i, step := range steps {
+				states[i] = step.State
+			}
+
+			// byte counts are dropped when the ui is lagging behind, state changes never are
+			if slices.Equal(states, lastStates) && len(updates) > 0 {
+				return
+			}
+			lastStates = states
+			updates <- migrationUpdate{steps: steps, updates: updates}
+		})
+		updates <- migrationUpdate{done: true, err: err, updates: updates}
+	}()
+
+	return updates
+}
+
+func waitForMigration(updates chan migrationUpdate) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
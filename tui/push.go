@@ -0,0 +1,174 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// a layer update of a running push, or the final result
+type pushUpdate struct {
+	layer dockercmd.LayerProgress
+	done  bool
+	err   error
+	// channel the update was read from, so updates of a closed view can be told apart
+	updates chan pushUpdate
+}
+
+// Shows the state of every layer while an image is pushed. Like TransferModel, closing the view does not stop the
+// push, Model keeps draining its updates and reports a failure as a dialog.
+type PushModel struct {
+	ref     string
+	updates chan pushUpdate
+	// layer ids in the order the registry reported them
+	layerIds []string
+	layers   map[string]dockercmd.LayerProgress
+	// general status lines, eg: the pushed digest
+	messages    []string
+	done        bool
+	err         error
+	progressBar progress.Model
+	help        help.Model
+}
+
+func NewPushModel(client dockercmd.DockerClient, source string, ref string, width int) PushModel {
+	bar := progress.New(progress.WithDefaultGradient())
+	bar.Width = min(width-70, 60)
+
+	return PushModel{
+		ref:         ref,
+		updates:     startPush(client, source, ref),
+		layers:      make(map[string]dockercmd.LayerProgress),
+		progressBar: bar,
+		help:        help.New(),
+	}
+}
+
+func (m PushModel) Init() tea.Cmd {
+	return waitForPush(m.updates)
+}
+
+func (m PushModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pushUpdate:
+		if msg.updates != m.updates {
+			return m, nil
+		}
+
+		if msg.done {
+			m.done = true
+			m.err = msg.err
+			return m, nil
+		}
+
+		if msg.layer.ID == "" {
+			m.messages = append(m.messages, msg.layer.Status)
+		} else {
+			if _, ok := m.layers[msg.layer.ID]; !ok {
+				m.layerIds = append(m.layerIds, msg.layer.ID)
+			}
+			m.layers[msg.layer.ID] = msg.layer
+		}
+
+		return m, waitForPush(m.updates)
+
+	case tea.KeyMsg:
+		if key.Matches(msg, TransferKeymap.Back) {
+			return m, closeView
+		}
+	}
+
+	return m, nil
+}
+
+func (m PushModel) View() string {
+	var res strings.Builder
+
+	for _, id := range m.layerIds {
+		layer := m.layers[id]
+
+		status := layer.Status
+		switch {
+		case layer.Status == "Pushed" || layer.Status == "Layer already exists" || strings.HasPrefix(layer.Status, "Mounted from"):
+			status = fileAddedStyle.Render(status)
+		case layer.Total > 0:
+			percent := min(float64(layer.Current)/float64(layer.Total), 1)
+			status = fmt.Sprintf("%-10s %s  %s / %s", status, m.progressBar.ViewAs(percent), humanSize(layer.Current), humanSize(layer.Total))
+		default:
+			status = mutedStyle.Render(status)
+		}
+
+		res.WriteString(fmt.Sprintf("%-14s %s\n", id, status))
+	}
+
+	// the first message only repeats the repository, the last ones are what matters (eg: the digest)
+	messages := m.messages
+	if len(messages) > 3 {
+		messages = messages[len(messages)-3:]
+	}
+	for _, message := range messages {
+		res.WriteString("\n" + message)
+	}
+
+	var status string
+	switch {
+	case !m.done:
+		status = fmt.Sprintf("pushing %d layer(s), esc continues in the background", len(m.layerIds))
+	case m.err != nil:
+		status = fileRemovedStyle.Render("failed: " + pushErrorString(m.err))
+	default:
+		status = fileAddedStyle.Render("pushed " + m.ref)
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		viewTitleStyle.Render("Pushing "+m.ref),
+		res.String(),
+		"",
+		status,
+		"",
+		m.help.View(TransferKeymap),
+	)
+}
+
+// runs the push on a seperate goroutine, updates are delivered by the cmd returned from waitForPush
+func startPush(client dockercmd.DockerClient, source string, ref string) chan pushUpdate {
+	updates := make(chan pushUpdate, 100)
+
+	go func() {
+		err := client.PushImage(source, ref, func(layer dockercmd.LayerProgress) {
+			updates <- pushUpdate{layer: layer, updates: updates}
+		})
+		updates <- pushUpdate{done: true, err: err, updates: updates}
+	}()
+
+	return updates
+}
+
+func waitForPush(updates chan pushUpdate) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+// auth errors get a hint on how to log in
+func pushErrorString(err error) string {
+	if dockercmd.IsRegistryAuthError(err) {
+		return err.Error() + ", press " + ImageKeymap.Login.Help().Key + " on the images tab to log in"
+	}
+	return err.Error()
+}
+
+// tags to offer in the push dialog, `<none>:<none>` can not be pushed
+func pushableTags(repoTags []string) []string {
+	return slices.DeleteFunc(slices.Clone(repoTags), func(tag string) bool {
+		return tag == "<none>:<none>"
+	})
+}
@@ -0,0 +1,374 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type registryPane int
+
+const (
+	registryReposPane registryPane = iota
+	registryTagsPane
+)
+
+type registryConnected struct {
+	client *dockercmd.RegistryClient
+	repos  []string
+	err    error
+}
+
+type registryTagsLoaded struct {
+	repo string
+	tags []string
+	err  error
+}
+
+type registryManifestLoaded struct {
+	details *dockercmd.ManifestDetails
+	err     error
+}
+
+// result of a pull or delete, reloadTags is set when the tags of the current repository changed
+type registryActionDone struct {
+	status     string
+	err        error
+	reloadTags bool
+}
+
+// Browses a v2 registry: repositories, their tags and the manifest of a tag. Tags can be pulled and deleted.
+type RegistryBrowserModel struct {
+	dockerClient dockercmd.DockerClient
+	server       string
+	client       *dockercmd.RegistryClient
+	repos        []string
+	tags         []string
+	// repository the tags belong to
+	repo       string
+	details    *dockercmd.ManifestDetails
+	pane       registryPane
+	repoCursor int
+	tagCursor  int
+	err        error
+	status     string
+	busy       bool
+	// set while waiting for y/n, either "tag" or "manifest"
+	pendingDelete string
+	help          help.Model
+	width         int
+	height        int
+}
+
+func NewRegistryBrowserModel(client dockercmd.DockerClient, server string, width int, height int) RegistryBrowserModel {
+	m := RegistryBrowserModel{
+		dockerClient: client,
+		server:       server,
+		help:         help.New(),
+		width:        width,
+		height:       height,
+		busy:         true,
+	}
+	m.help.Width = width
+	return m
+}
+
+func (m RegistryBrowserModel) Init() tea.Cmd {
+	server := m.server
+	return func() tea.Msg {
+		client, err := dockercmd.NewRegistryClient(server)
+		if err != nil {
+			return registryConnected{err: err}
+		}
+
+		repos, err := client.Catalog()
+		return registryConnected{client: client, repos: repos, err: err}
+	}
+}
+
+func (m RegistryBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case registryConnected:
+		m.busy = false
+		m.client = msg.client
+		m.repos = msg.repos
+		m.err = msg.err
+		m.repoCursor = 0
+
+	case registryTagsLoaded:
+		m.busy = false
+		if msg.err != nil {
+			m.status = fileRemovedStyle.Render(msg.err.Error())
+			return m, nil
+		}
+
+		m.repo = msg.repo
+		m.tags = msg.tags
+		m.details = nil
+		m.tagCursor = min(m.tagCursor, max(len(m.tags)-1, 0))
+
+	case registryManifestLoaded:
+		m.busy = false
+		if msg.err != nil {
+			m.status = fileRemovedStyle.Render(msg.err.Error())
+			return m, nil
+		}
+		m.details = msg.details
+		m.status = ""
+
+	case registryActionDone:
+		m.busy = false
+		if msg.err != nil {
+			m.status = fileRemovedStyle.Render(msg.err.Error())
+		} else {
+			m.status = fileAddedStyle.Render(msg.status)
+		}
+
+		if msg.reloadTags {
+			m.busy = true
+			return m, m.loadTags(m.repo)
+		}
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.help.Width = msg.Width
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m RegistryBrowserModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.pendingDelete != "" {
+		kind := m.pendingDelete
+		m.pendingDelete = ""
+
+		if !key.Matches(msg, RegistryBrowserKeymap.Confirm) {
+			m.status = "delete cancelled"
+			return m, nil
+		}
+
+		m.busy = true
+		return m, m.delete(kind)
+	}
+
+	switch {
+	case key.Matches(msg, RegistryBrowserKeymap.Back):
+		return m, closeView
+
+	case m.client == nil || m.busy:
+		return m, nil
+
+	case key.Matches(msg, RegistryBrowserKeymap.SwitchPane):
+		if m.pane == registryReposPane && len(m.tags) > 0 {
+			m.pane = registryTagsPane
+		} else {
+			m.pane = registryReposPane
+		}
+
+	case key.Matches(msg, NavKeymap.NextItem):
+		m.moveCursor(1)
+
+	case key.Matches(msg, NavKeymap.PrevItem):
+		m.moveCursor(-1)
+
+	case key.Matches(msg, RegistryBrowserKeymap.Open):
+		if m.pane == registryReposPane && len(m.repos) > 0 {
+			m.busy = true
+			m.pane = registryTagsPane
+			m.tagCursor = 0
+			return m, m.loadTags(m.repos[m.repoCursor])
+		}
+
+		if tag, ok := m.selectedTag(); ok {
+			m.busy = true
+			client, repo := m.client, m.repo
+			return m, func() tea.Msg {
+				details, err := client.Manifest(repo, tag)
+				return registryManifestLoaded{details: details, err: err}
+			}
+		}
+
+	case key.Matches(msg, RegistryBrowserKeymap.Refresh):
+		m.busy = true
+		if m.pane == registryTagsPane && m.repo != "" {
+			return m, m.loadTags(m.repo)
+		}
+		m.tags = nil
+		m.details = nil
+		m.repo = ""
+		return m, m.Init()
+
+	case key.Matches(msg, RegistryBrowserKeymap.Pull):
+		tag, ok := m.selectedTag()
+		if !ok {
+			return m, nil
+		}
+
+		m.busy = true
+		ref := m.client.ImageRef(m.repo, tag)
+		m.status = "pulling " + ref + "..."
+		client := m.dockerClient
+		return m, func() tea.Msg {
+			err := client.PullImage(ref, io.Discard)
+			return registryActionDone{status: "pulled " + ref, err: err}
+		}
+
+	case key.Matches(msg, RegistryBrowserKeymap.DeleteTag):
+		if tag, ok := m.selectedTag(); ok {
+			m.pendingDelete = "tag"
+			m.status = fmt.Sprintf("Delete tag %s:%s? (y/n)", m.repo, tag)
+		}
+
+	case key.Matches(msg, RegistryBrowserKeymap.DeleteManifest):
+		if _, ok := m.selectedTag(); ok && m.details != nil {
+			m.pendingDelete = "manifest"
+			m.status = fmt.Sprintf("Delete manifest %s and every tag pointing to it? (y/n)", m.details.Digest)
+		} else if ok {
+			m.status = "open the tag first to see its manifest"
+		}
+	}
+
+	return m, nil
+}
+
+func (m RegistryBrowserModel) View() string {
+	title := viewTitleStyle.Render("Registry: " + m.server)
+
+	if m.err != nil {
+		return lipgloss.JoinVertical(lipgloss.Left, title, "Error: "+m.err.Error(), "", m.help.View(RegistryBrowserKeymap))
+	}
+
+	if m.client == nil {
+		return lipgloss.JoinVertical(lipgloss.Left, title, "Connecting...")
+	}
+
+	reposStyle, tagsStyle := viewActivePaneStyle, viewPaneStyle
+	if m.pane == registryTagsPane {
+		reposStyle, tagsStyle = viewPaneStyle, viewActivePaneStyle
+	}
+
+	listWidth := max(m.width/4, 20)
+	detailsWidth := max(m.width-2*listWidth-12, 20)
+	height := m.paneHeight()
+
+	repos := reposStyle.Width(listWidth).Height(height).Render(renderCursorList(m.repos, m.repoCursor, height, listWidth, "no repositories"))
+	tags := tagsStyle.Width(listWidth).Height(height).Render(renderCursorList(m.tags, m.tagCursor, height, listWidth, "enter on a repository lists its tags"))
+	details := viewPaneStyle.Width(detailsWidth).Height(height).Render(m.detailsView())
+
+	status := m.status
+	if m.busy && status == "" {
+		status = "loading..."
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		lipgloss.JoinHorizontal(lipgloss.Top, repos, tags, details),
+		status,
+		m.help.View(RegistryBrowserKeymap),
+	)
+}
+
+// helpers
+
+func (m RegistryBrowserModel) paneHeight() int {
+	return max(m.height-10, 5)
+}
+
+func (m *RegistryBrowserModel) moveCursor(delta int) {
+	if m.pane == registryReposPane {
+		m.repoCursor = max(min(m.repoCursor+delta, len(m.repos)-1), 0)
+	} else {
+		m.tagCursor = max(min(m.tagCursor+delta, len(m.tags)-1), 0)
+		// the details belong to the previously selected tag
+		m.details = nil
+	}
+}
+
+func (m RegistryBrowserModel) selectedTag() (string, bool) {
+	if m.pane != registryTagsPane || len(m.tags) == 0 {
+		return "", false
+	}
+	return m.tags[m.tagCursor], true
+}
+
+func (m RegistryBrowserModel) loadTags(repo string) tea.Cmd {
+	client := m.client
+	return func() tea.Msg {
+		tags, err := client.Tags(repo)
+		return registryTagsLoaded{repo: repo, tags: tags, err: err}
+	}
+}
+
+func (m RegistryBrowserModel) delete(kind string) tea.Cmd {
+	client, repo := m.client, m.repo
+	tag, _ := m.selectedTag()
+
+	if kind == "manifest" {
+		digest := m.details.Digest
+		return func() tea.Msg {
+			err := client.DeleteManifest(repo, digest)
+			return registryActionDone{status: "deleted manifest " + digest, err: err, reloadTags: err == nil}
+		}
+	}
+
+	return func() tea.Msg {
+		err := client.DeleteTag(repo, tag)
+		return registryActionDone{status: "deleted tag " + tag, err: err, reloadTags: err == nil}
+	}
+}
+
+func (m RegistryBrowserModel) detailsView() string {
+	if m.details == nil {
+		return mutedStyle.Render("enter on a tag shows its manifest")
+	}
+
+	var res strings.Builder
+	addEntry(&res, "Reference: ", m.details.Repository+":"+m.details.Reference)
+	addEntry(&res, "Digest: ", m.details.Digest)
+	addEntry(&res, "Media Type: ", m.details.MediaType)
+	addEntry(&res, "Size: ", humanSize(m.details.Size))
+	res.WriteString("\n")
+
+	for _, platform := range m.details.Platforms {
+		addEntry(&res, platform.Platform+": ", fmt.Sprintf("%s, %d layer(s)", humanSize(platform.Size), platform.Layers))
+		res.WriteString(mutedStyle.Render("  "+platform.Digest) + "\n")
+	}
+
+	return res.String()
+}
+
+// renders items with the cursor highlighted, scrolled so the cursor stays visible
+func renderCursorList(items []string, cursor int, height int, width int, empty string) string {
+	if len(items) == 0 {
+		return mutedStyle.Render(empty)
+	}
+
+	offset := max(cursor-height+1, 0)
+	end := min(offset+height, len(items))
+
+	lines := make([]string, 0, end-offset)
+	for i := offset; i < end; i++ {
+		line := items[i]
+		if runes := []rune(line); len(runes) > width {
+			line = string(runes[:width-1]) + "…"
+		}
+
+		if i == cursor {
+			line = treeCursorStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,205 @@
+package tui
+
+import (
+	"os"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type runSpecFormat int
+
+const (
+	runSpecDockerRun runSpecFormat = iota
+	runSpecCompose
+)
+
+type runSpecLoaded struct {
+	spec *dockercmd.RunSpec
+	err  error
+}
+
+// shows a `docker run` command and a compose service that recreate the container
+type RunSpecModel struct {
+	dockerClient  dockercmd.DockerClient
+	containerId   string
+	containerName string
+	spec          *dockercmd.RunSpec
+	err           error
+	format        runSpecFormat
+	status        string
+	saving        bool
+	input         textinput.Model
+	viewport      viewport.Model
+	help          help.Model
+}
+
+func NewRunSpecModel(client dockercmd.DockerClient, containerId string, containerName string, width int, height int) RunSpecModel {
+	input := textinput.New()
+	input.Prompt = "Save to: "
+
+	m := RunSpecModel{
+		dockerClient:  client,
+		containerId:   containerId,
+		containerName: containerName,
+		input:         input,
+		viewport:      viewport.New(width, max(height-7, 5)),
+		help:          help.New(),
+	}
+	m.help.Width = width
+	return m
+}
+
+func (m RunSpecModel) Init() tea.Cmd {
+	return func() tea.Msg {
+		spec, err := m.dockerClient.ContainerRunSpec(m.containerId)
+		return runSpecLoaded{spec: spec, err: err}
+	}
+}
+
+func (m RunSpecModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case runSpecLoaded:
+		m.spec = msg.spec
+		m.err = msg.err
+		if m.spec != nil {
+			m.viewport.SetContent(m.content())
+		}
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		m.viewport.Width = msg.Width
+		m.viewport.Height = max(msg.Height-7, 5)
+		m.help.Width = msg.Width
+
+	case tea.KeyMsg:
+		if m.saving {
+			return m.updateInput(msg)
+		}
+
+		switch {
+		case key.Matches(msg, RunSpecKeymap.Back):
+			return m, closeView
+
+		case key.Matches(msg, RunSpecKeymap.SwitchFormat):
+			if m.format == runSpecDockerRun {
+				m.format = runSpecCompose
+			} else {
+				m.format = runSpecDockerRun
+			}
+			if m.spec != nil {
+				m.viewport.SetContent(m.content())
+				m.viewport.GotoTop()
+			}
+			return m, nil
+
+		case key.Matches(msg, RunSpecKeymap.Copy):
+			if m.spec == nil {
+				return m, nil
+			}
+
+			if err := clipboard.WriteAll(m.content()); err != nil {
+				m.status = "Could not copy to clipboard: " + err.Error()
+			} else {
+				m.status = "Copied to clipboard"
+			}
+			return m, nil
+
+		case key.Matches(msg, RunSpecKeymap.Save):
+			if m.spec == nil {
+				return m, nil
+			}
+
+			m.saving = true
+			if m.format == runSpecCompose {
+				m.input.SetValue("./compose.yaml")
+			} else {
+				m.input.SetValue("./" + m.spec.Name + ".sh")
+			}
+			m.input.CursorEnd()
+			return m, m.input.Focus()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m RunSpecModel) View() string {
+	title := "docker run: "
+	if m.format == runSpecCompose {
+		title = "compose service: "
+	}
+	title = viewTitleStyle.Render(title + m.containerName)
+
+	var body string
+	switch {
+	case m.err != nil:
+		body = "Error: " + m.err.Error()
+	case m.spec == nil:
+		body = "Loading..."
+	default:
+		body = m.viewport.View()
+	}
+
+	footer := mutedStyle.Render(m.status)
+	if m.saving {
+		footer = m.input.View()
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, body, footer, m.help.View(RunSpecKeymap))
+}
+
+// helpers
+
+func (m RunSpecModel) content() string {
+	if m.format == runSpecCompose {
+		return m.spec.ComposeService()
+	}
+	return m.spec.RunCommand()
+}
+
+func (m RunSpecModel) updateInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.saving = false
+		m.input.Blur()
+		return m, nil
+
+	case tea.KeyEnter:
+		m.saving = false
+		m.input.Blur()
+
+		dst := m.input.Value()
+		if dst == "" {
+			return m, nil
+		}
+
+		// refuse to clobber an existing compose file or script
+		f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.WriteString(m.content())
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}
+
+		if err != nil {
+			m.status = "Could not save: " + err.Error()
+		} else {
+			m.status = "Saved to " + dst
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,266 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// progress of saving and scanning the image, sbom is set once done
+type sbomUpdate struct {
+	transferred int64
+	done        bool
+	sbom        *dockercmd.SBOM
+	err         error
+	// channel the update was read from, so updates of a closed view can be told apart
+	updates chan sbomUpdate
+}
+
+// Lists the packages found in an image's filesystem, the list can be searched and exported as SPDX or CycloneDX.
+type SBOMModel struct {
+	name     string
+	updates  chan sbomUpdate
+	progress transferUpdate
+	sbom     *dockercmd.SBOM
+	// packages matching the search
+	filtered    []dockercmd.Package
+	err         error
+	search      textinput.Model
+	searching   bool
+	cursor      int
+	status      string
+	progressBar progress.Model
+	help        help.Model
+	width       int
+	height      int
+}
+
+func NewSBOMModel(client dockercmd.DockerClient, id string, name string, size int64, width int, height int) SBOMModel {
+	bar := progress.New(progress.WithDefaultGradient())
+	bar.Width = min(width-30, 80)
+
+	search := textinput.New()
+	search.Prompt = "/"
+
+	m := SBOMModel{
+		name:        name,
+		updates:     startSBOM(client, id),
+		progress:    transferUpdate{total: size},
+		search:      search,
+		progressBar: bar,
+		help:        help.New(),
+		width:       width,
+		height:      height,
+	}
+	m.help.Width = width
+	return m
+}
+
+func (m SBOMModel) Init() tea.Cmd {
+	return waitForSBOM(m.updates)
+}
+
+func (m SBOMModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case sbomUpdate:
+		if msg.updates != m.updates {
+			return m, nil
+		}
+
+		if !msg.done {
+			m.progress.transferred = msg.transferred
+			return m, waitForSBOM(m.updates)
+		}
+
+		m.sbom = msg.sbom
+		m.err = msg.err
+		m.applySearch()
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.help.Width = msg.Width
+
+	case tea.KeyMsg:
+		if m.searching {
+			return m.updateSearch(msg)
+		}
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m SBOMModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, SBOMKeymap.Back):
+		// the first esc clears the search
+		if m.search.Value() != "" {
+			m.search.SetValue("")
+			m.applySearch()
+			return m, nil
+		}
+		return m, closeView
+
+	case m.sbom == nil:
+		return m, nil
+
+	case key.Matches(msg, NavKeymap.NextItem):
+		m.cursor = min(m.cursor+1, max(len(m.filtered)-1, 0))
+
+	case key.Matches(msg, NavKeymap.PrevItem):
+		m.cursor = max(m.cursor-1, 0)
+
+	case key.Matches(msg, SBOMKeymap.Search):
+		m.searching = true
+		return m, m.search.Focus()
+
+	case key.Matches(msg, SBOMKeymap.ExportSPDX):
+		m.export(dockercmd.SPDXFormat, "spdx")
+
+	case key.Matches(msg, SBOMKeymap.ExportCycloneDX):
+		m.export(dockercmd.CycloneDXFormat, "cdx")
+	}
+
+	return m, nil
+}
+
+// the list is filtered while typing, enter keeps the filter and esc drops it
+func (m SBOMModel) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, SBOMKeymap.Back):
+		m.search.SetValue("")
+		fallthrough
+
+	case key.Matches(msg, NavKeymap.Enter):
+		m.searching = false
+		m.search.Blur()
+		m.applySearch()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	m.applySearch()
+	return m, cmd
+}
+
+func (m SBOMModel) View() string {
+	title := viewTitleStyle.Render("Packages: " + m.name)
+
+	if m.err != nil {
+		return lipgloss.JoinVertical(lipgloss.Left, title, "Error: "+m.err.Error(), "", m.help.View(SBOMKeymap))
+	}
+
+	if m.sbom == nil {
+		return lipgloss.JoinVertical(
+			lipgloss.Left,
+			title,
+			"Saving and scanning the image...",
+			renderTransfer(m.progressBar, m.progress),
+			"",
+			m.help.View(SBOMKeymap),
+		)
+	}
+
+	var summary strings.Builder
+	osName := m.sbom.OS.PrettyName
+	if osName == "" {
+		osName = "unknown"
+	}
+	addEntry(&summary, "OS: ", osName)
+	addEntry(&summary, "Packages: ", fmt.Sprintf("%d", len(m.sbom.Packages)))
+	for _, warning := range m.sbom.Warnings {
+		summary.WriteString(fileRemovedStyle.Render("! "+warning) + "\n")
+	}
+
+	rows := make([]string, len(m.filtered))
+	for i, pkg := range m.filtered {
+		rows[i] = fmt.Sprintf("%-7s %-40s %-24s %s", pkg.Type, pkg.Name, pkg.Version, pkg.License)
+	}
+
+	empty := "no packages found"
+	if m.search.Value() != "" {
+		empty = "no packages match the search"
+	}
+	height := max(m.height-12-len(m.sbom.Warnings), 5)
+	list := renderCursorList(rows, m.cursor, height, max(m.width-4, 20), empty)
+
+	var footer string
+	switch {
+	case m.searching || m.search.Value() != "":
+		footer = m.search.View()
+	case len(m.filtered) > 0:
+		pkg := m.filtered[m.cursor]
+		footer = mutedStyle.Render(pkg.PURL(m.sbom.OS) + "  found in /" + pkg.Source)
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		summary.String(),
+		list,
+		"",
+		footer,
+		m.status,
+		m.help.View(SBOMKeymap),
+	)
+}
+
+// helpers
+
+func (m *SBOMModel) applySearch() {
+	if m.sbom == nil {
+		return
+	}
+	m.filtered = dockercmd.FilterPackages(m.sbom.Packages, m.search.Value())
+	m.cursor = max(min(m.cursor, len(m.filtered)-1), 0)
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// writes the full SBOM (the search does not apply) next to the working directory
+func (m *SBOMModel) export(format dockercmd.SBOMFormat, extension string) {
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(m.name, "_"), "_")
+	path := fmt.Sprintf("./%s-%s.%s.json", name, time.Now().Format("20060102-150405"), extension)
+
+	if err := m.sbom.Write(path, format); err != nil {
+		m.status = fileRemovedStyle.Render(err.Error())
+		return
+	}
+	m.status = fileAddedStyle.Render("exported to " + path)
+}
+
+// scans the image on a seperate goroutine, updates are delivered by the cmd returned from waitForSBOM
+func startSBOM(client dockercmd.DockerClient, id string) chan sbomUpdate {
+	// room for one progress update and the result, so the goroutine never blocks if the view is closed
+	updates := make(chan sbomUpdate, 2)
+
+	go func() {
+		sbom, err := client.GenerateSBOM(id, func(n int64) {
+			// drop updates when the ui is lagging behind, only the latest one matters
+			if len(updates) == 0 {
+				updates <- sbomUpdate{transferred: n, updates: updates}
+			}
+		})
+
+		updates <- sbomUpdate{done: true, sbom: sbom, err: err, updates: updates}
+	}()
+
+	return updates
+}
+
+func waitForSBOM(updates chan sbomUpdate) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
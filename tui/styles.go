@@ -41,4 +41,34 @@ var (
 	containerCreatedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("118"))
 	containerDeadStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("88"))
 	containerRestartingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("200"))
+	volumeOrphanedStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("172"))
+	imageOutdatedStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+
+	lintHighStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Bold(true)
+	lintMediumStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	lintLowStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+
+	fileAddedStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("41"))
+	fileModifiedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	fileRemovedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("160"))
+	fileUnchangedStyle = lipgloss.NewStyle()
+	treeCursorStyle    = lipgloss.NewStyle().Background(highlightColor).Foreground(lipgloss.Color("255"))
+
+	viewTitleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("49")).Bold(true).MarginBottom(1)
+	viewPaneStyle  = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder()).
+			BorderForeground(lipgloss.Color("69"))
+	viewActivePaneStyle = viewPaneStyle.Copy().BorderForeground(highlightColor)
+	mutedStyle          = lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+
+	formDialogStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(highlightColor).
+			Padding(1, 2)
+	formFieldStyle       = lipgloss.NewStyle().PaddingLeft(2).MarginBottom(1)
+	formActiveFieldStyle = formFieldStyle.Copy().
+				Border(lipgloss.NormalBorder(), false, false, false, true).
+				BorderForeground(highlightColor).
+				PaddingLeft(1)
+	formInputStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
 )
@@ -1,9 +1,14 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,16 +23,24 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/volume"
 )
 
 type tabId int
 type TickMsg time.Time
 type preloadObjects int
 
+// sent by full screen views (eg: layer explorer) when the user wants to go back to the tabs
+type closeViewMsg struct{}
+
+func closeView() tea.Msg { return closeViewMsg{} }
+
 const (
 	images tabId = iota
 	containers
 	volumes
+	buildCache
+	networks
 )
 
 // INFO: temporary fix to performance hiccups
@@ -37,6 +50,24 @@ const showContainerSize = false
 var containerSizeMap map[string]ContainerSize = make(map[string]ContainerSize)
 var containerSizeMap_Mutex sync.Mutex = sync.Mutex{}
 
+// INFO: registry update status of the images used by running containers, keyed by image id. Filled on demand
+var imageUpdateMap map[string]dockercmd.ImageUpdateStatus = make(map[string]dockercmd.ImageUpdateStatus)
+var imageUpdateMap_Mutex sync.Mutex = sync.Mutex{}
+
+// INFO: layer ids of images for the image tree, keyed by image id. Layers of an image never change, so entries are
+// only ever added
+var imageLayersMap map[string][]string = make(map[string][]string)
+var imageLayersMap_Mutex sync.Mutex = sync.Mutex{}
+
+// INFO: security lint findings, keyed by container id or imageLintKey. Filled by the lint view and the risky filter
+var lintFindingsMap map[string]lintEntry = make(map[string]lintEntry)
+var lintFindingsMap_Mutex sync.Mutex = sync.Mutex{}
+
+// sent once the registries were asked for updates
+type imageUpdatesChecked struct {
+	err error
+}
+
 type Model struct {
 	dockerClient dockercmd.DockerClient
 	Tabs         []string
@@ -46,6 +77,11 @@ type Model struct {
 	height       int
 	showDialog   bool
 	activeDialog tea.Model
+	// full screen views replace the tabs until they send closeViewMsg
+	showView   bool
+	activeView tea.Model
+	// locations to return to after jumping to related objects
+	jumpHistory []jumpLocation
 	// we use this error channel to report error for possibly long running tasks, like pruneing
 	possibleLongRunningOpErrorChan chan error
 	windowTooSmall                 bool
@@ -66,9 +102,9 @@ func (m Model) Init() tea.Cmd {
 }
 
 func NewModel(tabs []string) Model {
-	contents := make([]listModel, 3)
+	contents := make([]listModel, 5)
 
-	for i, tabKind := range []tabId{images, containers, volumes} {
+	for i, tabKind := range []tabId{images, containers, volumes, buildCache, networks} {
 		contents[i] = InitList(tabKind)
 	}
 
@@ -103,23 +139,87 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.showDialog {
 
 		update, cmd := m.activeDialog.Update(msg)
-		if d, ok := update.(teadialog.Dialog); ok {
-			m.activeDialog = d
-		}
+		m.activeDialog = update
 
 		if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, NavKeymap.Enter) || key.Matches(msg, NavKeymap.Back) {
 			m.showDialog = false
 		}
 
+		cmds = append(cmds, cmd)
+	} else if m.showView {
+		// views get every message, since they usually wait on their own async results
+		update, cmd := m.activeView.Update(msg)
+		m.activeView = update
 		cmds = append(cmds, cmd)
 	}
 
 	switch msg := msg.(type) {
+	case closeViewMsg:
+		m.showView = false
+		m.activeView = nil
+
+	case commandOutputMsg:
+		// the output view was closed before the command finished, keep draining so the command does not block
+		if view, ok := m.activeView.(CommandOutputModel); !m.showView || !ok || view.output != msg.output {
+			if !msg.done {
+				cmds = append(cmds, waitForCommandOutput(msg.output))
+			} else if msg.err != nil {
+				m.possibleLongRunningOpErrorChan <- msg.err
+			}
+		}
+
+	case transferViewUpdate:
+		// the transfer view was closed before the transfer finished, keep waiting so errors are not lost
+		if view, ok := m.activeView.(TransferModel); !m.showView || !ok || view.updates != msg.updates {
+			if !msg.done {
+				cmds = append(cmds, waitForTransferView(msg.updates))
+			} else if msg.err != nil {
+				m.possibleLongRunningOpErrorChan <- msg.err
+			}
+		}
+
+	case pushUpdate:
+		// the push view was closed before the push finished, keep draining so the push does not block
+		if view, ok := m.activeView.(PushModel); !m.showView || !ok || view.updates != msg.updates {
+			if !msg.done {
+				cmds = append(cmds, waitForPush(msg.updates))
+			} else if msg.err != nil {
+				m.possibleLongRunningOpErrorChan <- errors.New(pushErrorString(msg.err))
+			}
+		}
+
+	case migrationUpdate:
+		// the migration view was closed before the migration finished, keep draining so the migration does not block
+		if view, ok := m.activeView.(MigrationModel); !m.showView || !ok || view.updates != msg.updates {
+			if !msg.done {
+				cmds = append(cmds, waitForMigration(msg.updates))
+			} else if msg.err != nil {
+				m.possibleLongRunningOpErrorChan <- msg.err
+			}
+		}
+
+	case lintJumpMsg:
+		m.showView = false
+		m.activeView = nil
+
+		if err := m.jumpTo(msg.target); err != nil {
+			m.activeDialog = teadialog.NewErrorDialog(err.Error(), m.width)
+			m.showDialog = true
+		}
+
+	case imageUpdatesChecked:
+		if msg.err != nil {
+			m.activeDialog = teadialog.NewErrorDialog(msg.err.Error(), m.width)
+			m.showDialog = true
+		}
+
 	//preloads all tabs, so no delay in displaying objects when first changing tabs
 	case preloadObjects:
 		m = m.updateContent(0)
 		m = m.updateContent(1)
 		m = m.updateContent(2)
+		m = m.updateContent(3)
+		m = m.updateContent(4)
 
 	case TickMsg:
 		m = m.updateContent(m.activeTab)
@@ -154,7 +254,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case tea.KeyMsg:
-		if !m.getActiveList().SettingFilter() && !m.showDialog {
+		if !m.getActiveList().SettingFilter() && !m.showDialog && !m.showView {
 			switch {
 			case key.Matches(msg, NavKeymap.Quit):
 				return m, tea.Quit
@@ -162,14 +262,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.nextTab()
 			case key.Matches(msg, NavKeymap.PrevTab):
 				m.prevTab()
+			case key.Matches(msg, NavKeymap.JumpTo):
+				if item, ok := m.getSelectedItem().(dockerRes); ok {
+					cmds = append(cmds, m.showJumpTargets(item))
+				}
+			case key.Matches(msg, NavKeymap.JumpBack):
+				m.jumpBack()
 			}
 
 			if m.activeTab == int(images) {
 				switch {
 				case key.Matches(msg, ImageKeymap.Delete):
 					curItem := m.getSelectedItem()
-					if curItem != nil {
-						imageId := curItem.(dockerRes).getId()
+					// shared layer chains of the tree can not be deleted themselves
+					if imageInfo, ok := curItem.(imageItem); ok {
+						imageId := imageInfo.getId()
 						storage := map[string]string{"ID": imageId}
 						m.activeDialog = getRemoveImageDialog(storage)
 						m.showDialog = true
@@ -196,17 +303,174 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						}
 					}
 
+				case key.Matches(msg, ImageKeymap.SBOM):
+					if imageInfo, ok := m.getSelectedItem().(imageItem); ok {
+						name := strings.TrimPrefix(imageInfo.ID, "sha256:")[:12]
+						if tags := pushableTags(imageInfo.RepoTags); len(tags) > 0 {
+							name = tags[0]
+						}
+
+						m.activeView = NewSBOMModel(m.dockerClient, imageInfo.getId(), name, imageInfo.Size, m.width, m.height)
+						m.showView = true
+						cmds = append(cmds, m.activeView.Init())
+					}
+
+				case key.Matches(msg, ImageKeymap.Lint):
+					m.activeView = NewLintModel(m.dockerClient, m.width, m.height)
+					m.showView = true
+					cmds = append(cmds, m.activeView.Init())
+
+				case key.Matches(msg, ImageKeymap.OnlyRisky):
+					m.setOnlyRisky(images, !m.TabContent[images].onlyRisky)
+
+				case key.Matches(msg, ImageKeymap.ToggleTree):
+					m.TabContent[images].imageTree = !m.TabContent[images].imageTree
+					// container counts and shared sizes might be stale
+					m.TabContent[images].sharedSizeKey = ""
+					m = m.updateContent(int(images))
+
 				case key.Matches(msg, ImageKeymap.Prune):
 					m.activeDialog = getPruneImagesDialog(make(map[string]string))
 					m.showDialog = true
 					cmds = append(cmds, m.activeDialog.Init())
+
+				case key.Matches(msg, ImageKeymap.ExploreLayers):
+					curItem := m.getSelectedItem()
+					if imageInfo, ok := curItem.(imageItem); ok {
+						m.activeView = NewImageLayersModel(m.dockerClient, imageInfo.getId(), imageInfo.getName(), m.width, m.height)
+						m.showView = true
+						cmds = append(cmds, m.activeView.Init())
+					}
+
+				case key.Matches(msg, ImageKeymap.Inspect):
+					curItem := m.getSelectedItem()
+					if imageInfo, ok := curItem.(imageItem); ok {
+						m.activeView = NewImageDetailsModel(m.dockerClient, imageInfo.getId(), imageInfo.getName(), m.width, m.height)
+						m.showView = true
+						cmds = append(cmds, m.activeView.Init())
+					}
+
+				case key.Matches(msg, ImageKeymap.CheckUpdates):
+					cmds = append(cmds, checkImageUpdates(m.dockerClient))
+
+				case key.Matches(msg, ImageKeymap.Update):
+					refs := outdatedImageRefs()
+					if len(refs) == 0 {
+						m.activeDialog = teadialog.NewErrorDialog("No outdated images found, check for updates first", m.width)
+						m.showDialog = true
+						break
+					}
+
+					m.activeDialog = getUpdateImagesDialog(refs, map[string]string{"refs": strings.Join(refs, " ")})
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
+
+				case key.Matches(msg, ImageKeymap.Push):
+					imageInfo, ok := m.getSelectedItem().(imageItem)
+					if !ok {
+						break
+					}
+
+					tags := pushableTags(imageInfo.RepoTags)
+					if len(tags) == 0 {
+						m.activeDialog = teadialog.NewErrorDialog("Image has no tag to push", m.width)
+						m.showDialog = true
+						break
+					}
+
+					m.activeDialog = getPushImageDialog(tags, map[string]string{})
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
+
+				case key.Matches(msg, ImageKeymap.Login):
+					// most likely the registry of the selected image
+					storage := map[string]string{"server": m.selectedImageRegistry()}
+
+					m.activeDialog = getRegistryLoginDialog(storage)
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
+
+				case key.Matches(msg, ImageKeymap.Browse):
+					// docker hub has no catalog, a local registry is the best guess
+					storage := map[string]string{"server": "localhost:5000"}
+					if server := m.selectedImageRegistry(); server != "" {
+						storage["server"] = server
+					}
+
+					m.activeDialog = getBrowseRegistryDialog(storage)
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
+
+				case key.Matches(msg, ImageKeymap.Save):
+					storage := map[string]string{}
+					if imageInfo, ok := m.getSelectedItem().(imageItem); ok {
+						// tags keep the names in the archive, untagged images can only be saved by id
+						if tags := pushableTags(imageInfo.RepoTags); len(tags) > 0 {
+							storage["images"] = strings.Join(tags, " ")
+						} else {
+							storage["images"] = strings.TrimPrefix(imageInfo.ID, "sha256:")[:12]
+						}
+					}
+
+					m.activeDialog = getSaveImagesDialog(storage)
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
+
+				case key.Matches(msg, ImageKeymap.Load):
+					m.activeDialog = getLoadImagesDialog(map[string]string{})
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
+
+				case key.Matches(msg, ImageKeymap.Import):
+					m.activeDialog = getImportImageDialog(map[string]string{})
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
+
+				case key.Matches(msg, ImageKeymap.Logout):
+					servers, err := m.dockerClient.LoggedInRegistries()
+					if err == nil && len(servers) == 0 {
+						err = errors.New("Not logged in to any registry")
+					}
+					if err != nil {
+						m.activeDialog = teadialog.NewErrorDialog(err.Error(), m.width)
+						m.showDialog = true
+						break
+					}
+
+					m.activeDialog = getRegistryLogoutDialog(servers, map[string]string{})
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
 				}
 
 			} else if m.activeTab == int(containers) {
+				project, isProject := m.getSelectedItem().(composeProjectItem)
+
 				switch {
+				case isProject && key.Matches(msg, ContainerKeymap.ToggleProject, ContainerKeymap.ToggleStartStop, ContainerKeymap.Restart,
+					ContainerKeymap.Delete, ContainerKeymap.DeleteForce, ContainerKeymap.TogglePause, ContainerKeymap.Exec,
+					ContainerKeymap.Diff, ContainerKeymap.BrowseFiles):
+					// project rows act on every container of the project, container only actions are ignored
+					cmds = append(cmds, m.handleComposeProjectKeys(msg, project))
+
 				case key.Matches(msg, ContainerKeymap.ToggleListAll):
 					m.dockerClient.ToggleContainerListAll()
 
+				case key.Matches(msg, ContainerKeymap.Compose):
+					storage := map[string]string{"files": "compose.yaml"}
+
+					// prefill from the labels compose put on the selected project's containers
+					if isProject && len(project.containers) > 0 {
+						labels := project.containers[0].Labels
+						storage["project"] = project.name
+						if files := labels[dockercmd.ComposeConfigFilesLabel]; files != "" {
+							storage["files"] = strings.ReplaceAll(files, ",", " ")
+						}
+					}
+
+					m.activeDialog = getComposeDialog(storage)
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
+
 				case key.Matches(msg, ContainerKeymap.ToggleStartStop):
 					log.Println("s pressed")
 					curItem := m.getSelectedItem()
@@ -279,10 +543,91 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					// execs into the default shell of the container (got from lazydocker)
 					cmd := exec.Command("docker", "exec", "-it", containerId, "/bin/sh", "-c", "eval $(grep ^$(id -un): /etc/passwd | cut -d : -f 7-)")
 					cmds = append(cmds, tea.ExecProcess(cmd, nil))
+
+				case key.Matches(msg, ContainerKeymap.Diff):
+					curItem := m.getSelectedItem()
+					if containerInfo, ok := curItem.(containerItem); ok {
+						m.activeView = NewContainerDiffModel(m.dockerClient, containerInfo.getId(), containerInfo.getName(), m.width, m.height)
+						m.showView = true
+						cmds = append(cmds, m.activeView.Init())
+					}
+
+				case key.Matches(msg, ContainerKeymap.RunSpec):
+					curItem := m.getSelectedItem()
+					if containerInfo, ok := curItem.(containerItem); ok {
+						m.activeView = NewRunSpecModel(m.dockerClient, containerInfo.getId(), containerInfo.getName(), m.width, m.height)
+						m.showView = true
+						cmds = append(cmds, m.activeView.Init())
+					}
+
+				case key.Matches(msg, ContainerKeymap.Recreate):
+					curItem := m.getSelectedItem()
+					if containerInfo, ok := curItem.(containerItem); ok {
+						m.activeDialog = getRecreateContainerDialog(map[string]string{"ID": containerInfo.getId(), "name": containerInfo.getName()})
+						m.showDialog = true
+						cmds = append(cmds, m.activeDialog.Init())
+					}
+
+				case key.Matches(msg, ContainerKeymap.Export, ContainerKeymap.Commit):
+					curItem := m.getSelectedItem()
+					if containerInfo, ok := curItem.(containerItem); ok {
+						// name is shown in the dialog, baseName (a single name without the slash) goes into paths and refs
+						storage := map[string]string{
+							"ID":       containerInfo.getId(),
+							"name":     containerInfo.getName(),
+							"baseName": dockercmd.ContainerName(containerInfo.Container),
+						}
+
+						if key.Matches(msg, ContainerKeymap.Export) {
+							m.activeDialog = getExportContainerDialog(storage)
+						} else {
+							m.activeDialog = getCommitContainerDialog(storage)
+						}
+						m.showDialog = true
+						cmds = append(cmds, m.activeDialog.Init())
+					}
+
+				case key.Matches(msg, ContainerKeymap.Lint):
+					m.activeView = NewLintModel(m.dockerClient, m.width, m.height)
+					m.showView = true
+					cmds = append(cmds, m.activeView.Init())
+
+				case key.Matches(msg, ContainerKeymap.OnlyRisky):
+					m.setOnlyRisky(containers, !m.TabContent[containers].onlyRisky)
+
+				case key.Matches(msg, ContainerKeymap.Migrate):
+					curItem := m.getSelectedItem()
+					if containerInfo, ok := curItem.(containerItem); ok {
+						// without contexts the address has to be typed in
+						hosts, _ := dockercmd.DockerContextHosts()
+						storage := map[string]string{"ID": containerInfo.getId(), "name": containerInfo.getName()}
+						m.activeDialog = getMigrateContainerDialog(hosts, storage)
+						m.showDialog = true
+						cmds = append(cmds, m.activeDialog.Init())
+					}
+
+				case key.Matches(msg, ContainerKeymap.BrowseFiles):
+					curItem := m.getSelectedItem()
+					if containerInfo, ok := curItem.(containerItem); ok {
+						m.activeView = NewContainerFilesModel(m.dockerClient, containerInfo.getId(), containerInfo.getName(), m.width, m.height)
+						m.showView = true
+						cmds = append(cmds, m.activeView.Init())
+					}
 				}
 
 			} else if m.activeTab == int(volumes) {
 				switch {
+				case key.Matches(msg, VolumeKeymap.Create):
+					// only used as a hint in the dialog, creating still works if this fails
+					drivers, err := m.dockerClient.VolumeDrivers()
+					if err != nil {
+						log.Println("could not list volume drivers", err)
+					}
+
+					m.activeDialog = getCreateVolumeDialog(drivers, make(map[string]string))
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
+
 				case key.Matches(msg, VolumeKeymap.Prune):
 					log.Println("Volume prune called")
 					curItem := m.getSelectedItem()
@@ -297,13 +642,85 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 					curItem := m.getSelectedItem()
 
+					if volumeInfo, ok := curItem.(VolumeItem); ok {
+						volumeId := volumeInfo.getId()
+
+						// the daemon refuses to remove volumes referenced by any container, offer to remove those first
+						if len(volumeInfo.mounts) > 0 {
+							users := make([]string, len(volumeInfo.mounts))
+							for i, mount := range volumeInfo.mounts {
+								users[i] = mount.ContainerName
+							}
+							m.activeDialog = getRemoveVolumeInUseDialog(users, map[string]string{"ID": volumeId})
+						} else {
+							m.activeDialog = getRemoveVolumeDialog(map[string]string{"ID": volumeId})
+						}
+
+						m.showDialog = true
+						cmds = append(cmds, m.activeDialog.Init())
+					}
+
+				case key.Matches(msg, VolumeKeymap.Browse):
+					curItem := m.getSelectedItem()
+					if volumeInfo, ok := curItem.(VolumeItem); ok {
+						m.activeView = NewVolumeFilesModel(m.dockerClient, volumeInfo.getId(), m.width, m.height)
+						m.showView = true
+						cmds = append(cmds, m.activeView.Init())
+					}
+
+				case key.Matches(msg, VolumeKeymap.Backup):
+					curItem := m.getSelectedItem()
+					if curItem != nil {
+						volumeId := curItem.(dockerRes).getId()
+						m.activeDialog = getBackupVolumeDialog(map[string]string{"ID": volumeId})
+						m.showDialog = true
+						cmds = append(cmds, m.activeDialog.Init())
+					}
+
+				case key.Matches(msg, VolumeKeymap.Restore):
+					storage := make(map[string]string)
+					if curItem := m.getSelectedItem(); curItem != nil {
+						storage["ID"] = curItem.(dockerRes).getId()
+					}
+					m.activeDialog = getRestoreVolumeDialog(storage)
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
+
+				case key.Matches(msg, VolumeKeymap.Clone):
+					curItem := m.getSelectedItem()
+					if curItem != nil {
+						volumeId := curItem.(dockerRes).getId()
+						m.activeDialog = getCloneVolumeDialog(map[string]string{"ID": volumeId})
+						m.showDialog = true
+						cmds = append(cmds, m.activeDialog.Init())
+					}
+
+				case key.Matches(msg, VolumeKeymap.Rename):
+					curItem := m.getSelectedItem()
 					if curItem != nil {
 						volumeId := curItem.(dockerRes).getId()
-						m.activeDialog = getRemoveVolumeDialog(map[string]string{"ID": volumeId})
+						m.activeDialog = getRenameVolumeDialog(map[string]string{"ID": volumeId})
 						m.showDialog = true
 						cmds = append(cmds, m.activeDialog.Init())
 					}
 				}
+			} else if m.activeTab == int(buildCache) {
+				switch {
+				case key.Matches(msg, BuildCacheKeymap.Delete):
+					curItem := m.getSelectedItem()
+
+					if curItem != nil {
+						recordId := curItem.(dockerRes).getId()
+						m.activeDialog = getRemoveBuildCacheDialog(map[string]string{"ID": recordId})
+						m.showDialog = true
+						cmds = append(cmds, m.activeDialog.Init())
+					}
+
+				case key.Matches(msg, BuildCacheKeymap.Prune):
+					m.activeDialog = getPruneBuildCacheDialog(make(map[string]string))
+					m.showDialog = true
+					cmds = append(cmds, m.activeDialog.Init())
+				}
 			}
 
 		}
@@ -403,6 +820,425 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+		case dialogBackupVolume:
+			volumeId := dialogRes.UserStorage["ID"]
+			archivePath := dialogRes.UserChoices["path"].(string)
+
+			if volumeId != "" && archivePath != "" {
+				m.activeView = NewTransferModel("Backing up "+volumeId+" to "+archivePath, 0, m.width, func(progress dockercmd.ProgressFunc) error {
+					return m.dockerClient.BackupVolume(volumeId, archivePath, progress)
+				})
+				m.showView = true
+				cmds = append(cmds, m.activeView.Init())
+			}
+
+		case dialogRestoreVolume:
+			archivePath := dialogRes.UserChoices["path"].(string)
+			volumeName := dialogRes.UserChoices["volume"].(string)
+
+			if archivePath == "" || volumeName == "" {
+				break
+			}
+
+			exists, err := m.dockerClient.VolumeExists(volumeName)
+			if err != nil {
+				m.activeDialog = teadialog.NewErrorDialog(err.Error(), m.width)
+				m.showDialog = true
+			} else if exists {
+				m.activeDialog = getConfirmRestoreVolumeDialog(map[string]string{"path": archivePath, "volume": volumeName})
+				m.showDialog = true
+				cmds = append(cmds, m.activeDialog.Init())
+			} else {
+				cmds = append(cmds, m.startVolumeRestore(archivePath, volumeName))
+			}
+
+		case dialogConfirmRestoreVolume:
+			if dialogRes.UserChoices["confirm"] == "Yes" {
+				cmds = append(cmds, m.startVolumeRestore(dialogRes.UserStorage["path"], dialogRes.UserStorage["volume"]))
+			}
+
+		case dialogCloneVolume:
+			volumeId := dialogRes.UserStorage["ID"]
+			newName := dialogRes.UserChoices["name"].(string)
+
+			if volumeId != "" && newName != "" {
+				m.activeView = NewTransferModel("Cloning "+volumeId+" to "+newName, 0, m.width, func(progress dockercmd.ProgressFunc) error {
+					return m.dockerClient.CloneVolume(volumeId, newName, progress)
+				})
+				m.showView = true
+				cmds = append(cmds, m.activeView.Init())
+			}
+
+		case dialogRenameVolume:
+			volumeId := dialogRes.UserStorage["ID"]
+			newName := dialogRes.UserChoices["name"].(string)
+
+			if volumeId != "" && newName != "" && newName != volumeId {
+				m.activeView = NewTransferModel("Renaming "+volumeId+" to "+newName, 0, m.width, func(progress dockercmd.ProgressFunc) error {
+					return m.dockerClient.RenameVolume(volumeId, newName, progress)
+				})
+				m.showView = true
+				cmds = append(cmds, m.activeView.Init())
+			}
+
+		case dialogCreateVolume:
+			userChoice := dialogRes.UserChoices
+
+			opts := volume.CreateOptions{
+				Name:   userChoice["name"].(string),
+				Driver: userChoice["driver"].(string),
+			}
+
+			var err error
+			if opts.DriverOpts, err = dockercmd.ParseKeyValuePairs(userChoice["driverOpts"].(string)); err == nil {
+				opts.Labels, err = dockercmd.ParseKeyValuePairs(userChoice["labels"].(string))
+			}
+
+			if err == nil {
+				_, err = m.dockerClient.CreateVolume(opts)
+			}
+
+			if err != nil {
+				m.activeDialog = teadialog.NewErrorDialog(err.Error(), m.width)
+				m.showDialog = true
+			}
+
+		case dialogRemoveVolumeInUse:
+			volumeId := dialogRes.UserStorage["ID"]
+
+			if dialogRes.UserChoices["confirm"] == "Yes" && volumeId != "" {
+				// stopping containers can take a while
+				go func() {
+					if err := m.dockerClient.DeleteVolumeAndUsers(volumeId); err != nil {
+						m.possibleLongRunningOpErrorChan <- err
+					}
+				}()
+			}
+
+		case dialogJumpTo:
+			if label, ok := dialogRes.UserChoices["target"].(string); ok {
+				if target, ok := decodeJumpTarget(label, dialogRes.UserStorage); ok {
+					if err := m.jumpTo(target); err != nil {
+						m.activeDialog = teadialog.NewErrorDialog(err.Error(), m.width)
+						m.showDialog = true
+					}
+				}
+			}
+
+		case dialogRemoveComposeProject:
+			userChoice := dialogRes.UserChoices
+			project := dialogRes.UserStorage["project"]
+
+			opts := container.RemoveOptions{
+				RemoveVolumes: userChoice["remVols"].(bool),
+				Force:         userChoice["force"].(bool),
+			}
+
+			if project != "" {
+				go func() {
+					if err := m.dockerClient.RemoveComposeProject(project, opts); err != nil {
+						m.possibleLongRunningOpErrorChan <- err
+					}
+				}()
+			}
+
+		case dialogCompose:
+			userChoice := dialogRes.UserChoices
+
+			opts := dockercmd.ComposeOpts{
+				Action:        dockercmd.ComposeAction(userChoice["action"].(string)),
+				ConfigFiles:   strings.Fields(userChoice["files"].(string)),
+				ProjectName:   userChoice["project"].(string),
+				Services:      strings.Fields(userChoice["services"].(string)),
+				ForceRecreate: userChoice["forceRecreate"].(bool),
+				RemoveVolumes: userChoice["removeVolumes"].(bool),
+				RemoveOrphans: userChoice["removeOrphans"].(bool),
+			}
+
+			var err error
+			opts.Scale, err = dockercmd.ParseKeyValuePairs(userChoice["scale"].(string))
+			if err == nil {
+				// surface invalid combinations before starting the view
+				_, err = dockercmd.ComposeArgs(opts)
+			}
+
+			if err != nil {
+				m.activeDialog = teadialog.NewErrorDialog(err.Error(), m.width)
+				m.showDialog = true
+				break
+			}
+
+			title := "docker compose " + string(opts.Action)
+			if opts.ProjectName != "" {
+				title += ": " + opts.ProjectName
+			}
+
+			m.activeView = NewCommandOutputModel(title, m.width, m.height, func(output io.Writer) error {
+				return m.dockerClient.RunCompose(opts, output)
+			})
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogRecreateContainer:
+			userChoice := dialogRes.UserChoices
+			containerId := dialogRes.UserStorage["ID"]
+
+			seconds, err := strconv.Atoi(userChoice["healthTimeout"].(string))
+			// with 0 a container with a healthcheck would be rolled back before its first check ran
+			if err != nil || seconds <= 0 {
+				m.activeDialog = teadialog.NewErrorDialog("health timeout has to be a positive number of seconds", m.width)
+				m.showDialog = true
+				break
+			}
+
+			opts := dockercmd.RecreateOpts{
+				Pull:          userChoice["pull"].(bool),
+				HealthTimeout: time.Duration(seconds) * time.Second,
+			}
+
+			m.activeView = NewCommandOutputModel("Recreating "+dialogRes.UserStorage["name"], m.width, m.height, func(output io.Writer) error {
+				return m.dockerClient.RecreateContainer(containerId, opts, output)
+			})
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogUpdateImages:
+			refs := strings.Fields(dialogRes.UserStorage["refs"])
+			if dialogRes.UserChoices["confirm"] != "Yes" || len(refs) == 0 {
+				break
+			}
+
+			m.activeView = NewCommandOutputModel("Updating outdated images", m.width, m.height, func(output io.Writer) error {
+				err := m.dockerClient.UpdateImages(refs, output)
+
+				// the updated containers run new image ids now, ask again so stale markers go away
+				if checkErr := refreshImageUpdates(m.dockerClient); checkErr != nil {
+					fmt.Fprintf(output, "could not check for updates: %s\n", checkErr)
+				}
+				return err
+			})
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogRegistryLogin:
+			userChoice := dialogRes.UserChoices
+			server := userChoice["server"].(string)
+			username := userChoice["username"].(string)
+			password := userChoice["password"].(string)
+
+			if username == "" || password == "" {
+				m.activeDialog = teadialog.NewErrorDialog("Username and password are required", m.width)
+				m.showDialog = true
+				break
+			}
+
+			title := "Logging in to docker hub"
+			if server != "" {
+				title = "Logging in to " + server
+			}
+
+			m.activeView = NewCommandOutputModel(title, m.width, m.height, func(output io.Writer) error {
+				if err := m.dockerClient.RegistryLogin(server, username, password); err != nil {
+					return err
+				}
+				fmt.Fprintf(output, "Login succeeded, credentials saved to %s\n", dockercmd.DockerConfigPath())
+				return nil
+			})
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogRegistryLogout:
+			server := dialogRes.UserChoices["server"].(string)
+			if err := m.dockerClient.RegistryLogout(server); err != nil {
+				m.activeDialog = teadialog.NewErrorDialog(err.Error(), m.width)
+				m.showDialog = true
+			}
+
+		case dialogPushImage:
+			userChoice := dialogRes.UserChoices
+			source := userChoice["tag"].(string)
+			ref := source
+			if as := userChoice["as"].(string); as != "" {
+				ref = as
+			}
+
+			if _, err := dockercmd.RegistryServerOf(ref); err != nil {
+				m.activeDialog = teadialog.NewErrorDialog("Invalid image reference: "+err.Error(), m.width)
+				m.showDialog = true
+				break
+			}
+
+			m.activeView = NewPushModel(m.dockerClient, source, ref, m.width)
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogBrowseRegistry:
+			server := dialogRes.UserChoices["server"].(string)
+			if server == "" {
+				break
+			}
+
+			m.activeView = NewRegistryBrowserModel(m.dockerClient, server, m.width, m.height)
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogSaveImages:
+			refs := strings.Fields(dialogRes.UserChoices["images"].(string))
+			archivePath := dialogRes.UserChoices["path"].(string)
+			if len(refs) == 0 || archivePath == "" {
+				break
+			}
+
+			// unknown (0) if an image does not exist, saving reports the error
+			total, _ := m.dockerClient.ImagesSize(refs)
+
+			m.activeView = NewTransferModel("Saving "+strings.Join(refs, ", ")+" to "+archivePath, total, m.width, func(progress dockercmd.ProgressFunc) error {
+				return m.dockerClient.SaveImages(refs, archivePath, progress)
+			})
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogLoadImages:
+			archivePath := dialogRes.UserChoices["path"].(string)
+			if archivePath == "" {
+				break
+			}
+
+			m.activeView = NewTransferModel("Loading "+archivePath, fileSize(archivePath), m.width, func(progress dockercmd.ProgressFunc) error {
+				return m.dockerClient.LoadImages(archivePath, progress)
+			})
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogImportImage:
+			userChoice := dialogRes.UserChoices
+			archivePath := userChoice["path"].(string)
+			if archivePath == "" {
+				break
+			}
+
+			opts := dockercmd.ImageImportOpts{
+				Ref:     userChoice["ref"].(string),
+				Message: userChoice["message"].(string),
+				Changes: dockercmd.ParseImageChanges(userChoice["changes"].(string)),
+			}
+
+			m.activeView = NewTransferModel("Importing "+archivePath, fileSize(archivePath), m.width, func(progress dockercmd.ProgressFunc) error {
+				return m.dockerClient.ImportImage(archivePath, opts, progress)
+			})
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogExportContainer:
+			containerId := dialogRes.UserStorage["ID"]
+			archivePath := dialogRes.UserChoices["path"].(string)
+			if containerId == "" || archivePath == "" {
+				break
+			}
+
+			// the root filesystem size is only known if it was calculated for the info box
+			var total int64
+			containerSizeMap_Mutex.Lock()
+			if size, ok := containerSizeMap[containerId]; ok {
+				total = size.rootFs
+			}
+			containerSizeMap_Mutex.Unlock()
+
+			m.activeView = NewTransferModel("Exporting "+dialogRes.UserStorage["name"]+" to "+archivePath, total, m.width, func(progress dockercmd.ProgressFunc) error {
+				return m.dockerClient.ExportContainer(containerId, archivePath, progress)
+			})
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogCommitContainer:
+			userChoice := dialogRes.UserChoices
+			containerId := dialogRes.UserStorage["ID"]
+			name := dialogRes.UserStorage["name"]
+
+			opts := dockercmd.CommitOpts{
+				Ref:     userChoice["ref"].(string),
+				Author:  userChoice["author"].(string),
+				Message: userChoice["message"].(string),
+				Pause:   userChoice["pause"].(bool),
+				Changes: dockercmd.ParseImageChanges(userChoice["changes"].(string)),
+			}
+
+			if opts.Ref != "" {
+				if _, err := dockercmd.RegistryServerOf(opts.Ref); err != nil {
+					m.activeDialog = teadialog.NewErrorDialog("Invalid image reference: "+err.Error(), m.width)
+					m.showDialog = true
+					break
+				}
+			}
+
+			// committing a big container takes a while
+			m.activeView = NewCommandOutputModel("Committing "+name, m.width, m.height, func(output io.Writer) error {
+				fmt.Fprintf(output, "committing %s\n", name)
+				imageId, err := m.dockerClient.CommitContainer(containerId, opts)
+				if err != nil {
+					return err
+				}
+
+				if opts.Ref != "" {
+					fmt.Fprintf(output, "created %s (%s)\n", opts.Ref, imageId)
+				} else {
+					fmt.Fprintf(output, "created %s\n", imageId)
+				}
+				return nil
+			})
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogMigrateContainer:
+			userChoice := dialogRes.UserChoices
+			host, err := migrationTarget(userChoice)
+			if err != nil {
+				m.activeDialog = teadialog.NewErrorDialog(err.Error(), m.width)
+				m.showDialog = true
+				break
+			}
+
+			opts := dockercmd.MigrateOpts{RemoveSource: userChoice["remove"].(bool)}
+			m.activeView = NewMigrationModel(m.dockerClient, dialogRes.UserStorage["ID"], dialogRes.UserStorage["name"], host, opts, m.width)
+			m.showView = true
+			cmds = append(cmds, m.activeView.Init())
+
+		case dialogRemoveBuildCache:
+			userChoice := dialogRes.UserChoices
+			recordId := dialogRes.UserStorage["ID"]
+
+			if userChoice["confirm"] == "Yes" && recordId != "" {
+				go func() {
+					// `All` is required, otherwise records that are not dangling are never pruned
+					_, err := m.dockerClient.PruneBuildCache(dockercmd.BuildCachePruneOpts{
+						All: true,
+						Ids: []string{recordId},
+					})
+
+					if err != nil {
+						m.possibleLongRunningOpErrorChan <- err
+					}
+				}()
+			}
+
+		case dialogPruneBuildCache:
+			userChoice := dialogRes.UserChoices
+
+			if userChoice["confirm"] == "Yes" {
+				keepStorage, _ := userChoice["keepStorage"].(string)
+				until, _ := userChoice["until"].(string)
+				opts := dockercmd.MakeBuildCachePruneOpts(userChoice["all"].(bool), keepStorage, until)
+
+				//build cache can be huge, prune on a seperate goroutine
+				go func() {
+					_, err := m.dockerClient.PruneBuildCache(opts)
+
+					if err != nil {
+						m.possibleLongRunningOpErrorChan <- err
+					}
+				}()
+			}
+
 		case dialogRemoveImage:
 			log.Println("remove image instruction recieved")
 			userChoice := dialogRes.UserChoices
@@ -426,8 +1262,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	var cmd tea.Cmd
-	//do not pass key.msg to list if dialog is active, otherwise tui updates to navigation keys
-	if !m.showDialog {
+	//do not pass key.msg to list if dialog or view is active, otherwise tui updates to navigation keys
+	if !m.showDialog && !m.showView {
 		m.TabContent[m.activeTab].list, cmd = m.TabContent[m.activeTab].list.Update(msg)
 		cmds = append(cmds, cmd)
 	}
@@ -452,6 +1288,11 @@ func (m Model) View() string {
 	if m.showDialog {
 		return dialogContainerStyle.Render(m.activeDialog.View())
 	}
+
+	if m.showView {
+		return docStyle.Render(m.activeView.View())
+	}
+
 	doc := strings.Builder{}
 
 	var renderedTabs []string
@@ -509,6 +1350,8 @@ func (m Model) View() string {
 		tabSpecificKeyBinds = m.helpGen.View(ContainerKeymap)
 	case int(volumes):
 		tabSpecificKeyBinds = m.helpGen.View(VolumeKeymap)
+	case int(buildCache):
+		tabSpecificKeyBinds = m.helpGen.View(BuildCacheKeymap)
 	}
 
 	body_with_help := lipgloss.JoinVertical(lipgloss.Top, body_with_info, "  "+m.navKeymap.View(NavKeymap), "  "+tabSpecificKeyBinds)
@@ -531,7 +1374,7 @@ func (m Model) updateContent(currentTab int) Model {
 //Util
 
 func (m *Model) nextTab() {
-	if m.activeTab == int(volumes) {
+	if m.activeTab == int(networks) {
 		m.activeTab = int(images)
 	} else {
 		m.activeTab += 1
@@ -540,7 +1383,7 @@ func (m *Model) nextTab() {
 
 func (m *Model) prevTab() {
 	if m.activeTab == int(images) {
-		m.activeTab = int(volumes)
+		m.activeTab = int(networks)
 	} else {
 		m.activeTab -= 1
 	}
@@ -584,3 +1427,145 @@ func updateContainerSizeMap(containerInfo *types.ContainerJSON) {
 	}
 	containerSizeMap_Mutex.Unlock()
 }
+
+// registry of the selected image's first tag, empty for docker hub or untagged images
+func (m Model) selectedImageRegistry() string {
+	imageInfo, ok := m.getSelectedItem().(imageItem)
+	if !ok {
+		return ""
+	}
+
+	tags := pushableTags(imageInfo.RepoTags)
+	if len(tags) == 0 {
+		return ""
+	}
+
+	server, err := dockercmd.RegistryServerOf(tags[0])
+	if err != nil || server == dockercmd.DockerHubServer {
+		return ""
+	}
+	return server
+}
+
+// asks the registries for newer versions of the images used by running containers, in the background
+func checkImageUpdates(client dockercmd.DockerClient) tea.Cmd {
+	return func() tea.Msg {
+		return imageUpdatesChecked{err: refreshImageUpdates(client)}
+	}
+}
+
+func refreshImageUpdates(client dockercmd.DockerClient) error {
+	statuses, err := client.CheckImageUpdates()
+	if err != nil {
+		return err
+	}
+
+	imageUpdateMap_Mutex.Lock()
+	imageUpdateMap = statuses
+	imageUpdateMap_Mutex.Unlock()
+	return nil
+}
+
+func getImageUpdateStatus(imageId string) (dockercmd.ImageUpdateStatus, bool) {
+	imageUpdateMap_Mutex.Lock()
+	defer imageUpdateMap_Mutex.Unlock()
+
+	status, ok := imageUpdateMap[imageId]
+	return status, ok
+}
+
+// refs of all images with a newer version in their registry, sorted
+func outdatedImageRefs() []string {
+	imageUpdateMap_Mutex.Lock()
+	defer imageUpdateMap_Mutex.Unlock()
+
+	var refs []string
+	for _, status := range imageUpdateMap {
+		if status.Outdated && !slices.Contains(refs, status.Ref) {
+			refs = append(refs, status.Ref)
+		}
+	}
+	slices.Sort(refs)
+	return refs
+}
+
+// size of the file at path, 0 (unknown to transfer views) if it can not be read
+func fileSize(path string) int64 {
+	if info, err := os.Stat(path); err == nil {
+		return info.Size()
+	}
+	return 0
+}
+
+// the host picked in the migrate dialog, a typed in address wins over the selected context
+func migrationTarget(userChoice map[string]any) (dockercmd.DockerHost, error) {
+	if address := userChoice["host"].(string); address != "" {
+		if !strings.Contains(address, "://") {
+			return dockercmd.DockerHost{}, fmt.Errorf("%s: the address needs a scheme, eg: ssh://%s", address, address)
+		}
+		return dockercmd.DockerHost{Host: address}, nil
+	}
+
+	if selected, ok := userChoice["context"].(string); ok {
+		hosts, err := dockercmd.DockerContextHosts()
+		if err != nil {
+			return dockercmd.DockerHost{}, err
+		}
+		for _, host := range hosts {
+			if host.String() == selected {
+				return host, nil
+			}
+		}
+	}
+
+	return dockercmd.DockerHost{}, errors.New("no target host given")
+}
+
+// opens a transfer view restoring archivePath into volumeName, progress is measured on the (compressed) archive
+func (m *Model) startVolumeRestore(archivePath string, volumeName string) tea.Cmd {
+	m.activeView = NewTransferModel("Restoring "+archivePath+" into "+volumeName, fileSize(archivePath), m.width, func(progress dockercmd.ProgressFunc) error {
+		return m.dockerClient.RestoreVolume(archivePath, volumeName, progress)
+	})
+	m.showView = true
+	return m.activeView.Init()
+}
+
+func (m *Model) handleComposeProjectKeys(msg tea.KeyMsg, project composeProjectItem) tea.Cmd {
+	// stopping or restarting a whole project can take a while, run on a seperate goroutine
+	runAsync := func(fn func(string) error) {
+		go func() {
+			if err := fn(project.name); err != nil {
+				m.possibleLongRunningOpErrorChan <- err
+			}
+		}()
+	}
+
+	switch {
+	case key.Matches(msg, ContainerKeymap.ToggleProject):
+		collapsed := m.TabContent[containers].collapsedProjects
+		collapsed[project.name] = !collapsed[project.name]
+		*m = m.updateContent(int(containers))
+
+	case key.Matches(msg, ContainerKeymap.ToggleStartStop):
+		if project.running() > 0 {
+			runAsync(m.dockerClient.StopComposeProject)
+		} else {
+			runAsync(m.dockerClient.StartComposeProject)
+		}
+
+	case key.Matches(msg, ContainerKeymap.Restart):
+		runAsync(m.dockerClient.RestartComposeProject)
+
+	case key.Matches(msg, ContainerKeymap.Delete):
+		m.activeDialog = getRemoveComposeProjectDialog(map[string]string{"project": project.name})
+		m.showDialog = true
+		return m.activeDialog.Init()
+
+	case key.Matches(msg, ContainerKeymap.DeleteForce):
+		runAsync(func(name string) error {
+			return m.dockerClient.RemoveComposeProject(name, container.RemoveOptions{Force: true})
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// progress of a long running transfer, eg: copying files out of a container
+type transferUpdate struct {
+	transferred int64
+	// 0 when the size is not known upfront
+	total int64
+	done  bool
+	err   error
+}
+
+// runs fn on a seperate goroutine, updates are delivered by the cmd returned from waitForTransfer
+func startTransfer(total int64, fn func(progress dockercmd.ProgressFunc) error) (chan transferUpdate, tea.Cmd) {
+	// room for one progress update and the final one, so the goroutine never blocks if the view is closed
+	updates := make(chan transferUpdate, 2)
+
+	go func() {
+		var transferred int64
+		err := fn(func(n int64) {
+			transferred = n
+			// drop updates when the ui is lagging behind, only the latest one matters
+			if len(updates) == 0 {
+				updates <- transferUpdate{transferred: n, total: total}
+			}
+		})
+
+		updates <- transferUpdate{transferred: transferred, total: total, done: true, err: err}
+	}()
+
+	return updates, waitForTransfer(updates)
+}
+
+func waitForTransfer(updates chan transferUpdate) tea.Cmd {
+	return func() tea.Msg {
+		return <-updates
+	}
+}
+
+func renderTransfer(bar progress.Model, update transferUpdate) string {
+	if update.total <= 0 {
+		return humanSize(update.transferred) + " transferred"
+	}
+
+	percent := min(float64(update.transferred)/float64(update.total), 1)
+	return fmt.Sprintf("%s  %s / %s", bar.ViewAs(percent), humanSize(update.transferred), humanSize(update.total))
+}
+
+// sent to TransferModel, carries the channel it was read from so updates of a closed view can be told apart
+type transferViewUpdate struct {
+	transferUpdate
+	updates chan transferUpdate
+}
+
+// full screen view for a single transfer that is not tied to another view, eg: backing up a volume.
+// Closing the view does not cancel the transfer, Model keeps waiting on it and reports errors as a dialog.
+type TransferModel struct {
+	title       string
+	updates     chan transferUpdate
+	last        transferUpdate
+	progressBar progress.Model
+	help        help.Model
+}
+
+func NewTransferModel(title string, total int64, width int, fn func(progress dockercmd.ProgressFunc) error) TransferModel {
+	bar := progress.New(progress.WithDefaultGradient())
+	bar.Width = min(width-30, 80)
+
+	updates, _ := startTransfer(total, fn)
+
+	return TransferModel{
+		title:       title,
+		updates:     updates,
+		last:        transferUpdate{total: total},
+		progressBar: bar,
+		help:        help.New(),
+	}
+}
+
+func (m TransferModel) Init() tea.Cmd {
+	return waitForTransferView(m.updates)
+}
+
+func (m TransferModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case transferViewUpdate:
+		if msg.updates != m.updates {
+			return m, nil
+		}
+
+		m.last = msg.transferUpdate
+		if !msg.done {
+			return m, waitForTransferView(m.updates)
+		}
+
+	case tea.KeyMsg:
+		if key.Matches(msg, TransferKeymap.Back) {
+			return m, closeView
+		}
+	}
+
+	return m, nil
+}
+
+func (m TransferModel) View() string {
+	var status string
+	switch {
+	case !m.last.done:
+		status = "in progress, esc continues in the background"
+	case m.last.err != nil:
+		status = fileRemovedStyle.Render("failed: " + m.last.err.Error())
+	default:
+		status = fileAddedStyle.Render("done")
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		viewTitleStyle.Render(m.title),
+		renderTransfer(m.progressBar, m.last),
+		"",
+		status,
+		"",
+		m.help.View(TransferKeymap),
+	)
+}
+
+func waitForTransferView(updates chan transferUpdate) tea.Cmd {
+	return func() tea.Msg {
+		return transferViewUpdate{transferUpdate: <-updates, updates: updates}
+	}
+}
@@ -2,11 +2,14 @@ package tui
 
 import (
 	"cmp"
+	"fmt"
 	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ajayd-san/gomanagedocker/dockercmd"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/image"
@@ -50,6 +53,65 @@ type dockerRes interface {
 
 type imageItem struct {
 	image.Summary
+	tree imageTreeRow
+}
+
+// position of an image (or shared layer chain) in the image tree
+type imageTreeRow struct {
+	// set when the images tab shows the tree
+	shown bool
+	// drawn before the title, eg: `│  ├─ `
+	prefix string
+	// continues the tree lines next to the description
+	descPrefix   string
+	layers       int
+	sharedLayers int
+	uniqueSize   int64
+	descendants  int
+}
+
+// a chain of layers several images build on, that is not an image itself
+type imageChainItem struct {
+	tree imageTreeRow
+}
+
+// flattens the tree into rows, children follow their parent
+func makeImageTreeItems(nodes []*dockercmd.ImageTreeNode) []dockerRes {
+	var res []dockerRes
+
+	var walk func(nodes []*dockercmd.ImageTreeNode, indent string, roots bool)
+	walk = func(nodes []*dockercmd.ImageTreeNode, indent string, roots bool) {
+		for i, node := range nodes {
+			branch, continuation := "├─ ", "│  "
+			if i == len(nodes)-1 {
+				branch, continuation = "└─ ", "   "
+			}
+			if roots {
+				branch, continuation = "", ""
+			}
+
+			row := imageTreeRow{
+				shown:        true,
+				prefix:       indent + branch,
+				descPrefix:   indent + continuation,
+				layers:       node.Layers,
+				sharedLayers: node.SharedLayers,
+				uniqueSize:   node.UniqueSize(),
+				descendants:  node.Descendants(),
+			}
+
+			if node.Image != nil {
+				res = append(res, imageItem{Summary: *node.Image, tree: row})
+			} else {
+				res = append(res, imageChainItem{tree: row})
+			}
+
+			walk(node.Children, indent+continuation, false)
+		}
+	}
+
+	walk(nodes, "", true)
+	return res
 }
 
 func makeImageItems(dockerlist []image.Summary) []dockerRes {
@@ -81,7 +143,12 @@ func (i imageItem) getName() string {
 }
 
 // INFO: impl list.Item Interface
-func (i imageItem) Title() string { return i.getName() }
+func (i imageItem) Title() string {
+	if status, ok := getImageUpdateStatus(i.ID); ok && status.Outdated {
+		return i.tree.prefix + i.getName() + imageOutdatedStyle.Render("  update available")
+	}
+	return i.tree.prefix + i.getName()
+}
 
 func (i imageItem) Description() string {
 	id := i.getId()
@@ -90,17 +157,43 @@ func (i imageItem) Description() string {
 
 	sizeStr := strconv.FormatFloat(i.getSize(), 'f', 2, 64) + "GB"
 
-	return shortId + "\t\t\t\t\t\t\t" + sizeStr
+	if i.tree.shown && i.tree.uniqueSize >= 0 {
+		sizeStr += "  (" + humanSize(i.tree.uniqueSize) + " unique)"
+	}
+
+	return i.tree.descPrefix + shortId + "\t\t\t\t\t\t\t" + sizeStr
 }
 
 func (i imageItem) FilterValue() string { return i.getName() }
 
+// INFO: impl dockerRes Interface, a chain can not be acted on so it has no id
+func (i imageChainItem) getId() string       { return "" }
+func (i imageChainItem) getSize() float64    { return 0 }
+func (i imageChainItem) getLabel() string    { return "" }
+func (i imageChainItem) getName() string     { return fmt.Sprintf("%d shared layers", i.tree.layers) }
+func (i imageChainItem) FilterValue() string { return "" }
+
+// INFO: impl list.Item Interface
+func (i imageChainItem) Title() string {
+	return i.tree.prefix + mutedStyle.Render(i.getName())
+}
+
+func (i imageChainItem) Description() string {
+	return i.tree.descPrefix + mutedStyle.Render(fmt.Sprintf("not an image, base of %d images", i.tree.descendants))
+}
+
 type containerItem struct {
 	types.Container
+	// set for containers listed under a compose project row
+	grouped bool
 }
 
-func makeContainerItems(dockerlist []types.Container) []dockerRes {
-	res := make([]dockerRes, len(dockerlist))
+// Compose projects are listed first, each project row is followed by its containers (unless collapsed), containers
+// that do not belong to a project come last
+// projectMembers holds all containers of each compose project, nil when they could not be listed; the listed ones are
+// used for the running counts then
+func makeContainerItems(dockerlist []types.Container, projectMembers map[string][]types.Container, collapsedProjects map[string]bool) []dockerRes {
+	res := make([]dockerRes, 0, len(dockerlist))
 
 	slices.SortFunc(dockerlist, func(a types.Container, b types.Container) int {
 
@@ -114,13 +207,61 @@ func makeContainerItems(dockerlist []types.Container) []dockerRes {
 		return cmp.Compare(a.Names[0], b.Names[0])
 	})
 
-	for i := range dockerlist {
-		res[i] = containerItem{Container: dockerlist[i]}
+	projects := make(map[string][]types.Container)
+	var standalone []types.Container
+
+	for _, c := range dockerlist {
+		if project := c.Labels[dockercmd.ComposeProjectLabel]; project != "" {
+			projects[project] = append(projects[project], c)
+		} else {
+			standalone = append(standalone, c)
+		}
+	}
+
+	projectNames := make([]string, 0, len(projects))
+	for name := range projects {
+		projectNames = append(projectNames, name)
+	}
+	slices.Sort(projectNames)
+
+	for _, name := range projectNames {
+		members := projects[name]
+
+		// services in a stable order, instead of by state, so rows do not jump around on start/stop
+		slices.SortFunc(members, func(a types.Container, b types.Container) int {
+			if c := cmp.Compare(a.Labels[dockercmd.ComposeServiceLabel], b.Labels[dockercmd.ComposeServiceLabel]); c != 0 {
+				return c
+			}
+			return cmp.Compare(replicaNumber(a), replicaNumber(b))
+		})
+
+		all := members
+		if projectMembers != nil {
+			all = projectMembers[name]
+		}
+
+		collapsed := collapsedProjects[name]
+		res = append(res, composeProjectItem{name: name, containers: members, members: all, collapsed: collapsed})
+
+		if !collapsed {
+			for _, c := range members {
+				res = append(res, containerItem{Container: c, grouped: true})
+			}
+		}
+	}
+
+	for _, c := range standalone {
+		res = append(res, containerItem{Container: c})
 	}
 
 	return res
 }
 
+func replicaNumber(c types.Container) int {
+	n, _ := strconv.Atoi(c.Labels[dockercmd.ComposeContainerNumberLabel])
+	return n
+}
+
 // INFO: impl dockerRes Interface
 func (c containerItem) getId() string {
 	return c.ID
@@ -139,7 +280,18 @@ func (c containerItem) getName() string {
 }
 
 // INFO: impl list.Item Interface
-func (i containerItem) Title() string { return i.getName() }
+func (i containerItem) Title() string {
+	if !i.grouped {
+		return i.getName()
+	}
+
+	service := i.Labels[dockercmd.ComposeServiceLabel]
+	if number := i.Labels[dockercmd.ComposeContainerNumberLabel]; number != "" {
+		service += " #" + number
+	}
+
+	return "  " + service + mutedStyle.Render("  "+i.getName())
+}
 func (i containerItem) Description() string {
 
 	id := i.getId()
@@ -160,13 +312,86 @@ func (i containerItem) Description() string {
 		state = containerDeadStyle.Render(state)
 	}
 
+	if status, ok := getImageUpdateStatus(i.ImageID); ok && status.Outdated {
+		state += imageOutdatedStyle.Render("  image outdated")
+	}
+
 	return shortId + "\t\t\t\t\t\t\t" + state
 }
 
 func (i containerItem) FilterValue() string { return i.getLabel() }
 
+// a row grouping the containers of a docker compose project
+type composeProjectItem struct {
+	name string
+	// only the containers currently listed, stopped ones are missing unless listing all containers
+	containers []types.Container
+	// every container of the project, running or not
+	members   []types.Container
+	collapsed bool
+}
+
+func (p composeProjectItem) getId() string {
+	return "compose-project:" + p.name
+}
+
+func (p composeProjectItem) getSize() float64 {
+	var size int64
+	for _, c := range p.containers {
+		size += c.SizeRw
+	}
+	return float64(size) / float64(1e+9)
+}
+
+func (p composeProjectItem) getLabel() string {
+	return p.name
+}
+
+func (p composeProjectItem) getName() string {
+	return p.name
+}
+
+func (p composeProjectItem) running() int {
+	running := 0
+	for _, c := range p.members {
+		if c.State == "running" {
+			running++
+		}
+	}
+	return running
+}
+
+func (p composeProjectItem) Title() string {
+	marker := "▾ "
+	if p.collapsed {
+		marker = "▸ "
+	}
+	return marker + p.name
+}
+
+func (p composeProjectItem) Description() string {
+	status := fmt.Sprintf("%d/%d running", p.running(), len(p.members))
+
+	switch p.running() {
+	case len(p.members):
+		status = containerRunningStyle.Render(status)
+	case 0:
+		status = containerExitedStyle.Render(status)
+	default:
+		status = containerRestartingStyle.Render(status)
+	}
+
+	return "compose project" + "\t\t\t\t\t\t\t" + status
+}
+
+func (p composeProjectItem) FilterValue() string { return p.name }
+
 type VolumeItem struct {
 	volume.Volume
+	// containers mounting this volume, empty for orphaned volumes
+	mounts []dockercmd.VolumeMount
+	// false when the containers could not be listed, the volume must not be shown as orphaned then
+	mountsKnown bool
 }
 
 func (v VolumeItem) FilterValue() string {
@@ -192,14 +417,103 @@ func (v VolumeItem) getSize() float64 {
 	return float64(v.UsageData.Size)
 }
 
-func (i VolumeItem) Title() string       { return i.getName() }
-func (i VolumeItem) Description() string { return "" }
+func (i VolumeItem) Title() string { return i.getName() }
 
-func makeVolumeItem(dockerlist []*volume.Volume) []dockerRes {
+func (i VolumeItem) Description() string {
+	if !i.mountsKnown {
+		return "usage unknown"
+	}
+	if len(i.mounts) == 0 {
+		return volumeOrphanedStyle.Render("orphaned")
+	}
+	return fmt.Sprintf("used by %d container(s)", len(i.mounts))
+}
+
+// mounts is nil when listing the containers failed
+func makeVolumeItem(dockerlist []*volume.Volume, mounts map[string][]dockercmd.VolumeMount) []dockerRes {
 	res := make([]dockerRes, len(dockerlist))
 
 	for i, volume := range dockerlist {
-		res[i] = VolumeItem{Volume: *volume}
+		res[i] = VolumeItem{Volume: *volume, mounts: mounts[volume.Name], mountsKnown: mounts != nil}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].getName() < res[j].getName()
+	})
+
+	return res
+}
+
+type buildCacheItem struct {
+	types.BuildCache
+}
+
+func makeBuildCacheItems(dockerlist []*types.BuildCache) []dockerRes {
+	res := make([]dockerRes, len(dockerlist))
+
+	for i, record := range dockerlist {
+		res[i] = buildCacheItem{BuildCache: *record}
+	}
+
+	// largest records first, since that is what users usually want to get rid of
+	slices.SortStableFunc(res, func(a dockerRes, b dockerRes) int {
+		if c := cmp.Compare(b.(buildCacheItem).Size, a.(buildCacheItem).Size); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.getId(), b.getId())
+	})
+
+	return res
+}
+
+// INFO: impl dockerRes Interface
+func (b buildCacheItem) getId() string {
+	return b.ID
+}
+
+func (b buildCacheItem) getSize() float64 {
+	return float64(b.Size) / float64(1e+9)
+}
+
+func (b buildCacheItem) getLabel() string {
+	return b.BuildCache.Description
+}
+
+func (b buildCacheItem) getName() string {
+	// `Description` is shadowed by list.Item's Description()
+	if b.BuildCache.Description == "" {
+		return b.ID
+	}
+	return b.BuildCache.Description
+}
+
+func (b buildCacheItem) lastUsed() time.Time {
+	if b.LastUsedAt == nil {
+		return b.CreatedAt
+	}
+	return *b.LastUsedAt
+}
+
+// INFO: impl list.Item Interface
+func (b buildCacheItem) Title() string { return b.getName() }
+
+func (b buildCacheItem) Description() string {
+	shortId := b.ID[:min(12, len(b.ID))]
+
+	return shortId + "\t\t" + b.Type + "\t\t" + humanSize(b.Size)
+}
+
+func (b buildCacheItem) FilterValue() string { return b.Type + " " + b.getName() }
+
+type networkItem struct {
+	types.NetworkResource
+}
+
+func makeNetworkItems(dockerlist []types.NetworkResource) []dockerRes {
+	res := make([]dockerRes, len(dockerlist))
+
+	for i, network := range dockerlist {
+		res[i] = networkItem{NetworkResource: network}
 	}
 
 	sort.Slice(res, func(i, j int) bool {
@@ -208,3 +522,39 @@ func makeVolumeItem(dockerlist []*volume.Volume) []dockerRes {
 
 	return res
 }
+
+func (n networkItem) subnets() []string {
+	var res []string
+	for _, config := range n.IPAM.Config {
+		if config.Subnet != "" {
+			res = append(res, config.Subnet)
+		}
+	}
+	return res
+}
+
+// INFO: impl dockerRes Interface
+func (n networkItem) getId() string {
+	return n.ID
+}
+
+func (n networkItem) getSize() float64 {
+	return -1
+}
+
+func (n networkItem) getLabel() string {
+	return n.Driver
+}
+
+func (n networkItem) getName() string {
+	return n.Name
+}
+
+// INFO: impl list.Item Interface
+func (n networkItem) Title() string { return n.getName() }
+
+func (n networkItem) Description() string {
+	return n.ID[:min(12, len(n.ID))] + "\t\t" + n.Driver + "\t\t" + strings.Join(n.subnets(), ", ")
+}
+
+func (n networkItem) FilterValue() string { return n.getName() }